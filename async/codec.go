@@ -0,0 +1,81 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"encoding/json"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+var (
+	_ Codec = (*JSONCodec)(nil)
+	_ Codec = (*TypedJSONCodec[int])(nil)
+)
+
+// Codec serializes work items so they can be written to a write ahead log.
+//
+// Items replayed from the log when a queue starts are the values returned by `Decode`, so the
+// codec must restore the types the queue's action expects; see `TypedJSONCodec`.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// JSONCodec serializes work items as json.
+//
+// Without `New`, replayed work items lose their types: structs come back as
+// `map[string]interface{}` and numbers as `float64`. Set `New` to return a pointer to
+// the work item type, or use a `TypedJSONCodec` when the items have a single type.
+type JSONCodec struct {
+	New func() interface{}
+}
+
+// Encode implements Codec.
+func (jc JSONCodec) Encode(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	return data, nil
+}
+
+// Decode implements Codec.
+func (jc JSONCodec) Decode(data []byte) (interface{}, error) {
+	if jc.New == nil {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, ex.New(err)
+		}
+		return value, nil
+	}
+	value := jc.New()
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, ex.New(err)
+	}
+	return value, nil
+}
+
+// TypedJSONCodec serializes work items of a given type as json, and replays them as `T`.
+//
+// It is the codec to use with a `TypedQueue`, whose actions reject items of other types.
+type TypedJSONCodec[T any] struct{}
+
+// Encode implements Codec.
+func (tjc TypedJSONCodec[T]) Encode(value interface{}) ([]byte, error) {
+	return JSONCodec{}.Encode(value)
+}
+
+// Decode implements Codec.
+func (tjc TypedJSONCodec[T]) Decode(data []byte) (interface{}, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, ex.New(err)
+	}
+	return value, nil
+}
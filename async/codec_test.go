@@ -0,0 +1,40 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+type codecTestItem struct {
+	Name string
+}
+
+func TestJSONCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := JSONCodec{}.Encode(codecTestItem{Name: "foo"})
+	assert.Nil(err)
+
+	value, err := JSONCodec{}.Decode(data)
+	assert.Nil(err)
+	assert.Equal(map[string]interface{}{"Name": "foo"}, value)
+
+	value, err = JSONCodec{New: func() interface{} { return new(codecTestItem) }}.Decode(data)
+	assert.Nil(err)
+	assert.Equal(&codecTestItem{Name: "foo"}, value)
+
+	value, err = TypedJSONCodec[codecTestItem]{}.Decode(data)
+	assert.Nil(err)
+	assert.Equal(codecTestItem{Name: "foo"}, value)
+
+	_, err = JSONCodec{}.Decode([]byte("{"))
+	assert.NotNil(err)
+}
@@ -27,3 +27,10 @@ const (
 	DefaultInterval            = 500 * time.Millisecond
 	DefaultShutdownGracePeriod = 10 * time.Second
 )
+
+// WAL defaults
+const (
+	DefaultWALMaxSegmentBytes = 4 << 20
+	DefaultWALCompactionRatio = 0.5
+	DefaultWALFileMode        = 0600
+)
@@ -8,5 +8,13 @@ Use of this source code is governed by a MIT license that can be found in the LI
 /*
 Package async provides syncronization primitives and background workers.
 This is a core package that is used by a lot of other packages.
+
+`TypedQueue` and `TypedBatch` are generic variants of `Queue` and `Batch`, and `Pipeline`
+chains typed stages with per stage parallelism and bounded buffers.
+
+A `Queue` can be made durable across restarts with `OptQueueWAL`, which appends enqueued work items
+to a segmented write ahead log on local disk (see `OpenWAL`) and acknowledges them once the action succeeds.
+Replayed items are decoded by the queue's `Codec`; use a `TypedJSONCodec` (or a `JSONCodec` with `New` set)
+so they keep their types.
 */
 package async // import "github.com/blend/go-sdk/async"
@@ -13,4 +13,13 @@ import "github.com/blend/go-sdk/ex"
 var (
 	ErrCannotStart ex.Class = "cannot start; already started"
 	ErrCannotStop  ex.Class = "cannot stop; already stopped"
+	// ErrUnexpectedWorkType is returned by typed actions when a work item is not of the expected type.
+	ErrUnexpectedWorkType ex.Class = "unexpected work item type"
+)
+
+// WAL errors
+var (
+	ErrWALFull    ex.Class = "write ahead log is full"
+	ErrWALCorrupt ex.Class = "write ahead log segment is corrupt"
+	ErrWALClosed  ex.Class = "write ahead log is closed"
 )
@@ -0,0 +1,84 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+NewPipeline returns a new pipeline for a given context.
+
+Stages are added with the generic functions `FromSlice`, `FromChannel` and `Then`,
+and results are gathered with `Collect` or `CollectOrdered`:
+
+	p := async.NewPipeline(ctx)
+	ids := async.FromSlice(p, []string{"a", "b", "c"})
+	users := async.Then(ids, fetchUser, async.OptStageParallelism(8))
+	results, err := async.CollectOrdered(users)
+
+The first error returned (or panic raised) by any stage cancels the pipeline's context.
+*/
+func NewPipeline(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pipeline{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Pipeline is a group of goroutines that process work in stages.
+//
+// It is similar to an errgroup; the first error cancels the group context
+// and is returned by `Wait`.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// Context returns the pipeline context.
+// It is canceled on the first error or when `Wait` returns.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Go runs an action on a new goroutine as part of the pipeline.
+// Panics are recovered and treated as errors.
+func (p *Pipeline) Go(action ContextAction) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		errors := make(chan error, 1)
+		Recover(func() error { return action(p.ctx) }, errors)
+		select {
+		case err := <-errors:
+			p.fail(err)
+		default:
+		}
+	}()
+}
+
+// Wait blocks until all the pipeline goroutines return, and returns the first error.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	return p.err
+}
+
+// fail records the first error and cancels the pipeline.
+func (p *Pipeline) fail(err error) {
+	p.errOnce.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
@@ -0,0 +1,196 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// StageAction is a typed action for a pipeline stage.
+type StageAction[In, Out any] func(context.Context, In) (Out, error)
+
+// StageOptions are options for a pipeline stage.
+type StageOptions struct {
+	// Parallelism is the number of workers for the stage.
+	Parallelism int
+	// Buffer is the number of results the stage can hold before its workers block.
+	Buffer int
+}
+
+// StageOption mutates stage options.
+type StageOption func(*StageOptions)
+
+// OptStageParallelism sets the stage parallelism, or the number of workers to create.
+func OptStageParallelism(parallelism int) StageOption {
+	return func(so *StageOptions) {
+		so.Parallelism = parallelism
+	}
+}
+
+// OptStageBuffer sets the stage output buffer size.
+func OptStageBuffer(buffer int) StageOption {
+	return func(so *StageOptions) {
+		so.Buffer = buffer
+	}
+}
+
+// Stage is a typed step in a pipeline.
+type Stage[T any] struct {
+	Pipeline *Pipeline
+	output   chan stageItem[T]
+}
+
+// stageItem is a value tagged with its position in the pipeline source.
+type stageItem[T any] struct {
+	Index int
+	Value T
+}
+
+// FromSlice returns a stage that emits the given items.
+func FromSlice[T any](p *Pipeline, items []T) *Stage[T] {
+	output := make(chan stageItem[T])
+	p.Go(func(ctx context.Context) error {
+		defer close(output)
+		for index, item := range items {
+			select {
+			case output <- stageItem[T]{Index: index, Value: item}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	})
+	return &Stage[T]{Pipeline: p, output: output}
+}
+
+// FromChannel returns a stage that emits items read from a channel until it is closed.
+func FromChannel[T any](p *Pipeline, items <-chan T) *Stage[T] {
+	output := make(chan stageItem[T])
+	p.Go(func(ctx context.Context) error {
+		defer close(output)
+		var index int
+		for {
+			select {
+			case item, ok := <-items:
+				if !ok {
+					return nil
+				}
+				select {
+				case output <- stageItem[T]{Index: index, Value: item}:
+					index++
+				case <-ctx.Done():
+					return nil
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+	return &Stage[T]{Pipeline: p, output: output}
+}
+
+// Then returns a stage that applies an action to each item of a given stage.
+//
+// Workers block when the stage buffer is full, applying backpressure to upstream stages.
+func Then[In, Out any](input *Stage[In], action StageAction[In, Out], options ...StageOption) *Stage[Out] {
+	stageOptions := StageOptions{
+		Parallelism: runtime.NumCPU(),
+	}
+	for _, option := range options {
+		option(&stageOptions)
+	}
+	if stageOptions.Parallelism < 1 {
+		stageOptions.Parallelism = 1
+	}
+	if stageOptions.Buffer < 0 {
+		stageOptions.Buffer = 0
+	}
+
+	p := input.Pipeline
+	output := make(chan stageItem[Out], stageOptions.Buffer)
+	wg := new(sync.WaitGroup)
+	wg.Add(stageOptions.Parallelism)
+	for x := 0; x < stageOptions.Parallelism; x++ {
+		p.Go(func(ctx context.Context) error {
+			defer wg.Done()
+			for item := range input.output {
+				if ctx.Err() != nil {
+					return nil
+				}
+				result, err := action(ctx, item.Value)
+				if err != nil {
+					return err
+				}
+				select {
+				case output <- stageItem[Out]{Index: item.Index, Value: result}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
+	}
+	p.Go(func(_ context.Context) error {
+		wg.Wait()
+		close(output)
+		return nil
+	})
+	return &Stage[Out]{Pipeline: p, output: output}
+}
+
+// Collect reads the results of a stage in the order they complete
+// and waits for the pipeline, returning the first error.
+func Collect[T any](stage *Stage[T]) ([]T, error) {
+	items := collect(stage)
+	if err := stage.Pipeline.Wait(); err != nil {
+		return nil, err
+	}
+	results := make([]T, len(items))
+	for index, item := range items {
+		results[index] = item.Value
+	}
+	return results, nil
+}
+
+// CollectOrdered reads the results of a stage in the order of the pipeline source
+// and waits for the pipeline, returning the first error.
+func CollectOrdered[T any](stage *Stage[T]) ([]T, error) {
+	items := collect(stage)
+	if err := stage.Pipeline.Wait(); err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Index < items[j].Index
+	})
+	results := make([]T, len(items))
+	for index, item := range items {
+		results[index] = item.Value
+	}
+	return results, nil
+}
+
+// collect reads a stage's output until it is closed or the pipeline is canceled.
+func collect[T any](stage *Stage[T]) (items []stageItem[T]) {
+	ctx := stage.Pipeline.Context()
+	for {
+		select {
+		case item, ok := <-stage.output:
+			if !ok {
+				return
+			}
+			items = append(items, item)
+		case <-ctx.Done():
+			// records the parent context error if the pipeline has not failed already.
+			stage.Pipeline.fail(ctx.Err())
+			return
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestPipelineCollectOrdered(t *testing.T) {
+	assert := assert.New(t)
+
+	items := make([]int, 100)
+	for x := range items {
+		items[x] = x
+	}
+
+	p := NewPipeline(context.Background())
+	doubled := Then(FromSlice(p, items), func(_ context.Context, v int) (int, error) {
+		// finish out of order
+		time.Sleep(time.Duration(v%3) * time.Millisecond)
+		return v * 2, nil
+	}, OptStageParallelism(8))
+	formatted := Then(doubled, func(_ context.Context, v int) (string, error) {
+		return strconv.Itoa(v), nil
+	}, OptStageParallelism(2), OptStageBuffer(4))
+
+	results, err := CollectOrdered(formatted)
+	assert.Nil(err)
+	assert.Len(results, 100)
+	for x, result := range results {
+		assert.Equal(strconv.Itoa(x*2), result)
+	}
+}
+
+func TestPipelineCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	work := make(chan int, 10)
+	for x := 0; x < 10; x++ {
+		work <- x
+	}
+	close(work)
+
+	p := NewPipeline(context.Background())
+	results, err := Collect(Then(FromChannel(p, work), func(_ context.Context, v int) (int, error) {
+		return v + 1, nil
+	}, OptStageParallelism(4)))
+	assert.Nil(err)
+	sort.Ints(results)
+	assert.Equal([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, results)
+}
+
+func TestPipelineFirstError(t *testing.T) {
+	assert := assert.New(t)
+
+	items := make([]int, 1000)
+	var processed int32
+	p := NewPipeline(context.Background())
+	stage := Then(FromSlice(p, items), func(_ context.Context, v int) (int, error) {
+		if atomic.AddInt32(&processed, 1) == 10 {
+			return 0, fmt.Errorf("this is only a test")
+		}
+		return v, nil
+	}, OptStageParallelism(2))
+
+	results, err := Collect(stage)
+	assert.NotNil(err)
+	assert.Equal("this is only a test", err.Error())
+	assert.Empty(results)
+	assert.True(atomic.LoadInt32(&processed) < 1000)
+	assert.NotNil(p.Context().Err())
+}
+
+func TestPipelinePanic(t *testing.T) {
+	assert := assert.New(t)
+
+	p := NewPipeline(context.Background())
+	stage := Then(FromSlice(p, []string{"a", "b"}), func(_ context.Context, v string) (string, error) {
+		if v == "b" {
+			panic("this is only a test")
+		}
+		return v, nil
+	}, OptStageParallelism(1))
+
+	_, err := Collect(stage)
+	assert.NotNil(err)
+	assert.Equal("this is only a test", ex.ErrClass(err).Error())
+}
+
+func TestPipelineBackpressure(t *testing.T) {
+	assert := assert.New(t)
+
+	items := make([]int, 100)
+	var produced int32
+	p := NewPipeline(context.Background())
+	stage := Then(FromSlice(p, items), func(_ context.Context, v int) (int, error) {
+		atomic.AddInt32(&produced, 1)
+		return v, nil
+	}, OptStageParallelism(1), OptStageBuffer(2))
+
+	// nothing is reading the output, so the worker blocks once the buffer is full.
+	time.Sleep(10 * time.Millisecond)
+	assert.True(atomic.LoadInt32(&produced) <= 3)
+
+	results, err := Collect(stage)
+	assert.Nil(err)
+	assert.Len(results, 100)
+}
+
+func TestPipelineCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	work := make(chan int)
+	p := NewPipeline(ctx)
+	stage := Then(FromChannel(p, work), func(_ context.Context, v int) (int, error) {
+		return v, nil
+	})
+	cancel()
+
+	_, err := Collect(stage)
+	assert.Equal(context.Canceled, err)
+}
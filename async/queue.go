@@ -10,6 +10,8 @@ package async
 import (
 	"context"
 	"runtime"
+	"sync"
+	"time"
 
 	"github.com/blend/go-sdk/ex"
 )
@@ -60,7 +62,26 @@ func OptQueueContext(ctx context.Context) QueueOption {
 	}
 }
 
+// OptQueueStats sets the queue stats collector.
+func OptQueueStats(collector QueueCollector) QueueOption {
+	return func(q *Queue) {
+		q.Stats = collector
+	}
+}
+
+// OptQueueRetry sets the number of times failed work items are retried, and the
+// delay before the first retry, which doubles for each subsequent retry.
+func OptQueueRetry(maxRetries int, backoff time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.MaxRetries = maxRetries
+		q.RetryBackoff = backoff
+	}
+}
+
 // Queue is a queue with multiple workers.
+//
+// Work items are dispatched by priority, then in the order they were enqueued;
+// items with a not before time are held until that time.
 type Queue struct {
 	Latch *Latch
 
@@ -70,9 +91,26 @@ type Queue struct {
 	Parallelism int
 	MaxWork     int
 
+	// Stats is an optional collector for queue depth, wait and processing times.
+	Stats QueueCollector
+	// MaxRetries is the number of times a failed work item is retried.
+	// Errors are only reported once an item's retries are exhausted.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry of a failed work item.
+	RetryBackoff time.Duration
+	// WAL is an optional write ahead log that makes work items durable.
+	WAL *WAL
+	// Codec encodes work items for the write ahead log.
+	Codec Codec
+
 	// these will typically be set by Start
 	Workers chan *Worker
 	Work    chan interface{}
+
+	mu       sync.Mutex
+	schedule *queueItemSchedule
+	sequence uint64
+	wake     chan struct{}
 }
 
 // Background returns a background context.
@@ -84,8 +122,26 @@ func (q *Queue) Background() context.Context {
 }
 
 // Enqueue adds an item to the work queue.
-func (q *Queue) Enqueue(obj interface{}) {
-	q.Work <- obj
+func (q *Queue) Enqueue(obj interface{}, options ...QueueItemOption) {
+	item := &QueueItem{
+		Value:    obj,
+		Enqueued: time.Now().UTC(),
+	}
+	for _, option := range options {
+		option(item)
+	}
+	if q.WAL != nil {
+		// the item is still processed if it cannot be persisted.
+		q.handleError(q.persist(item))
+	}
+	q.Work <- item
+}
+
+// Len returns the number of work items waiting to be dispatched.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lenUnsafe()
 }
 
 // Start starts the queue and its workers.
@@ -99,9 +155,16 @@ func (q *Queue) Start() error {
 	// create channel(s)
 	q.Work = make(chan interface{}, q.MaxWork)
 	q.Workers = make(chan *Worker, q.Parallelism)
+	q.mu.Lock()
+	q.schedule = newQueueItemSchedule()
+	q.wake = make(chan struct{}, 1)
+	q.mu.Unlock()
+	if q.WAL != nil {
+		q.replay()
+	}
 
 	for x := 0; x < q.Parallelism; x++ {
-		worker := NewWorker(q.Action)
+		worker := NewWorker(q.process)
 		worker.Context = q.Context
 		worker.Errors = q.Errors
 		worker.Finalizer = q.ReturnWorker
@@ -118,7 +181,6 @@ func (q *Queue) Start() error {
 // Dispatch processes work items in a loop.
 func (q *Queue) Dispatch() {
 	q.Latch.Started()
-	var workItem interface{}
 	var worker *Worker
 	var stopping <-chan struct{}
 	for {
@@ -129,13 +191,11 @@ func (q *Queue) Dispatch() {
 			return
 		default:
 		}
+
 		select {
-		case workItem = <-q.Work:
-			stopping = q.Latch.NotifyStopping()
-			select {
-			case worker = <-q.Workers:
-				worker.Enqueue(workItem)
-			case <-stopping:
+		case worker = <-q.Workers:
+			if !q.dispatch(worker, stopping) {
+				q.Workers <- worker
 				q.Latch.Stopped()
 				return
 			}
@@ -173,3 +233,163 @@ func (q *Queue) ReturnWorker(ctx context.Context, worker *Worker) error {
 	q.Workers <- worker
 	return nil
 }
+
+// dispatch waits for the next ready work item and enqueues it with the worker.
+// It returns false if the queue started stopping first.
+func (q *Queue) dispatch(worker *Worker, stopping <-chan struct{}) bool {
+	for {
+		item, wait := q.next()
+		if item != nil {
+			item.Attempt++
+			q.timing(MetricNameQueueWaitTime, time.Now().UTC().Sub(item.Ready()))
+			worker.Enqueue(item)
+			return true
+		}
+
+		// only take more work once the schedule has room, so enqueue blocks once the
+		// schedule and the work channel are both full.
+		var work <-chan interface{}
+		if q.acceptsWork() {
+			work = q.Work
+		}
+		var timer *time.Timer
+		var ready <-chan time.Time
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			ready = timer.C
+		}
+		select {
+		case obj := <-work:
+			q.push(obj)
+		case <-q.wake:
+		case <-ready:
+		case <-stopping:
+			if timer != nil {
+				timer.Stop()
+			}
+			return false
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// next moves enqueued work to the schedule while it has room and returns the next ready item,
+// or how long to wait for a delayed item.
+func (q *Queue) next() (*QueueItem, time.Duration) {
+	q.mu.Lock()
+	now := time.Now().UTC()
+	for pending := len(q.Work); pending > 0 && q.acceptsWorkUnsafe(); pending-- {
+		q.pushUnsafe(<-q.Work, now)
+	}
+	item, expired, wait := q.schedule.Pop(now)
+	for range expired {
+		q.increment(MetricNameQueueExpired)
+	}
+	q.gauge(MetricNameQueueDepth, float64(q.lenUnsafe()))
+	q.mu.Unlock()
+
+	// expired items are dropped, so they must not be replayed from the write ahead log.
+	for _, expiredItem := range expired {
+		if expiredItem.durable {
+			q.handleError(q.WAL.Ack(expiredItem.walID))
+		}
+	}
+	return item, wait
+}
+
+// acceptsWork returns if the schedule has room for more enqueued work.
+func (q *Queue) acceptsWork() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.acceptsWorkUnsafe()
+}
+
+// acceptsWorkUnsafe returns if the schedule holds fewer than max work items.
+func (q *Queue) acceptsWorkUnsafe() bool {
+	maxWork := q.MaxWork
+	if maxWork < 1 {
+		maxWork = 1
+	}
+	return q.schedule.Len() < maxWork
+}
+
+// push adds a work item to the schedule.
+func (q *Queue) push(obj interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pushUnsafe(obj, time.Now().UTC())
+}
+
+// pushUnsafe adds a work item to the schedule, wrapping it in a queue item if necessary.
+func (q *Queue) pushUnsafe(obj interface{}, now time.Time) {
+	item, ok := obj.(*QueueItem)
+	if !ok {
+		item = &QueueItem{Value: obj, Enqueued: now}
+	}
+	q.sequence++
+	item.sequence = q.sequence
+	q.schedule.Push(item, now)
+}
+
+// lenUnsafe returns the number of work items waiting to be dispatched.
+func (q *Queue) lenUnsafe() int {
+	depth := len(q.Work)
+	if q.schedule != nil {
+		depth += q.schedule.Len()
+	}
+	return depth
+}
+
+// process is the worker action; it runs the queue action for a work item
+// and schedules retries if it fails.
+//
+// Durable items are acknowledged once they are processed or their retries are exhausted.
+func (q *Queue) process(ctx context.Context, obj interface{}) (err error) {
+	item, ok := obj.(*QueueItem)
+	if !ok {
+		return q.Action(ctx, obj)
+	}
+	if !item.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, item.Deadline)
+		defer cancel()
+	}
+
+	started := time.Now().UTC()
+	defer func() {
+		if r := recover(); r != nil {
+			err = ex.New(r)
+		}
+		q.timing(MetricNameQueueProcessingTime, time.Now().UTC().Sub(started))
+		if err != nil && item.Attempt <= q.MaxRetries {
+			q.retry(item)
+			err = nil
+			return
+		}
+		if item.durable {
+			if ackErr := q.WAL.Ack(item.walID); ackErr != nil && err == nil {
+				err = ackErr
+			} else {
+				q.handleError(ackErr)
+			}
+		}
+	}()
+	return q.Action(ctx, item.Value)
+}
+
+// retry schedules a failed work item to be dispatched again after a backoff.
+func (q *Queue) retry(item *QueueItem) {
+	q.increment(MetricNameQueueRetries)
+	item.NotBefore = time.Now().UTC().Add(q.RetryBackoff << uint(item.Attempt-1))
+
+	q.mu.Lock()
+	q.pushUnsafe(item, time.Now().UTC())
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
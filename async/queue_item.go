@@ -0,0 +1,165 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"container/heap"
+	"time"
+)
+
+// QueueItemOption mutates a queue item.
+type QueueItemOption func(*QueueItem)
+
+// OptQueueItemPriority sets the queue item priority.
+// Items with higher priorities are dispatched first.
+func OptQueueItemPriority(priority int) QueueItemOption {
+	return func(qi *QueueItem) {
+		qi.Priority = priority
+	}
+}
+
+// OptQueueItemNotBefore sets the earliest time the queue item can be dispatched.
+func OptQueueItemNotBefore(notBefore time.Time) QueueItemOption {
+	return func(qi *QueueItem) {
+		qi.NotBefore = notBefore
+	}
+}
+
+// OptQueueItemDelay sets the earliest time the queue item can be dispatched relative to now.
+func OptQueueItemDelay(delay time.Duration) QueueItemOption {
+	return func(qi *QueueItem) {
+		qi.NotBefore = time.Now().UTC().Add(delay)
+	}
+}
+
+// OptQueueItemDeadline sets the queue item deadline.
+// Items that are not dispatched by their deadline are dropped, and
+// the action context for the item is canceled at the deadline.
+func OptQueueItemDeadline(deadline time.Time) QueueItemOption {
+	return func(qi *QueueItem) {
+		qi.Deadline = deadline
+	}
+}
+
+// QueueItem is a work item with scheduling metadata.
+type QueueItem struct {
+	Value     interface{}
+	Priority  int
+	NotBefore time.Time
+	Deadline  time.Time
+	Enqueued  time.Time
+	// Attempt is the number of times the item has been dispatched.
+	Attempt int
+
+	sequence uint64
+	walID    uint64
+	durable  bool
+}
+
+// IsReady returns if the item can be dispatched at a given time.
+func (qi *QueueItem) IsReady(now time.Time) bool {
+	return qi.NotBefore.IsZero() || !qi.NotBefore.After(now)
+}
+
+// IsExpired returns if the item has passed its deadline at a given time.
+func (qi *QueueItem) IsExpired(now time.Time) bool {
+	return !qi.Deadline.IsZero() && !now.Before(qi.Deadline)
+}
+
+// Ready returns the time the item became ready to dispatch.
+func (qi *QueueItem) Ready() time.Time {
+	if qi.NotBefore.After(qi.Enqueued) {
+		return qi.NotBefore
+	}
+	return qi.Enqueued
+}
+
+// newQueueItemSchedule returns a new queue item schedule.
+func newQueueItemSchedule() *queueItemSchedule {
+	return &queueItemSchedule{
+		delayed: queueItemHeap{byNotBefore: true},
+	}
+}
+
+// queueItemSchedule holds queue items that are not ready yet in not before order,
+// and ready items in priority order.
+type queueItemSchedule struct {
+	delayed queueItemHeap
+	ready   queueItemHeap
+}
+
+// Len returns the total number of scheduled items.
+func (qis *queueItemSchedule) Len() int {
+	return len(qis.delayed.items) + len(qis.ready.items)
+}
+
+// Push adds an item to the schedule.
+func (qis *queueItemSchedule) Push(item *QueueItem, now time.Time) {
+	if item.IsReady(now) {
+		heap.Push(&qis.ready, item)
+		return
+	}
+	heap.Push(&qis.delayed, item)
+}
+
+// Pop returns the highest priority ready item, along with any expired items it skipped.
+// If no items are ready, it returns how long until the next delayed item is ready, or a negative duration
+// if there are no delayed items.
+func (qis *queueItemSchedule) Pop(now time.Time) (item *QueueItem, expired []*QueueItem, wait time.Duration) {
+	for len(qis.delayed.items) > 0 && qis.delayed.items[0].IsReady(now) {
+		heap.Push(&qis.ready, heap.Pop(&qis.delayed))
+	}
+	for len(qis.ready.items) > 0 {
+		next := heap.Pop(&qis.ready).(*QueueItem)
+		if next.IsExpired(now) {
+			expired = append(expired, next)
+			continue
+		}
+		item = next
+		return
+	}
+	wait = -1
+	if len(qis.delayed.items) > 0 {
+		wait = qis.delayed.items[0].NotBefore.Sub(now)
+	}
+	return
+}
+
+// queueItemHeap is a heap of queue items.
+//
+// If byNotBefore is set, items are ordered by their not before time, otherwise
+// they are ordered by priority, then by the order they were enqueued.
+type queueItemHeap struct {
+	items       []*QueueItem
+	byNotBefore bool
+}
+
+func (qih queueItemHeap) Len() int { return len(qih.items) }
+
+func (qih queueItemHeap) Less(i, j int) bool {
+	if qih.byNotBefore && !qih.items[i].NotBefore.Equal(qih.items[j].NotBefore) {
+		return qih.items[i].NotBefore.Before(qih.items[j].NotBefore)
+	}
+	if qih.items[i].Priority != qih.items[j].Priority {
+		return qih.items[i].Priority > qih.items[j].Priority
+	}
+	return qih.items[i].sequence < qih.items[j].sequence
+}
+
+func (qih queueItemHeap) Swap(i, j int) { qih.items[i], qih.items[j] = qih.items[j], qih.items[i] }
+
+func (qih *queueItemHeap) Push(x interface{}) { qih.items = append(qih.items, x.(*QueueItem)) }
+
+func (qih *queueItemHeap) Pop() interface{} {
+	old := qih.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	qih.items = old[:n-1]
+	return item
+}
@@ -0,0 +1,68 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestQueueItemSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 01, 02, 03, 04, 05, 0, time.UTC)
+	schedule := newQueueItemSchedule()
+	schedule.Push(&QueueItem{Value: "low", sequence: 1}, now)
+	schedule.Push(&QueueItem{Value: "high", Priority: 10, sequence: 2}, now)
+	schedule.Push(&QueueItem{Value: "low2", sequence: 3}, now)
+	schedule.Push(&QueueItem{Value: "delayed", Priority: 100, NotBefore: now.Add(time.Second), sequence: 4}, now)
+	schedule.Push(&QueueItem{Value: "expired", Priority: 100, Deadline: now, sequence: 5}, now)
+	assert.Equal(5, schedule.Len())
+
+	item, expired, _ := schedule.Pop(now)
+	assert.Equal("high", item.Value)
+	assert.Len(expired, 1)
+	assert.Equal("expired", expired[0].Value)
+
+	item, _, _ = schedule.Pop(now)
+	assert.Equal("low", item.Value)
+	item, _, _ = schedule.Pop(now)
+	assert.Equal("low2", item.Value)
+
+	item, _, wait := schedule.Pop(now)
+	assert.Nil(item)
+	assert.Equal(time.Second, wait)
+
+	item, _, _ = schedule.Pop(now.Add(time.Second))
+	assert.Equal("delayed", item.Value)
+
+	item, _, wait = schedule.Pop(now.Add(time.Second))
+	assert.Nil(item)
+	assert.True(wait < 0)
+	assert.Zero(schedule.Len())
+}
+
+func TestQueueItemReady(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 01, 02, 03, 04, 05, 0, time.UTC)
+	item := QueueItem{Enqueued: now}
+	assert.True(item.IsReady(now))
+	assert.False(item.IsExpired(now))
+	assert.Equal(now, item.Ready())
+
+	item.NotBefore = now.Add(time.Second)
+	item.Deadline = now.Add(2 * time.Second)
+	assert.False(item.IsReady(now))
+	assert.True(item.IsReady(now.Add(time.Second)))
+	assert.Equal(now.Add(time.Second), item.Ready())
+	assert.False(item.IsExpired(now.Add(time.Second)))
+	assert.True(item.IsExpired(now.Add(2 * time.Second)))
+}
@@ -0,0 +1,44 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import "time"
+
+// QueueCollector is the subset of a `stats.Collector` the queue reports metrics to.
+type QueueCollector interface {
+	Gauge(name string, value float64, tags ...string) error
+	Increment(name string, tags ...string) error
+	TimeInMilliseconds(name string, value time.Duration, tags ...string) error
+}
+
+// Queue metric names.
+const (
+	MetricNameQueueDepth          = "async.queue.depth"
+	MetricNameQueueWaitTime       = "async.queue.wait_time"
+	MetricNameQueueProcessingTime = "async.queue.processing_time"
+	MetricNameQueueExpired        = "async.queue.expired"
+	MetricNameQueueRetries        = "async.queue.retries"
+)
+
+func (q *Queue) gauge(name string, value float64) {
+	if q.Stats != nil {
+		_ = q.Stats.Gauge(name, value)
+	}
+}
+
+func (q *Queue) increment(name string) {
+	if q.Stats != nil {
+		_ = q.Stats.Increment(name)
+	}
+}
+
+func (q *Queue) timing(name string, value time.Duration) {
+	if q.Stats != nil {
+		_ = q.Stats.TimeInMilliseconds(name, value)
+	}
+}
@@ -9,8 +9,11 @@ package async
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/blend/go-sdk/assert"
 )
@@ -38,3 +41,156 @@ func TestParallelQueue(t *testing.T) {
 	q.Close()
 	assert.False(q.Latch.IsStarted())
 }
+
+func TestQueuePriority(t *testing.T) {
+	assert := assert.New(t)
+
+	block := make(chan struct{})
+	processed := make(chan interface{}, 8)
+	q := NewQueue(func(_ context.Context, obj interface{}) error {
+		if obj == "block" {
+			<-block
+			return nil
+		}
+		processed <- obj
+		return nil
+	}, OptQueueParallelism(1))
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	defer q.Close()
+
+	// hold the only worker so the rest of the items are scheduled together.
+	q.Enqueue("block")
+	for q.Len() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	q.Enqueue("low")
+	q.Enqueue("high", OptQueueItemPriority(10))
+	q.Enqueue("low2")
+	q.Enqueue("expired", OptQueueItemPriority(100), OptQueueItemDeadline(time.Now().UTC()))
+	close(block)
+
+	assert.Equal("high", <-processed)
+	assert.Equal("low", <-processed)
+	assert.Equal("low2", <-processed)
+	assert.Empty(processed)
+}
+
+func TestQueueNotBefore(t *testing.T) {
+	assert := assert.New(t)
+
+	processed := make(chan time.Time, 1)
+	q := NewQueue(func(_ context.Context, obj interface{}) error {
+		processed <- time.Now().UTC()
+		return nil
+	})
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	defer q.Close()
+
+	enqueued := time.Now().UTC()
+	q.Enqueue("hello", OptQueueItemDelay(20*time.Millisecond))
+	assert.True((<-processed).Sub(enqueued) >= 20*time.Millisecond)
+}
+
+func TestQueueMaxWork(t *testing.T) {
+	assert := assert.New(t)
+
+	processed := make(chan struct{}, 5)
+	q := NewQueue(func(_ context.Context, obj interface{}) error {
+		processed <- struct{}{}
+		return nil
+	}, OptQueueMaxWork(2), OptQueueParallelism(1))
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	defer q.Close()
+
+	// the schedule and the work channel each hold max work items.
+	started := time.Now().UTC()
+	for x := 0; x < 4; x++ {
+		q.Enqueue(x, OptQueueItemDelay(50*time.Millisecond))
+	}
+	assert.Equal(4, q.Len())
+
+	// so the next enqueue blocks until a delayed item is dispatched.
+	q.Enqueue(4)
+	assert.True(time.Since(started) >= 40*time.Millisecond)
+	for x := 0; x < 5; x++ {
+		<-processed
+	}
+}
+
+func TestQueueDeadlineContext(t *testing.T) {
+	assert := assert.New(t)
+
+	deadlines := make(chan time.Time, 1)
+	q := NewQueue(func(ctx context.Context, obj interface{}) error {
+		deadline, _ := ctx.Deadline()
+		deadlines <- deadline
+		return nil
+	})
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	defer q.Close()
+
+	deadline := time.Now().UTC().Add(time.Minute)
+	q.Enqueue("hello", OptQueueItemDeadline(deadline))
+	assert.True(deadline.Equal(<-deadlines))
+}
+
+func TestQueueRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	errors := make(chan error, 1)
+	collector := newMockQueueCollector()
+	q := NewQueue(func(_ context.Context, obj interface{}) error {
+		atomic.AddInt32(&attempts, 1)
+		return fmt.Errorf("this is only a test")
+	},
+		OptQueueRetry(2, time.Millisecond),
+		OptQueueErrors(errors),
+		OptQueueStats(collector),
+	)
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	defer q.Close()
+
+	q.Enqueue("hello")
+	assert.Equal("this is only a test", (<-errors).Error())
+	assert.Equal(3, atomic.LoadInt32(&attempts))
+	assert.Equal(2, collector.Count(MetricNameQueueRetries))
+	assert.Equal(3, collector.Count(MetricNameQueueProcessingTime))
+	assert.Equal(3, collector.Count(MetricNameQueueWaitTime))
+	assert.NotZero(collector.Count(MetricNameQueueDepth))
+}
+
+func newMockQueueCollector() *mockQueueCollector {
+	return &mockQueueCollector{counts: make(map[string]int)}
+}
+
+type mockQueueCollector struct {
+	sync.Mutex
+	counts map[string]int
+}
+
+func (m *mockQueueCollector) Count(name string) int {
+	m.Lock()
+	defer m.Unlock()
+	return m.counts[name]
+}
+
+func (m *mockQueueCollector) Gauge(name string, _ float64, _ ...string) error {
+	m.Lock()
+	defer m.Unlock()
+	m.counts[name]++
+	return nil
+}
+
+func (m *mockQueueCollector) Increment(name string, _ ...string) error {
+	return m.Gauge(name, 1)
+}
+
+func (m *mockQueueCollector) TimeInMilliseconds(name string, _ time.Duration, _ ...string) error {
+	return m.Gauge(name, 0)
+}
@@ -0,0 +1,109 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// OptQueueWAL sets a write ahead log that makes enqueued work items durable.
+//
+// Work items are encoded with the codec and appended to the log when they are enqueued,
+// and acknowledged once the action for them succeeds, their retries are exhausted, or they expire.
+// Unacknowledged items, e.g. those that were in flight when the process stopped, are enqueued
+// again when the queue starts.
+//
+// Replayed items are the values decoded by the codec, so it must preserve the work item type,
+// e.g. a `TypedJSONCodec` or a `JSONCodec` with `New` set; a bare `JSONCodec{}` replays generic json values.
+func OptQueueWAL(wal *WAL, codec Codec) QueueOption {
+	return func(q *Queue) {
+		q.WAL = wal
+		q.Codec = codec
+	}
+}
+
+// queueWALItem is the log representation of a queue item.
+type queueWALItem struct {
+	Value     []byte    `json:"value"`
+	Priority  int       `json:"priority,omitempty"`
+	NotBefore time.Time `json:"notBefore,omitempty"`
+	Deadline  time.Time `json:"deadline,omitempty"`
+	Enqueued  time.Time `json:"enqueued"`
+}
+
+// persist appends a queue item to the write ahead log.
+func (q *Queue) persist(item *QueueItem) error {
+	value, err := q.Codec.Encode(item.Value)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(queueWALItem{
+		Value:     value,
+		Priority:  item.Priority,
+		NotBefore: item.NotBefore,
+		Deadline:  item.Deadline,
+		Enqueued:  item.Enqueued,
+	})
+	if err != nil {
+		return ex.New(err)
+	}
+	id, err := q.WAL.Append(data)
+	if err != nil {
+		return err
+	}
+	item.walID = id
+	item.durable = true
+	return nil
+}
+
+// replay schedules the unacknowledged items in the write ahead log.
+//
+// Items that cannot be decoded are reported as errors and acknowledged so they are not replayed again.
+func (q *Queue) replay() {
+	for _, entry := range q.WAL.Pending() {
+		item, err := q.restore(entry)
+		if err != nil {
+			q.handleError(ex.New(err, ex.OptMessagef("write ahead log entry: %d", entry.ID)))
+			q.handleError(q.WAL.Ack(entry.ID))
+			continue
+		}
+		q.push(item)
+	}
+}
+
+// restore decodes a queue item from a write ahead log entry.
+func (q *Queue) restore(entry WALEntry) (*QueueItem, error) {
+	var walItem queueWALItem
+	if err := json.Unmarshal(entry.Data, &walItem); err != nil {
+		return nil, ex.New(err)
+	}
+	value, err := q.Codec.Decode(walItem.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &QueueItem{
+		Value:     value,
+		Priority:  walItem.Priority,
+		NotBefore: walItem.NotBefore,
+		Deadline:  walItem.Deadline,
+		Enqueued:  walItem.Enqueued,
+		walID:     entry.ID,
+		durable:   true,
+	}, nil
+}
+
+// handleError sends a non-nil err to the errors channel if one is provided.
+func (q *Queue) handleError(err error) {
+	if err == nil || q.Errors == nil {
+		return
+	}
+	q.Errors <- err
+}
@@ -0,0 +1,111 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestQueueWAL(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	wal, err := OpenWAL(dir)
+	assert.Nil(err)
+
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	errors := make(chan error, 4)
+	q := NewTypedQueue(func(_ context.Context, obj codecTestItem) error {
+		defer wg.Done()
+		if obj.Name == "bad" {
+			return fmt.Errorf("this is only a test")
+		}
+		return nil
+	},
+		OptQueueWAL(wal, TypedJSONCodec[codecTestItem]{}),
+		OptQueueErrors(errors),
+	)
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+
+	q.Enqueue(codecTestItem{Name: "good"})
+	q.Enqueue(codecTestItem{Name: "bad"}, OptQueueItemPriority(5))
+	q.Enqueue(codecTestItem{Name: "good2"})
+	q.Enqueue(codecTestItem{Name: "expired"}, OptQueueItemDeadline(time.Now().UTC().Add(-time.Second)))
+	q.Enqueue(codecTestItem{Name: "later"}, OptQueueItemDelay(200*time.Millisecond))
+	q.Enqueue(codecTestItem{Name: "later2"}, OptQueueItemDelay(200*time.Millisecond))
+	wg.Wait()
+
+	// processed, failed and expired items are acknowledged, leaving the delayed items.
+	waitForWALLen(wal, 2)
+	assert.Nil(q.Close())
+	assert.Len(errors, 1)
+	assert.Nil(wal.Close())
+
+	// a new process replays the items that were not processed.
+	wal, err = OpenWAL(dir)
+	assert.Nil(err)
+	defer wal.Close()
+	assert.Equal(2, wal.Len())
+
+	var mu sync.Mutex
+	var replayed []string
+	wg.Add(2)
+	q = NewTypedQueue(func(_ context.Context, obj codecTestItem) error {
+		defer wg.Done()
+		mu.Lock()
+		replayed = append(replayed, obj.Name)
+		mu.Unlock()
+		return nil
+	}, OptQueueWAL(wal, TypedJSONCodec[codecTestItem]{}))
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	wg.Wait()
+	waitForWALLen(wal, 0)
+	assert.Nil(q.Close())
+
+	sort.Strings(replayed)
+	assert.Equal([]string{"later", "later2"}, replayed)
+	assert.Zero(wal.Len())
+}
+
+func TestQueueWALUndecodable(t *testing.T) {
+	assert := assert.New(t)
+
+	wal, err := OpenWAL(t.TempDir())
+	assert.Nil(err)
+	defer wal.Close()
+	_, err = wal.Append([]byte("not json"))
+	assert.Nil(err)
+
+	errors := make(chan error, 1)
+	q := NewQueue(func(_ context.Context, _ interface{}) error {
+		return nil
+	}, OptQueueWAL(wal, JSONCodec{}), OptQueueErrors(errors))
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+	defer q.Close()
+
+	assert.NotNil(<-errors)
+	assert.Zero(wal.Len())
+}
+
+// waitForWALLen waits for in flight acknowledgements, which happen after the action returns.
+func waitForWALLen(wal *WAL, expected int) {
+	for wal.Len() > expected {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import "context"
+
+// NewTypedBatch creates a new batch processor for typed work items.
+func NewTypedBatch[T any](work chan T, action TypedWorkAction[T], options ...BatchOption) *TypedBatch[T] {
+	return &TypedBatch[T]{
+		Batch: NewBatch(nil, action.untyped(), options...),
+		Work:  work,
+	}
+}
+
+// TypedBatch is a batch of typed work executed by a fixed count of workers.
+type TypedBatch[T any] struct {
+	*Batch
+	Work chan T
+}
+
+// Process executes the action for all the work items.
+func (tb *TypedBatch[T]) Process(ctx context.Context) {
+	numWorkItems := len(tb.Work)
+	tb.Batch.Work = make(chan interface{}, numWorkItems)
+	for x := 0; x < numWorkItems; x++ {
+		tb.Batch.Work <- <-tb.Work
+	}
+	tb.Batch.Process(ctx)
+}
@@ -0,0 +1,49 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestTypedBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	workItems := 32
+	items := make(chan int, workItems)
+	for x := 0; x < workItems; x++ {
+		items <- x
+	}
+
+	var processed, total int32
+	action := func(_ context.Context, v int) error {
+		atomic.AddInt32(&processed, 1)
+		atomic.AddInt32(&total, int32(v))
+		if v%2 == 0 {
+			return fmt.Errorf("this is only a test")
+		}
+		return nil
+	}
+
+	errors := make(chan error, workItems)
+	NewTypedBatch(
+		items,
+		action,
+		OptBatchErrors(errors),
+		OptBatchParallelism(4),
+	).Process(context.Background())
+
+	assert.Equal(workItems, processed)
+	assert.Equal(496, total)
+	assert.Equal(workItems/2, len(errors))
+}
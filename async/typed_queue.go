@@ -0,0 +1,48 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// TypedWorkAction is a typed action handler for a queue.
+type TypedWorkAction[T any] func(context.Context, T) error
+
+// NewTypedQueue returns a new parallel queue whose action receives typed work items.
+func NewTypedQueue[T any](action TypedWorkAction[T], options ...QueueOption) *TypedQueue[T] {
+	return &TypedQueue[T]{
+		Queue: NewQueue(action.untyped(), options...),
+	}
+}
+
+// TypedQueue is a queue with multiple workers that process typed work items.
+type TypedQueue[T any] struct {
+	*Queue
+}
+
+// Enqueue adds an item to the work queue.
+func (tq *TypedQueue[T]) Enqueue(obj T, options ...QueueItemOption) {
+	tq.Queue.Enqueue(obj, options...)
+}
+
+// untyped returns the action as a work action.
+//
+// Work items that are not of the expected type return an `ErrUnexpectedWorkType` error.
+func (twa TypedWorkAction[T]) untyped() WorkAction {
+	return func(ctx context.Context, obj interface{}) error {
+		typed, ok := obj.(T)
+		if !ok {
+			return ex.New(ErrUnexpectedWorkType, ex.OptMessagef("expected: %v, actual: %T", reflect.TypeOf((*T)(nil)).Elem(), obj))
+		}
+		return twa(ctx, typed)
+	}
+}
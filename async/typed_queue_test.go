@@ -0,0 +1,62 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestTypedQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	wg := sync.WaitGroup{}
+	wg.Add(8)
+	var total int32
+	q := NewTypedQueue(func(_ context.Context, obj int) error {
+		defer wg.Done()
+		atomic.AddInt32(&total, int32(obj))
+		return nil
+	}, OptQueueParallelism(2))
+
+	go func() { _ = q.Start() }()
+	<-q.Latch.NotifyStarted()
+
+	for x := 0; x < 8; x++ {
+		q.Enqueue(x)
+	}
+
+	wg.Wait()
+	q.Close()
+	assert.False(q.Latch.IsStarted())
+	assert.Equal(28, total)
+}
+
+func TestTypedWorkActionUnexpectedType(t *testing.T) {
+	assert := assert.New(t)
+
+	var called bool
+	action := TypedWorkAction[int](func(_ context.Context, _ int) error {
+		called = true
+		return nil
+	}).untyped()
+
+	assert.Nil(action(context.Background(), 1))
+	assert.True(called)
+
+	called = false
+	err := action(context.Background(), "one")
+	assert.True(ex.Is(err, ErrUnexpectedWorkType))
+	assert.Contains(ex.ErrMessage(err), "expected: int, actual: string")
+	assert.False(called)
+}
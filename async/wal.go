@@ -0,0 +1,364 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// OpenWAL opens or creates a write ahead log in a given directory,
+// replaying any existing segments to find unacknowledged entries.
+func OpenWAL(dir string, options ...WALOption) (*WAL, error) {
+	w := WAL{
+		Dir:             dir,
+		MaxSegmentBytes: DefaultWALMaxSegmentBytes,
+		CompactionRatio: DefaultWALCompactionRatio,
+		Sync:            true,
+	}
+	for _, option := range options {
+		option(&w)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// WALOption is an option for a write ahead log.
+type WALOption func(*WAL)
+
+// OptWALMaxSegmentBytes sets the size at which a new segment file is started.
+func OptWALMaxSegmentBytes(maxSegmentBytes int64) WALOption {
+	return func(w *WAL) {
+		w.MaxSegmentBytes = maxSegmentBytes
+	}
+}
+
+// OptWALMaxBytes sets the maximum total size of the segment files.
+// Appends that would exceed the size return `ErrWALFull`.
+func OptWALMaxBytes(maxBytes int64) WALOption {
+	return func(w *WAL) {
+		w.MaxBytes = maxBytes
+	}
+}
+
+// OptWALCompactionRatio sets the live entry ratio at or below which a segment is rewritten during compaction.
+func OptWALCompactionRatio(ratio float64) WALOption {
+	return func(w *WAL) {
+		w.CompactionRatio = ratio
+	}
+}
+
+// OptWALSync sets if the active segment is synced to disk after each write.
+func OptWALSync(sync bool) WALOption {
+	return func(w *WAL) {
+		w.Sync = sync
+	}
+}
+
+// WALEntry is an unacknowledged entry in a write ahead log.
+type WALEntry struct {
+	ID   uint64
+	Data []byte
+}
+
+// WAL is a segmented write ahead log of entries that are appended and later acknowledged.
+//
+// Entries that are not acknowledged are returned by `Pending`, including after the log is reopened.
+// Segments are removed once all their entries are acknowledged, and mostly acknowledged
+// segments are compacted by rewriting their live entries to the active segment.
+type WAL struct {
+	Dir             string
+	MaxSegmentBytes int64
+	MaxBytes        int64
+	CompactionRatio float64
+	Sync            bool
+
+	mu       sync.Mutex
+	segments []*walSegment
+	active   *os.File
+	pending  map[uint64]*walEntry
+	nextID   uint64
+	closed   bool
+}
+
+// walEntry is a pending entry and the segment it is stored in.
+type walEntry struct {
+	Data    []byte
+	Segment *walSegment
+}
+
+// Append writes an entry to the log and returns its id.
+func (w *WAL) Append(data []byte) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ex.New(ErrWALClosed)
+	}
+	record := walRecord{Kind: walRecordAppend, ID: w.nextID, Data: data}
+	if w.MaxBytes > 0 && w.sizeUnsafe()+record.Size() > w.MaxBytes {
+		if err := w.compactUnsafe(); err != nil {
+			return 0, err
+		}
+		if w.sizeUnsafe()+record.Size() > w.MaxBytes {
+			return 0, ex.New(ErrWALFull)
+		}
+	}
+	if err := w.rotateUnsafe(record.Size()); err != nil {
+		return 0, err
+	}
+	if err := w.writeUnsafe(record); err != nil {
+		return 0, err
+	}
+	w.nextID++
+	w.trackUnsafe(record.ID, data, w.activeSegment())
+	return record.ID, nil
+}
+
+// Ack acknowledges an entry so it is no longer pending.
+func (w *WAL) Ack(id uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ex.New(ErrWALClosed)
+	}
+	entry, ok := w.pending[id]
+	if !ok {
+		return nil
+	}
+	if err := w.writeUnsafe(walRecord{Kind: walRecordAck, ID: id}); err != nil {
+		return err
+	}
+	delete(w.pending, id)
+	entry.Segment.Live--
+	return w.removeAckedUnsafe()
+}
+
+// Pending returns the unacknowledged entries in the order they were appended.
+func (w *WAL) Pending() []WALEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries := make([]WALEntry, 0, len(w.pending))
+	for id, entry := range w.pending {
+		entries = append(entries, WALEntry{ID: id, Data: entry.Data})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// Len returns the number of unacknowledged entries.
+func (w *WAL) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Size returns the total size of the segment files in bytes.
+func (w *WAL) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sizeUnsafe()
+}
+
+// Compact removes fully acknowledged segments and rewrites the live entries of
+// segments at or below the compaction ratio to the active segment.
+func (w *WAL) Compact() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return ex.New(ErrWALClosed)
+	}
+	return w.compactUnsafe()
+}
+
+// Close closes the active segment.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return ex.New(w.active.Close())
+}
+
+// open replays the segments in the log directory and opens the active segment.
+func (w *WAL) open() error {
+	if err := os.MkdirAll(w.Dir, 0700); err != nil {
+		return ex.New(err)
+	}
+	segments, err := listWALSegments(w.Dir)
+	if err != nil {
+		return err
+	}
+	w.pending = make(map[uint64]*walEntry)
+	for index, segment := range segments {
+		offset, err := readWALSegment(segment.Path, func(record walRecord) {
+			w.replayUnsafe(record, segment)
+		})
+		if err != nil {
+			// a torn write at the end of the last segment is expected after a crash.
+			if index < len(segments)-1 {
+				return ex.New(ErrWALCorrupt, ex.OptMessagef("segment: %s", segment.Path), ex.OptInner(err))
+			}
+			if err := os.Truncate(segment.Path, offset); err != nil {
+				return ex.New(err)
+			}
+		}
+		segment.Size = offset
+	}
+	w.segments = segments
+	if len(w.segments) == 0 {
+		return w.createSegmentUnsafe(0)
+	}
+	active := w.activeSegment()
+	w.active, err = os.OpenFile(active.Path, os.O_WRONLY|os.O_APPEND, DefaultWALFileMode)
+	if err != nil {
+		return ex.New(err)
+	}
+	return w.removeAckedUnsafe()
+}
+
+// replayUnsafe applies a record read from a segment.
+func (w *WAL) replayUnsafe(record walRecord, segment *walSegment) {
+	if record.ID >= w.nextID {
+		w.nextID = record.ID + 1
+	}
+	switch record.Kind {
+	case walRecordAppend:
+		// compaction may leave an entry in two segments if it is interrupted; the later copy wins.
+		if entry, ok := w.pending[record.ID]; ok {
+			entry.Segment.Live--
+		}
+		w.trackUnsafe(record.ID, record.Data, segment)
+	case walRecordAck:
+		if entry, ok := w.pending[record.ID]; ok {
+			delete(w.pending, record.ID)
+			entry.Segment.Live--
+		}
+	}
+}
+
+// trackUnsafe records a pending entry in a segment.
+func (w *WAL) trackUnsafe(id uint64, data []byte, segment *walSegment) {
+	w.pending[id] = &walEntry{Data: data, Segment: segment}
+	segment.Appended++
+	segment.Live++
+}
+
+// activeSegment returns the segment being written to.
+func (w *WAL) activeSegment() *walSegment {
+	return w.segments[len(w.segments)-1]
+}
+
+// sizeUnsafe returns the total size of the segments.
+func (w *WAL) sizeUnsafe() (size int64) {
+	for _, segment := range w.segments {
+		size += segment.Size
+	}
+	return
+}
+
+// writeUnsafe writes a record to the active segment.
+func (w *WAL) writeUnsafe(record walRecord) error {
+	if _, err := w.active.Write(record.Encode()); err != nil {
+		return ex.New(err)
+	}
+	if w.Sync {
+		if err := w.active.Sync(); err != nil {
+			return ex.New(err)
+		}
+	}
+	w.activeSegment().Size += record.Size()
+	return nil
+}
+
+// rotateUnsafe starts a new segment if a record would not fit in the active segment.
+func (w *WAL) rotateUnsafe(recordSize int64) error {
+	active := w.activeSegment()
+	if w.MaxSegmentBytes <= 0 || active.Size == 0 || active.Size+recordSize <= w.MaxSegmentBytes {
+		return nil
+	}
+	if err := w.active.Close(); err != nil {
+		return ex.New(err)
+	}
+	if err := w.createSegmentUnsafe(active.ID + 1); err != nil {
+		return err
+	}
+	return w.compactUnsafe()
+}
+
+// createSegmentUnsafe creates a new active segment.
+func (w *WAL) createSegmentUnsafe(id uint64) (err error) {
+	segment := &walSegment{ID: id, Path: walSegmentPath(w.Dir, id)}
+	w.active, err = os.OpenFile(segment.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, DefaultWALFileMode)
+	if err != nil {
+		return ex.New(err)
+	}
+	w.segments = append(w.segments, segment)
+	return nil
+}
+
+// removeAckedUnsafe removes the oldest segments while all their entries are acknowledged.
+//
+// Segments are only removed from the head of the log, as later segments can hold
+// acknowledgements for entries in earlier segments.
+func (w *WAL) removeAckedUnsafe() error {
+	for len(w.segments) > 1 && w.segments[0].Live == 0 {
+		if err := os.Remove(w.segments[0].Path); err != nil && !os.IsNotExist(err) {
+			return ex.New(err)
+		}
+		w.segments = w.segments[1:]
+	}
+	return nil
+}
+
+// compactUnsafe removes acknowledged segments, then rewrites the live entries of
+// sparse segments at the head of the log to the active segment so they can be removed.
+func (w *WAL) compactUnsafe() error {
+	if err := w.removeAckedUnsafe(); err != nil {
+		return err
+	}
+	for len(w.segments) > 1 {
+		head := w.segments[0]
+		if float64(head.Live) > w.CompactionRatio*float64(head.Appended) {
+			return nil
+		}
+		active := w.activeSegment()
+		var ids []uint64
+		for id, entry := range w.pending {
+			if entry.Segment == head {
+				ids = append(ids, id)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, id := range ids {
+			entry := w.pending[id]
+			if err := w.writeUnsafe(walRecord{Kind: walRecordAppend, ID: id, Data: entry.Data}); err != nil {
+				return err
+			}
+			head.Live--
+			w.trackUnsafe(id, entry.Data, active)
+		}
+		if err := w.removeAckedUnsafe(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// WAL record kinds.
+const (
+	walRecordAppend byte = 1
+	walRecordAck    byte = 2
+)
+
+// walRecordHeaderSize is the size of a record header;
+// kind (1 byte), id (8 bytes), payload length (4 bytes) and crc32 (4 bytes).
+const walRecordHeaderSize = 1 + 8 + 4 + 4
+
+// walSegmentExtension is the file extension for segment files.
+const walSegmentExtension = ".wal"
+
+// walRecord is an individual log record.
+type walRecord struct {
+	Kind byte
+	ID   uint64
+	Data []byte
+}
+
+// Size returns the encoded size of the record.
+func (wr walRecord) Size() int64 {
+	return int64(walRecordHeaderSize + len(wr.Data))
+}
+
+// Encode returns the record in its on disk format.
+func (wr walRecord) Encode() []byte {
+	buffer := make([]byte, walRecordHeaderSize+len(wr.Data))
+	buffer[0] = wr.Kind
+	binary.BigEndian.PutUint64(buffer[1:9], wr.ID)
+	binary.BigEndian.PutUint32(buffer[9:13], uint32(len(wr.Data)))
+	copy(buffer[walRecordHeaderSize:], wr.Data)
+	binary.BigEndian.PutUint32(buffer[13:17], wr.checksum())
+	return buffer
+}
+
+// checksum returns the crc32 of the record kind, id and data.
+func (wr walRecord) checksum() uint32 {
+	var header [9]byte
+	header[0] = wr.Kind
+	binary.BigEndian.PutUint64(header[1:], wr.ID)
+	return crc32.Update(crc32.ChecksumIEEE(header[:]), crc32.IEEETable, wr.Data)
+}
+
+// readWALRecord reads a record with at most a given number of bytes remaining in the segment,
+// returning io.EOF at a clean end of the segment, and io.ErrUnexpectedEOF or ErrWALCorrupt for torn
+// or corrupt records.
+func readWALRecord(r io.Reader, remaining int64) (record walRecord, err error) {
+	var header [walRecordHeaderSize]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	record.Kind = header[0]
+	record.ID = binary.BigEndian.Uint64(header[1:9])
+	length := binary.BigEndian.Uint32(header[9:13])
+	if record.Kind != walRecordAppend && record.Kind != walRecordAck {
+		err = ex.New(ErrWALCorrupt, ex.OptMessagef("invalid record kind: %d", record.Kind))
+		return
+	}
+	// check the length before allocating, as a corrupt length could be up to 4GiB.
+	if int64(length) > remaining-walRecordHeaderSize {
+		err = ex.New(ErrWALCorrupt, ex.OptMessagef("record length exceeds segment: %d", length))
+		return
+	}
+	record.Data = make([]byte, length)
+	if _, err = io.ReadFull(r, record.Data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	if record.checksum() != binary.BigEndian.Uint32(header[13:17]) {
+		err = ex.New(ErrWALCorrupt, ex.OptMessagef("checksum mismatch for record: %d", record.ID))
+	}
+	return
+}
+
+// walSegment is a single log file.
+type walSegment struct {
+	ID   uint64
+	Path string
+	Size int64
+	// Appended is the number of items appended to the segment.
+	Appended int
+	// Live is the number of items appended to the segment that have not been acknowledged.
+	Live int
+}
+
+// walSegmentPath returns the path for a segment id.
+func walSegmentPath(dir string, id uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%016x%s", id, walSegmentExtension))
+}
+
+// listWALSegments returns the segments in a directory in order.
+func listWALSegments(dir string) ([]*walSegment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	// ReadDir returns entries sorted by name, and segment names are fixed width hex.
+	var segments []*walSegment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, walSegmentExtension) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, walSegmentExtension), 16, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, &walSegment{ID: id, Path: filepath.Join(dir, name)})
+	}
+	return segments, nil
+}
+
+// readWALSegment calls a handler for each record in a segment.
+// It returns the offset of the end of the last valid record, and any error that stopped the read.
+func readWALSegment(path string, handler func(walRecord)) (offset int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		err = ex.New(err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		err = ex.New(err)
+		return
+	}
+
+	r := bufio.NewReader(f)
+	var record walRecord
+	for {
+		record, err = readWALRecord(r, info.Size()-offset)
+		if err == io.EOF {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+		handler(record)
+		offset += record.Size()
+	}
+}
@@ -0,0 +1,189 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package async
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestWAL(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	wal, err := OpenWAL(dir)
+	assert.Nil(err)
+
+	first, err := wal.Append([]byte("one"))
+	assert.Nil(err)
+	second, err := wal.Append([]byte("two"))
+	assert.Nil(err)
+	_, err = wal.Append([]byte("three"))
+	assert.Nil(err)
+	assert.Equal(3, wal.Len())
+
+	assert.Nil(wal.Ack(second))
+	assert.Nil(wal.Ack(second), "acknowledging twice should be a no-op")
+	assert.Equal(2, wal.Len())
+	assert.Nil(wal.Close())
+
+	wal, err = OpenWAL(dir)
+	assert.Nil(err)
+	defer wal.Close()
+	pending := wal.Pending()
+	assert.Len(pending, 2)
+	assert.Equal(first, pending[0].ID)
+	assert.Equal("one", string(pending[0].Data))
+	assert.Equal("three", string(pending[1].Data))
+
+	next, err := wal.Append([]byte("four"))
+	assert.Nil(err)
+	assert.True(next > pending[1].ID, "ids should not be reused after reopening")
+}
+
+func TestWALRemovesAckedSegments(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	wal, err := OpenWAL(dir, OptWALMaxSegmentBytes(64))
+	assert.Nil(err)
+	defer wal.Close()
+
+	var ids []uint64
+	for x := 0; x < 8; x++ {
+		id, err := wal.Append([]byte("0123456789"))
+		assert.Nil(err)
+		ids = append(ids, id)
+	}
+	segments, err := listWALSegments(dir)
+	assert.Nil(err)
+	assert.True(len(segments) > 2)
+
+	for _, id := range ids {
+		assert.Nil(wal.Ack(id))
+	}
+	segments, err = listWALSegments(dir)
+	assert.Nil(err)
+	assert.Len(segments, 1)
+	assert.Zero(wal.Len())
+}
+
+func TestWALCompact(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	wal, err := OpenWAL(dir, OptWALMaxSegmentBytes(1<<10))
+	assert.Nil(err)
+
+	var ids []uint64
+	for x := 0; x < 4; x++ {
+		id, err := wal.Append([]byte("0123456789"))
+		assert.Nil(err)
+		ids = append(ids, id)
+	}
+	// ack all but the first entry in the head segment
+	for _, id := range ids[1:] {
+		assert.Nil(wal.Ack(id))
+	}
+	wal.MaxSegmentBytes = 32
+	last, err := wal.Append([]byte("0123456789"))
+	assert.Nil(err)
+
+	// rotating compacts the head segment, moving the first entry to the active segment.
+	segments, err := listWALSegments(dir)
+	assert.Nil(err)
+	assert.Len(segments, 1)
+	assert.Nil(wal.Close())
+
+	wal, err = OpenWAL(dir)
+	assert.Nil(err)
+	defer wal.Close()
+	pending := wal.Pending()
+	assert.Len(pending, 2)
+	assert.Equal(ids[0], pending[0].ID)
+	assert.Equal(last, pending[1].ID)
+}
+
+func TestWALMaxBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	wal, err := OpenWAL(t.TempDir(), OptWALMaxBytes(64))
+	assert.Nil(err)
+	defer wal.Close()
+
+	first, err := wal.Append([]byte("0123456789"))
+	assert.Nil(err)
+	_, err = wal.Append([]byte("0123456789"))
+	assert.Nil(err)
+	_, err = wal.Append([]byte("0123456789"))
+	assert.True(ex.Is(err, ErrWALFull))
+	assert.True(wal.Size() <= 64)
+
+	assert.Nil(wal.Ack(first))
+	assert.Nil(wal.Compact())
+}
+
+func TestWALTornWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	wal, err := OpenWAL(dir)
+	assert.Nil(err)
+	_, err = wal.Append([]byte("one"))
+	assert.Nil(err)
+	_, err = wal.Append([]byte("two"))
+	assert.Nil(err)
+	size := wal.Size()
+	assert.Nil(wal.Close())
+
+	// simulate a crash partway through writing the last record.
+	segments, err := listWALSegments(dir)
+	assert.Nil(err)
+	assert.Nil(os.Truncate(segments[0].Path, size-2))
+
+	wal, err = OpenWAL(dir)
+	assert.Nil(err)
+	defer wal.Close()
+	pending := wal.Pending()
+	assert.Len(pending, 1)
+	assert.Equal("one", string(pending[0].Data))
+
+	_, err = wal.Append([]byte("three"))
+	assert.Nil(err)
+	assert.Len(wal.Pending(), 2)
+}
+
+func TestReadWALRecordLengthExceedsSegment(t *testing.T) {
+	assert := assert.New(t)
+
+	var header [walRecordHeaderSize]byte
+	header[0] = walRecordAppend
+	binary.BigEndian.PutUint64(header[1:9], 1)
+	binary.BigEndian.PutUint32(header[9:13], math.MaxUint32)
+
+	_, err := readWALRecord(bytes.NewReader(header[:]), int64(len(header)))
+	assert.True(ex.Is(err, ErrWALCorrupt))
+}
+
+func TestWALClosed(t *testing.T) {
+	assert := assert.New(t)
+
+	wal, err := OpenWAL(t.TempDir())
+	assert.Nil(err)
+	assert.Nil(wal.Close())
+	assert.Nil(wal.Close())
+
+	_, err = wal.Append([]byte("one"))
+	assert.True(ex.Is(err, ErrWALClosed))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/blend/go-sdk/collections"
 	"github.com/blend/go-sdk/ex"
 	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/retry"
 	"github.com/blend/go-sdk/stats"
 	"github.com/blend/go-sdk/timeutil"
 )
@@ -64,6 +65,44 @@ func OptMaxLen(maxLen int) Option {
 	}
 }
 
+// OptMaxBytes sets the auto-flush buffer's maximum size in bytes, as measured by its sizer.
+// Items that would cause the buffer to exceed the maximum size trigger a flush before they are added.
+func OptMaxBytes(maxBytes int) Option {
+	return func(afb *Buffer) {
+		afb.MaxBytes = maxBytes
+	}
+}
+
+// OptSizer sets the auto-flush buffer's item sizer, used with OptMaxBytes.
+func OptSizer(sizer Sizer) Option {
+	return func(afb *Buffer) {
+		afb.Sizer = sizer
+	}
+}
+
+// OptRetry sets the auto-flush buffer to retry failed flushes with the given retry options.
+//
+// Retry defaults (i.e. the number of attempts and the delay) are taken from the retry package.
+// Flushes that fail every attempt are passed to the dead letter handler with the last handler error.
+func OptRetry(options ...retry.Option) Option {
+	return func(afb *Buffer) {
+		var retryOptions retry.Options
+		retry.DefaultOptions(&retryOptions)
+		for _, option := range options {
+			option(&retryOptions)
+		}
+		afb.Retry = &retryOptions
+	}
+}
+
+// OptDeadLetter sets the auto-flush buffer's dead letter handler, which is called
+// with the contents of flushes that fail after any retries.
+func OptDeadLetter(deadLetter DeadLetterAction) Option {
+	return func(afb *Buffer) {
+		afb.DeadLetter = deadLetter
+	}
+}
+
 // OptInterval sets the auto-flush buffer's interval.
 func OptInterval(d time.Duration) Option {
 	return func(afb *Buffer) {
@@ -116,6 +155,9 @@ func OptTracer(tracer Tracer) Option {
 // Action is an action called by an  buffer.
 type Action func(context.Context, []interface{}) error
 
+// DeadLetterAction is called with the contents of a failed flush and the error it failed with.
+type DeadLetterAction func(context.Context, []interface{}, error)
+
 // Buffer is a backing store that operates either on a fixed length flush or a fixed interval flush.
 // A handler should be provided but without one the buffer will just clear.
 // Adds that would cause fixed length flushes do not block on the flush handler.
@@ -128,16 +170,21 @@ type Buffer struct {
 	Tracer Tracer
 
 	MaxLen              int
+	MaxBytes            int
+	Sizer               Sizer
 	Interval            time.Duration
 	Parallelism         int
 	MaxFlushes          int
 	ShutdownGracePeriod time.Duration
 
-	contentsMu sync.Mutex
-	contents   *collections.RingBuffer
+	contentsMu    sync.Mutex
+	contents      *collections.RingBuffer
+	contentsBytes int
 
-	Handler Action
-	Errors  chan error
+	Handler    Action
+	Retry      *retry.Options
+	DeadLetter DeadLetterAction
+	Errors     chan error
 
 	intervalWorker    *async.Interval
 	flushes           chan Flush
@@ -245,7 +292,7 @@ func (ab *Buffer) Stop() error {
 	if ab.contents.Len() > 0 {
 		ab.flushes <- Flush{
 			Context:  timeoutContext,
-			Contents: ab.contents.Drain(),
+			Contents: ab.drainUnsafe(),
 		}
 	}
 
@@ -321,15 +368,14 @@ func (ab *Buffer) Add(ctx context.Context, obj interface{}) {
 		}()
 	}
 
-	var flush []interface{}
+	size := ab.size(obj)
 	ab.contentsMu.Lock()
 	bufferLength = ab.contents.Len()
-	ab.contents.Enqueue(obj)
-	if ab.contents.Len() >= ab.MaxLen {
-		flush = ab.contents.Drain()
-	}
+	flushes := ab.addUnsafe(obj, size)
 	ab.contentsMu.Unlock()
-	ab.unsafeFlushAsync(ctx, flush)
+	for _, flush := range flushes {
+		ab.unsafeFlushAsync(ctx, flush)
+	}
 }
 
 // AddMany adds many objects to the buffer at once.
@@ -349,14 +395,15 @@ func (ab *Buffer) AddMany(ctx context.Context, objs ...interface{}) {
 		}()
 	}
 
+	sizes := make([]int, len(objs))
+	for index, obj := range objs {
+		sizes[index] = ab.size(obj)
+	}
 	var flushes [][]interface{}
 	ab.contentsMu.Lock()
 	bufferLength = ab.contents.Len()
-	for _, obj := range objs {
-		ab.contents.Enqueue(obj)
-		if ab.contents.Len() >= ab.MaxLen {
-			flushes = append(flushes, ab.contents.Drain())
-		}
+	for index, obj := range objs {
+		flushes = append(flushes, ab.addUnsafe(obj, sizes[index])...)
 	}
 	ab.contentsMu.Unlock()
 	for _, flush := range flushes {
@@ -368,12 +415,42 @@ func (ab *Buffer) AddMany(ctx context.Context, objs ...interface{}) {
 // This call is asynchronous, in that it will call the flush handler on its own goroutine.
 func (ab *Buffer) FlushAsync(ctx context.Context) error {
 	ab.contentsMu.Lock()
-	contents := ab.contents.Drain()
+	contents := ab.drainUnsafe()
 	ab.contentsMu.Unlock()
 	ab.unsafeFlushAsync(ctx, contents)
 	return nil
 }
 
+// addUnsafe adds an object to the buffer and returns the contents of any flushes it triggers.
+func (ab *Buffer) addUnsafe(obj interface{}, size int) (flushes [][]interface{}) {
+	if ab.MaxBytes > 0 && ab.contents.Len() > 0 && ab.contentsBytes+size > ab.MaxBytes {
+		flushes = append(flushes, ab.drainUnsafe())
+	}
+	ab.contents.Enqueue(obj)
+	ab.contentsBytes += size
+	if ab.contents.Len() >= ab.MaxLen || (ab.MaxBytes > 0 && ab.contentsBytes >= ab.MaxBytes) {
+		flushes = append(flushes, ab.drainUnsafe())
+	}
+	return
+}
+
+// drainUnsafe empties the buffer and returns its contents.
+func (ab *Buffer) drainUnsafe() []interface{} {
+	ab.contentsBytes = 0
+	return ab.contents.Drain()
+}
+
+// size returns the size of an object if the buffer has a max bytes limit.
+func (ab *Buffer) size(obj interface{}) int {
+	if ab.MaxBytes <= 0 {
+		return 0
+	}
+	if ab.Sizer != nil {
+		return ab.Sizer(obj)
+	}
+	return JSONSizer(obj)
+}
+
 // workerAction is called by the  workers.
 func (ab *Buffer) workerAction(ctx context.Context, obj interface{}) (err error) {
 	typed, ok := obj.(Flush)
@@ -390,7 +467,55 @@ func (ab *Buffer) workerAction(ctx context.Context, obj interface{}) (err error)
 		start := time.Now().UTC()
 		defer func() { ab.maybeStatElapsed(ctx, MetricFlushHandlerElapsed, start) }()
 	}
-	err = ab.Handler(typed.Context, typed.Contents)
+	err = ab.handle(typed)
+	if err != nil && ab.DeadLetter != nil {
+		ab.maybeStatCount(ctx, MetricFlushDeadLetter, 1)
+		ab.DeadLetter(typed.Context, typed.Contents, err)
+	}
+	return
+}
+
+// handle calls the handler for a flush, retrying it if the buffer has retry options.
+//
+// It returns the error from the last attempt; it does not wait after the last attempt,
+// and stops retrying if the flush context is canceled.
+func (ab *Buffer) handle(flush Flush) error {
+	if ab.Retry == nil {
+		return ab.callHandler(flush.Context, flush.Contents)
+	}
+	ctx := flush.Context
+	for attempt := uint(0); ; attempt++ {
+		err := ab.callHandler(ctx, flush.Contents)
+		if err == nil || attempt+1 >= ab.Retry.MaxAttempts {
+			return err
+		}
+		if ab.Retry.ShouldRetryProvider != nil && !ab.Retry.ShouldRetryProvider(err) {
+			return err
+		}
+		var delay time.Duration
+		if ab.Retry.DelayProvider != nil {
+			delay = ab.Retry.DelayProvider(ctx, attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			// the flush failed; report the handler error rather than the cancellation.
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		ab.maybeStatCount(ctx, MetricFlushRetry, 1)
+	}
+}
+
+// callHandler calls the handler, recovering panics so failed flushes reach the dead letter handler.
+func (ab *Buffer) callHandler(ctx context.Context, contents []interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ex.New(r)
+		}
+	}()
+	err = ab.Handler(ctx, contents)
 	return
 }
 
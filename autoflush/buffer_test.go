@@ -17,6 +17,7 @@ import (
 
 	"github.com/blend/go-sdk/assert"
 	"github.com/blend/go-sdk/graceful"
+	"github.com/blend/go-sdk/retry"
 	"github.com/blend/go-sdk/stats"
 )
 
@@ -172,6 +173,180 @@ func Test_Buffer_Stats(t *testing.T) {
 	})
 }
 
+func Test_Buffer_MaxBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	flushes := make(chan []interface{}, 4)
+	handler := func(_ context.Context, objects []interface{}) error {
+		flushes <- objects
+		return nil
+	}
+
+	afb := New(handler,
+		OptMaxLen(100),
+		OptMaxBytes(10),
+		OptSizer(func(obj interface{}) int { return len(obj.(string)) }),
+		OptInterval(time.Hour),
+	)
+
+	go func() { _ = afb.Start() }()
+	<-afb.NotifyStarted()
+	defer func() { _ = afb.Stop() }()
+
+	afb.Add(context.TODO(), "aaaa")
+	afb.Add(context.TODO(), "bbbb")
+	// would exceed the max bytes, so the first two are flushed
+	afb.Add(context.TODO(), "cccc")
+	assert.Equal([]interface{}{"aaaa", "bbbb"}, <-flushes)
+
+	// reaching the max bytes exactly flushes
+	afb.AddMany(context.TODO(), "dddddd", "eeeeeeeeeeee")
+	assert.Equal([]interface{}{"cccc", "dddddd"}, <-flushes)
+	// items larger than the max bytes are flushed on their own
+	assert.Equal([]interface{}{"eeeeeeeeeeee"}, <-flushes)
+}
+
+func Test_Buffer_RetryDeadLetter(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	handler := func(_ context.Context, objects []interface{}) error {
+		if atomic.AddInt32(&attempts, 1) < 4 {
+			return fmt.Errorf("this is only a test")
+		}
+		return nil
+	}
+	deadLetters := make(chan []interface{}, 1)
+	errors := make(chan error, 1)
+	collector := stats.NewMockCollector(32)
+
+	afb := New(handler,
+		OptMaxLen(2),
+		OptInterval(time.Hour),
+		OptErrors(errors),
+		OptStats(collector),
+		OptRetry(retry.OptMaxAttempts(2), retry.OptConstantDelay(time.Millisecond)),
+		OptDeadLetter(func(_ context.Context, objects []interface{}, err error) {
+			assert.Equal("this is only a test", err.Error())
+			deadLetters <- objects
+		}),
+	)
+
+	go func() { _ = afb.Start() }()
+	<-afb.NotifyStarted()
+	defer func() { _ = afb.Stop() }()
+
+	// fails twice and is dead lettered
+	afb.AddMany(context.TODO(), "foo", "bar")
+	assert.Equal([]interface{}{"foo", "bar"}, <-deadLetters)
+	assert.NotNil(<-errors)
+	assert.Equal(2, atomic.LoadInt32(&attempts))
+
+	// fails once and succeeds on retry
+	afb.AddMany(context.TODO(), "baz", "buzz")
+	for atomic.LoadInt32(&attempts) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Empty(deadLetters)
+
+	var retries, dead int
+	for _, metric := range collector.AllMetrics() {
+		switch metric.Name {
+		case MetricFlushRetry:
+			retries++
+		case MetricFlushDeadLetter:
+			dead++
+		}
+	}
+	assert.Equal(2, retries)
+	assert.Equal(1, dead)
+}
+
+func Test_Buffer_RetryNoDelayAfterLastAttempt(t *testing.T) {
+	assert := assert.New(t)
+
+	deadLetters := make(chan error, 1)
+	afb := New(func(_ context.Context, _ []interface{}) error {
+		return fmt.Errorf("this is only a test")
+	},
+		OptMaxLen(1),
+		OptInterval(time.Hour),
+		OptRetry(retry.OptMaxAttempts(1), retry.OptConstantDelay(time.Hour)),
+		OptDeadLetter(func(_ context.Context, _ []interface{}, err error) {
+			deadLetters <- err
+		}),
+	)
+
+	go func() { _ = afb.Start() }()
+	<-afb.NotifyStarted()
+	defer func() { _ = afb.Stop() }()
+
+	afb.Add(context.TODO(), "foo")
+	select {
+	case err := <-deadLetters:
+		assert.Equal("this is only a test", err.Error())
+	case <-time.After(5 * time.Second):
+		assert.FailNow("the flush should be dead lettered without waiting for the retry delay")
+	}
+}
+
+func Test_Buffer_RetryCanceledReturnsLastError(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deadLetters := make(chan error, 1)
+	afb := New(func(_ context.Context, _ []interface{}) error {
+		cancel()
+		return fmt.Errorf("this is only a test")
+	},
+		OptMaxLen(10),
+		OptInterval(time.Hour),
+		OptRetry(retry.OptMaxAttempts(3), retry.OptConstantDelay(time.Hour)),
+		OptDeadLetter(func(_ context.Context, _ []interface{}, err error) {
+			deadLetters <- err
+		}),
+	)
+
+	go func() { _ = afb.Start() }()
+	<-afb.NotifyStarted()
+	defer func() { _ = afb.Stop() }()
+
+	afb.Add(ctx, "foo")
+	assert.Nil(afb.FlushAsync(ctx))
+	assert.Equal("this is only a test", (<-deadLetters).Error())
+}
+
+func Test_Buffer_DeadLetterPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	deadLetters := make(chan error, 1)
+	afb := New(func(_ context.Context, _ []interface{}) error {
+		panic("this is only a test")
+	},
+		OptMaxLen(1),
+		OptInterval(time.Hour),
+		OptDeadLetter(func(_ context.Context, _ []interface{}, err error) {
+			deadLetters <- err
+		}),
+	)
+
+	go func() { _ = afb.Start() }()
+	<-afb.NotifyStarted()
+	defer func() { _ = afb.Stop() }()
+
+	afb.Add(context.TODO(), "foo")
+	assert.NotNil(<-deadLetters)
+}
+
+func Test_JSONSizer(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(3, JSONSizer("foo"))
+	assert.Equal(3, JSONSizer([]byte("foo")))
+	assert.Equal(10, JSONSizer(map[string]int{"foo": 10}))
+	assert.Zero(JSONSizer(func() {}))
+}
+
 func BenchmarkBuffer(b *testing.B) {
 	buffer := New(func(_ context.Context, objects []interface{}) error {
 		if len(objects) > 128 {
@@ -7,7 +7,11 @@ Use of this source code is governed by a MIT license that can be found in the LI
 
 package autoflush
 
-import "time"
+import (
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
 
 // Defaults
 const (
@@ -17,6 +21,12 @@ const (
 	DefaultShutdownGracePeriod = 10 * time.Second
 )
 
+// Errors
+const (
+	// ErrUnexpectedItemType is returned by typed buffers when an item is not of the expected type.
+	ErrUnexpectedItemType ex.Class = "autoflush; unexpected item type"
+)
+
 // Metric names
 const (
 	MetricFlush               string = "autoflush.flush"
@@ -25,6 +35,8 @@ const (
 	MetricFlushHandler        string = "autoflush.flush.handler"
 	MetricFlushHandlerElapsed string = "autoflush.flush.handler.elapsed"
 	MetricFlushQueueLength    string = "autoflush.flush.queue_length"
+	MetricFlushRetry          string = "autoflush.flush.retry"
+	MetricFlushDeadLetter     string = "autoflush.flush.dead_letter"
 	MetricBufferLength        string = "autoflush.buffer.length"
 	MetricAdd                 string = "autoflush.add"
 	MetricAddElapsed          string = "autoflush.add.elapsed"
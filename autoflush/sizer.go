@@ -0,0 +1,29 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package autoflush
+
+import "encoding/json"
+
+// Sizer returns the size of a buffered item in bytes.
+type Sizer func(interface{}) int
+
+// JSONSizer returns the size of an item's json representation.
+// Strings and byte slices are measured directly.
+func JSONSizer(obj interface{}) int {
+	switch typed := obj.(type) {
+	case []byte:
+		return len(typed)
+	case string:
+		return len(typed)
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,96 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package autoflush
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// TypedAction is a typed action called by a typed buffer.
+type TypedAction[T any] func(context.Context, []T) error
+
+// TypedDeadLetterAction is called with the typed contents of a failed flush and the error it failed with.
+type TypedDeadLetterAction[T any] func(context.Context, []T, error)
+
+// NewTyped creates a new buffer whose handler receives typed items.
+//
+// Flushes that contain items not of the expected type fail with an `ErrUnexpectedItemType` error
+// without calling the handler.
+func NewTyped[T any](handler TypedAction[T], options ...Option) *TypedBuffer[T] {
+	return &TypedBuffer[T]{
+		Buffer: New(func(ctx context.Context, contents []interface{}) error {
+			typed, err := typedContents[T](contents)
+			if err != nil {
+				return err
+			}
+			return handler(ctx, typed)
+		}, options...),
+	}
+}
+
+// OptSizerTyped sets the buffer's item sizer from a typed sizer.
+//
+// Items not of the expected type are sized with `JSONSizer`.
+func OptSizerTyped[T any](sizer func(T) int) Option {
+	return OptSizer(func(obj interface{}) int {
+		typed, ok := obj.(T)
+		if !ok {
+			return JSONSizer(obj)
+		}
+		return sizer(typed)
+	})
+}
+
+// OptDeadLetterTyped sets the buffer's dead letter handler from a typed handler.
+//
+// Items not of the expected type are omitted from the contents passed to the handler.
+func OptDeadLetterTyped[T any](deadLetter TypedDeadLetterAction[T]) Option {
+	return OptDeadLetter(func(ctx context.Context, contents []interface{}, err error) {
+		typed, _ := typedContents[T](contents)
+		deadLetter(ctx, typed, err)
+	})
+}
+
+// TypedBuffer is a buffer of typed items.
+type TypedBuffer[T any] struct {
+	*Buffer
+}
+
+// Add adds a new item to the buffer.
+func (tb *TypedBuffer[T]) Add(ctx context.Context, obj T) {
+	tb.Buffer.Add(ctx, obj)
+}
+
+// AddMany adds many items to the buffer at once.
+func (tb *TypedBuffer[T]) AddMany(ctx context.Context, objs ...T) {
+	untyped := make([]interface{}, len(objs))
+	for index, obj := range objs {
+		untyped[index] = obj
+	}
+	tb.Buffer.AddMany(ctx, untyped...)
+}
+
+// typedContents converts flush contents to a typed slice, omitting and returning an error
+// for items not of the expected type.
+func typedContents[T any](contents []interface{}) ([]T, error) {
+	typed := make([]T, 0, len(contents))
+	var err error
+	for _, obj := range contents {
+		if typedObj, ok := obj.(T); ok {
+			typed = append(typed, typedObj)
+			continue
+		}
+		if err == nil {
+			err = ex.New(ErrUnexpectedItemType, ex.OptMessagef("expected: %v, actual: %T", reflect.TypeOf((*T)(nil)).Elem(), obj))
+		}
+	}
+	return typed, err
+}
@@ -0,0 +1,72 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package autoflush
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+type typedTestItem struct {
+	Name string `json:"name"`
+}
+
+func Test_TypedBuffer(t *testing.T) {
+	assert := assert.New(t)
+
+	flushes := make(chan []typedTestItem, 2)
+	deadLetters := make(chan []typedTestItem, 1)
+	afb := NewTyped(func(_ context.Context, items []typedTestItem) error {
+		if items[0].Name == "bad" {
+			return fmt.Errorf("this is only a test")
+		}
+		flushes <- items
+		return nil
+	},
+		OptMaxLen(10),
+		OptMaxBytes(8),
+		OptSizerTyped(func(item typedTestItem) int { return len(item.Name) }),
+		OptDeadLetterTyped(func(_ context.Context, items []typedTestItem, _ error) {
+			deadLetters <- items
+		}),
+		OptInterval(time.Hour),
+	)
+
+	go func() { _ = afb.Start() }()
+	<-afb.NotifyStarted()
+	defer func() { _ = afb.Stop() }()
+
+	afb.AddMany(context.TODO(), typedTestItem{Name: "foo"}, typedTestItem{Name: "bar"})
+	afb.Add(context.TODO(), typedTestItem{Name: "buzz"})
+	assert.Equal([]typedTestItem{{Name: "foo"}, {Name: "bar"}}, <-flushes)
+
+	assert.Nil(afb.FlushAsync(context.TODO()))
+	assert.Equal([]typedTestItem{{Name: "buzz"}}, <-flushes)
+
+	afb.Add(context.TODO(), typedTestItem{Name: "bad"})
+	assert.Nil(afb.FlushAsync(context.TODO()))
+	assert.Equal([]typedTestItem{{Name: "bad"}}, <-deadLetters)
+}
+
+func Test_TypedContents(t *testing.T) {
+	assert := assert.New(t)
+
+	typed, err := typedContents[string]([]interface{}{"foo", "bar"})
+	assert.Nil(err)
+	assert.Equal([]string{"foo", "bar"}, typed)
+
+	typed, err = typedContents[string]([]interface{}{"foo", 1, "bar"})
+	assert.True(ex.Is(err, ErrUnexpectedItemType))
+	assert.Contains(ex.ErrMessage(err), "expected: string, actual: int")
+	assert.Equal([]string{"foo", "bar"}, typed)
+}
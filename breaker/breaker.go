@@ -79,6 +79,24 @@ type Breaker struct {
 	// Counts are stats for the breaker.
 	Counts Counts
 
+	// Window is an optional sliding window of call outcomes; if set, the breaker opens
+	// when the failure rate or slow call rate of the window exceed their thresholds.
+	Window Window
+	// MinimumCalls is the minimum number of calls in the window before the rates are considered.
+	MinimumCalls int64
+	// FailureRateThreshold is the fraction of calls in the window, from 0 to 1, that must
+	// fail for the breaker to open. If it is 0 the failure rate is not considered.
+	FailureRateThreshold float64
+	// SlowCallDuration is the duration above which calls are considered slow.
+	SlowCallDuration time.Duration
+	// SlowCallRateThreshold is the fraction of calls in the window, from 0 to 1, that must
+	// be slow for the breaker to open. If it is 0 the slow call rate is not considered.
+	SlowCallRateThreshold float64
+	// HalfOpenRampUp, if set, is the period over which a half-open breaker admits a growing
+	// fraction of calls, rather than just `HalfOpenMaxActions` calls. The breaker closes once
+	// the period has elapsed without failures.
+	HalfOpenRampUp time.Duration
+
 	// state is the current Breaker state (Closed, HalfOpen, Open etc.)
 	state State
 	// generation is the current state generation.
@@ -87,6 +105,10 @@ type Breaker struct {
 	// It is set when we change state according to the interval
 	// and the current time.
 	stateExpiresAt time.Time
+	// stateChangedAt is the time when the current state was entered.
+	stateChangedAt time.Time
+	// halfOpenAttempts is the number of calls attempted, admitted or not, while ramping up.
+	halfOpenAttempts int64
 }
 
 // Do runs the given action if the Breaker accepts it.
@@ -101,23 +123,43 @@ func (b *Breaker) Do(ctx context.Context, action Action) (interface{}, error) {
 		}
 		return nil, err
 	}
+	started := b.now()
 	defer func() {
 		if r := recover(); r != nil {
-			b.afterAction(ctx, generation, false)
+			b.afterAction(ctx, generation, false, b.now().Sub(started))
 		}
 	}()
 
 	res, err := action(ctx)
-	b.afterAction(ctx, generation, err == nil)
+	b.afterAction(ctx, generation, err == nil, b.now().Sub(started))
 	return res, err
 }
 
+// Snapshot returns the current state and counts of the breaker, including the counts
+// of the sliding window if one is set, e.g. for stats reporting.
+func (b *Breaker) Snapshot(ctx context.Context) Snapshot {
+	b.Lock()
+	defer b.Unlock()
+
+	now := b.now()
+	state, generation := b.evaluateState(ctx, now)
+	snapshot := Snapshot{
+		State:      state,
+		Generation: generation,
+		Counts:     b.Counts,
+	}
+	if b.Window != nil {
+		snapshot.Window = b.Window.Counts(now)
+	}
+	return snapshot
+}
+
 // EvaluateState returns the current state of the CircuitBreaker.
 func (b *Breaker) EvaluateState(ctx context.Context) State {
 	b.Lock()
 	defer b.Unlock()
 
-	now := time.Now()
+	now := b.now()
 	state, _ := b.evaluateState(ctx, now)
 	return state
 }
@@ -131,7 +173,7 @@ func (b *Breaker) beforeAction(ctx context.Context) (int64, error) {
 
 	if state == StateOpen {
 		return generation, ex.New(ErrOpenState)
-	} else if state == StateHalfOpen && b.Counts.Requests >= b.HalfOpenMaxActions {
+	} else if state == StateHalfOpen && !b.admitHalfOpen(now) {
 		return generation, ex.New(ErrTooManyRequests)
 	}
 
@@ -139,12 +181,45 @@ func (b *Breaker) beforeAction(ctx context.Context) (int64, error) {
 	return generation, nil
 }
 
-func (b *Breaker) afterAction(ctx context.Context, currentGeneration int64, success bool) {
+// admitHalfOpen returns if a call should be admitted while half-open.
+//
+// Without a ramp up, only `HalfOpenMaxActions` calls are admitted. With a ramp up, at least
+// `HalfOpenMaxActions` calls are admitted, and beyond that the fraction of attempted calls
+// that are admitted grows with the time elapsed since the breaker became half-open.
+func (b *Breaker) admitHalfOpen(now time.Time) bool {
+	if b.HalfOpenRampUp <= 0 {
+		return b.Counts.Requests < b.HalfOpenMaxActions
+	}
+	b.halfOpenAttempts++
+	if b.Counts.Requests < b.HalfOpenMaxActions {
+		return true
+	}
+	return float64(b.Counts.Requests) < b.halfOpenRampFraction(now)*float64(b.halfOpenAttempts)
+}
+
+// halfOpenRampFraction returns the fraction of calls admitted while ramping up, from 0 to 1.
+func (b *Breaker) halfOpenRampFraction(now time.Time) float64 {
+	if b.HalfOpenRampUp <= 0 {
+		return 1
+	}
+	fraction := float64(now.Sub(b.stateChangedAt)) / float64(b.HalfOpenRampUp)
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+func (b *Breaker) afterAction(ctx context.Context, currentGeneration int64, success bool, elapsed time.Duration) {
 	b.Lock()
 	defer b.Unlock()
 
 	now := b.now()
 	state, generation := b.evaluateState(ctx, now)
+	// the window slides independently of the closed expiry interval, so
+	// outcomes are recorded even if the closed generation has changed.
+	if state == StateClosed && b.Window != nil {
+		b.Window.Record(now, !success, b.SlowCallDuration > 0 && elapsed >= b.SlowCallDuration)
+	}
 	if generation != currentGeneration {
 		return
 	}
@@ -161,11 +236,14 @@ func (b *Breaker) success(ctx context.Context, state State, now time.Time) {
 		atomic.AddInt64(&b.Counts.TotalSuccesses, 1)
 		atomic.AddInt64(&b.Counts.ConsecutiveSuccesses, 1)
 		atomic.StoreInt64(&b.Counts.ConsecutiveFailures, 0)
+		if b.Window != nil && b.shouldOpen(ctx) {
+			b.setState(ctx, StateOpen, now)
+		}
 	case StateHalfOpen:
 		atomic.AddInt64(&b.Counts.TotalSuccesses, 1)
 		atomic.AddInt64(&b.Counts.ConsecutiveSuccesses, 1)
 		atomic.StoreInt64(&b.Counts.ConsecutiveFailures, 0)
-		if b.Counts.ConsecutiveSuccesses >= b.HalfOpenMaxActions {
+		if b.Counts.ConsecutiveSuccesses >= b.HalfOpenMaxActions && b.halfOpenRampFraction(now) >= 1 {
 			b.setState(ctx, StateClosed, now)
 		}
 	}
@@ -206,6 +284,10 @@ func (b *Breaker) setState(ctx context.Context, state State, now time.Time) {
 
 	previousState := b.state
 	b.state = state
+	b.stateChangedAt = now
+	if b.Window != nil {
+		b.Window.Reset()
+	}
 	b.incrementGeneration(now)
 	if b.OnStateChange != nil {
 		b.OnStateChange(ctx, previousState, b.state, b.generation)
@@ -215,6 +297,7 @@ func (b *Breaker) setState(ctx context.Context, state State, now time.Time) {
 func (b *Breaker) incrementGeneration(now time.Time) {
 	atomic.AddInt64(&b.generation, 1)
 	b.Counts = Counts{}
+	b.halfOpenAttempts = 0
 
 	var zero time.Time
 	switch b.state {
@@ -235,9 +318,23 @@ func (b *Breaker) shouldOpen(ctx context.Context) bool {
 	if b.ShouldOpenProvider != nil {
 		return b.ShouldOpenProvider(ctx, b.Counts)
 	}
+	if b.Window != nil {
+		return b.shouldOpenWindow(b.Window.Counts(b.now()))
+	}
 	return b.Counts.ConsecutiveFailures > DefaultConsecutiveFailures
 }
 
+// shouldOpenWindow returns if the window counts exceed the failure rate or slow call rate thresholds.
+func (b *Breaker) shouldOpenWindow(counts WindowCounts) bool {
+	if counts.Calls == 0 || counts.Calls < b.MinimumCalls {
+		return false
+	}
+	if b.FailureRateThreshold > 0 && counts.FailureRate() >= b.FailureRateThreshold {
+		return true
+	}
+	return b.SlowCallRateThreshold > 0 && counts.SlowCallRate() >= b.SlowCallRateThreshold
+}
+
 func (b *Breaker) now() time.Time {
 	if b.NowProvider != nil {
 		return b.NowProvider()
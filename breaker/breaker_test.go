@@ -183,3 +183,131 @@ func TestBreakerCallsOnOpenHandler(t *testing.T) {
 	assert.True(didCallOpen)
 	assert.Equal("on open", res)
 }
+
+func TestBreakerFailureRateThreshold(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	b := MustNew(
+		OptWindow(NewCountWindow(10)),
+		OptMinimumCalls(4),
+		OptFailureRateThreshold(0.5),
+	)
+
+	// below the minimum calls the breaker stays closed regardless of the rate
+	assert.Nil(fail(ctx, b))
+	assert.Nil(fail(ctx, b))
+	assert.Nil(succeed(ctx, b))
+	assert.Equal(StateClosed, b.EvaluateState(ctx))
+
+	// the fourth call meets the minimum calls with a failure rate of 0.75
+	assert.Nil(succeed(ctx, b))
+	assert.Equal(StateOpen, b.EvaluateState(ctx))
+	assert.Equal(WindowCounts{}, b.Snapshot(ctx).Window, "the window should be reset when the state changes")
+}
+
+func TestBreakerFailureRateThresholdNotMet(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	b := MustNew(
+		OptWindow(NewCountWindow(10)),
+		OptMinimumCalls(4),
+		OptFailureRateThreshold(0.6),
+	)
+	for x := 0; x < 10; x++ {
+		if x%3 == 0 {
+			assert.Nil(fail(ctx, b))
+		} else {
+			assert.Nil(succeed(ctx, b))
+		}
+	}
+	assert.Equal(StateClosed, b.EvaluateState(ctx))
+	assert.Equal(WindowCounts{Calls: 10, Failures: 4}, b.Snapshot(ctx).Window)
+}
+
+func TestBreakerSlowCallRateThreshold(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	b := MustNew(
+		OptNowProvider(func() time.Time { return now }),
+		OptWindow(NewTimeWindow(time.Minute)),
+		OptMinimumCalls(2),
+		OptSlowCallRateThreshold(time.Second, 1),
+	)
+
+	slow := func(_ context.Context) (interface{}, error) {
+		now = now.Add(2 * time.Second)
+		return nil, nil
+	}
+	_, err := b.Do(ctx, slow)
+	assert.Nil(err)
+	assert.Nil(succeed(ctx, b))
+	assert.Equal(StateClosed, b.EvaluateState(ctx))
+	assert.Equal(WindowCounts{Calls: 2, SlowCalls: 1}, b.Snapshot(ctx).Window)
+
+	// the fast call slides out of the window
+	now = now.Add(time.Minute)
+	_, err = b.Do(ctx, slow)
+	assert.Nil(err)
+	assert.Equal(StateClosed, b.EvaluateState(ctx))
+	_, err = b.Do(ctx, slow)
+	assert.Nil(err)
+	assert.Equal(StateOpen, b.EvaluateState(ctx))
+}
+
+func TestBreakerHalfOpenRampUp(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	now := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	b := MustNew(
+		OptNowProvider(func() time.Time { return now }),
+		OptOpenExpiryInterval(time.Second),
+		OptHalfOpenMaxActions(1),
+		OptHalfOpenRampUp(10*time.Second),
+	)
+	b.setState(ctx, StateOpen, now)
+	now = now.Add(2 * time.Second)
+	assert.Equal(StateHalfOpen, b.EvaluateState(ctx))
+
+	// the first call is always admitted, but doesn't close the breaker during the ramp up
+	assert.Nil(succeed(ctx, b))
+	assert.Equal(StateHalfOpen, b.EvaluateState(ctx))
+
+	// at the start of the ramp up no further calls are admitted
+	assert.True(ErrIsTooManyRequests(succeed(ctx, b)))
+
+	// half way through the ramp up roughly half of the calls are admitted
+	now = now.Add(5 * time.Second)
+	var admitted int
+	for x := 0; x < 10; x++ {
+		if err := succeed(ctx, b); err == nil {
+			admitted++
+		}
+	}
+	assert.True(admitted >= 4 && admitted <= 6, admitted)
+	assert.Equal(StateHalfOpen, b.EvaluateState(ctx))
+
+	// once the ramp up has elapsed the next success closes the breaker
+	now = now.Add(5 * time.Second)
+	assert.Nil(succeed(ctx, b))
+	assert.Equal(StateClosed, b.EvaluateState(ctx))
+}
+
+func TestBreakerSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	b := MustNew(OptWindow(NewCountWindow(5)))
+	assert.Nil(succeed(ctx, b))
+	assert.Nil(fail(ctx, b))
+
+	snapshot := b.Snapshot(ctx)
+	assert.Equal(StateClosed, snapshot.State)
+	assert.Equal(2, snapshot.Counts.Requests)
+	assert.Equal(1, snapshot.Counts.TotalFailures)
+	assert.Equal(WindowCounts{Calls: 2, Failures: 1}, snapshot.Window)
+}
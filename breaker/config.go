@@ -14,4 +14,25 @@ type Config struct {
 	HalfOpenMaxActions   int64         `json:"halfOpenMaxActions" yaml:"halfOpenMaxActions"`
 	ClosedExpiryInterval time.Duration `json:"closedExpiryInterval" yaml:"closedExpiryInterval"`
 	OpenExpiryInterval   time.Duration `json:"openExpiryInterval" yaml:"openExpiryInterval"`
+
+	WindowSize            int           `json:"windowSize,omitempty" yaml:"windowSize,omitempty"`
+	WindowDuration        time.Duration `json:"windowDuration,omitempty" yaml:"windowDuration,omitempty"`
+	MinimumCalls          int64         `json:"minimumCalls,omitempty" yaml:"minimumCalls,omitempty"`
+	FailureRateThreshold  float64       `json:"failureRateThreshold,omitempty" yaml:"failureRateThreshold,omitempty"`
+	SlowCallDuration      time.Duration `json:"slowCallDuration,omitempty" yaml:"slowCallDuration,omitempty"`
+	SlowCallRateThreshold float64       `json:"slowCallRateThreshold,omitempty" yaml:"slowCallRateThreshold,omitempty"`
+	HalfOpenRampUp        time.Duration `json:"halfOpenRampUp,omitempty" yaml:"halfOpenRampUp,omitempty"`
+}
+
+// Window returns the sliding window for the config; a window of the last `WindowSize`
+// calls takes precedence over a window of the last `WindowDuration`.
+// It returns nil if neither are set.
+func (c Config) Window() Window {
+	if c.WindowSize > 0 {
+		return NewCountWindow(c.WindowSize)
+	}
+	if c.WindowDuration > 0 {
+		return NewTimeWindow(c.WindowDuration)
+	}
+	return nil
 }
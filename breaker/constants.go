@@ -15,4 +15,5 @@ const (
 	DefaultOpenExpiryInterval   = 60 * time.Second
 	DefaultHalfOpenMaxActions   = 1
 	DefaultConsecutiveFailures  = 5
+	DefaultTimeWindowBuckets    = 10
 )
@@ -44,6 +44,14 @@ func OptConfig(cfg Config) Option {
 		b.HalfOpenMaxActions = cfg.HalfOpenMaxActions
 		b.ClosedExpiryInterval = cfg.ClosedExpiryInterval
 		b.OpenExpiryInterval = cfg.OpenExpiryInterval
+		if window := cfg.Window(); window != nil {
+			b.Window = window
+		}
+		b.MinimumCalls = cfg.MinimumCalls
+		b.FailureRateThreshold = cfg.FailureRateThreshold
+		b.SlowCallDuration = cfg.SlowCallDuration
+		b.SlowCallRateThreshold = cfg.SlowCallRateThreshold
+		b.HalfOpenRampUp = cfg.HalfOpenRampUp
 		return nil
 	}
 }
@@ -79,3 +87,45 @@ func OptNowProvider(provider NowProvider) Option {
 		return nil
 	}
 }
+
+// OptWindow sets the sliding window used by the failure rate and slow call rate thresholds.
+func OptWindow(window Window) Option {
+	return func(b *Breaker) error {
+		b.Window = window
+		return nil
+	}
+}
+
+// OptMinimumCalls sets the minimum number of calls in the window before the rates are considered.
+func OptMinimumCalls(minimumCalls int64) Option {
+	return func(b *Breaker) error {
+		b.MinimumCalls = minimumCalls
+		return nil
+	}
+}
+
+// OptFailureRateThreshold sets the fraction of calls in the window, from 0 to 1, that must fail for the breaker to open.
+func OptFailureRateThreshold(threshold float64) Option {
+	return func(b *Breaker) error {
+		b.FailureRateThreshold = threshold
+		return nil
+	}
+}
+
+// OptSlowCallRateThreshold sets the duration above which calls are slow, and the fraction of calls
+// in the window, from 0 to 1, that must be slow for the breaker to open.
+func OptSlowCallRateThreshold(slowCallDuration time.Duration, threshold float64) Option {
+	return func(b *Breaker) error {
+		b.SlowCallDuration = slowCallDuration
+		b.SlowCallRateThreshold = threshold
+		return nil
+	}
+}
+
+// OptHalfOpenRampUp sets the period over which a half-open breaker admits a growing fraction of calls.
+func OptHalfOpenRampUp(rampUp time.Duration) Option {
+	return func(b *Breaker) error {
+		b.HalfOpenRampUp = rampUp
+		return nil
+	}
+}
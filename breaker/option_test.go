@@ -94,3 +94,48 @@ func TestOptNowProvider(t *testing.T) {
 	assert.Nil(OptNowProvider(time.Now)(b))
 	assert.NotNil(b.NowProvider)
 }
+
+func TestOptConfigWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	b := new(Breaker)
+	assert.Nil(OptConfig(Config{
+		WindowSize:            10,
+		MinimumCalls:          5,
+		FailureRateThreshold:  0.5,
+		SlowCallDuration:      time.Second,
+		SlowCallRateThreshold: 0.75,
+		HalfOpenRampUp:        30 * time.Second,
+	})(b))
+	assert.NotNil(b.Window)
+	_, isCountWindow := b.Window.(*CountWindow)
+	assert.True(isCountWindow)
+	assert.Equal(5, b.MinimumCalls)
+	assert.Equal(0.5, b.FailureRateThreshold)
+	assert.Equal(time.Second, b.SlowCallDuration)
+	assert.Equal(0.75, b.SlowCallRateThreshold)
+	assert.Equal(30*time.Second, b.HalfOpenRampUp)
+
+	b = new(Breaker)
+	assert.Nil(OptConfig(Config{WindowDuration: time.Minute})(b))
+	_, isTimeWindow := b.Window.(*TimeWindow)
+	assert.True(isTimeWindow)
+}
+
+func TestOptWindowThresholds(t *testing.T) {
+	assert := assert.New(t)
+
+	b := new(Breaker)
+	assert.Nil(b.Window)
+	assert.Nil(OptWindow(NewCountWindow(5))(b))
+	assert.NotNil(b.Window)
+	assert.Nil(OptMinimumCalls(3)(b))
+	assert.Equal(3, b.MinimumCalls)
+	assert.Nil(OptFailureRateThreshold(0.5)(b))
+	assert.Equal(0.5, b.FailureRateThreshold)
+	assert.Nil(OptSlowCallRateThreshold(time.Second, 0.25)(b))
+	assert.Equal(time.Second, b.SlowCallDuration)
+	assert.Equal(0.25, b.SlowCallRateThreshold)
+	assert.Nil(OptHalfOpenRampUp(time.Minute)(b))
+	assert.Equal(time.Minute, b.HalfOpenRampUp)
+}
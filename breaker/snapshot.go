@@ -0,0 +1,16 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package breaker
+
+// Snapshot is a point in time view of a breaker's state and counts.
+type Snapshot struct {
+	State      State        `json:"state"`
+	Generation int64        `json:"generation"`
+	Counts     Counts       `json:"counts"`
+	Window     WindowCounts `json:"window"`
+}
@@ -0,0 +1,178 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package breaker
+
+import (
+	"time"
+)
+
+// Window is a sliding window of call outcomes used to decide if the breaker should open.
+//
+// Windows are not safe for concurrent use on their own; the breaker guards them with its lock.
+type Window interface {
+	// Record records the outcome of a call.
+	Record(now time.Time, failure, slow bool)
+	// Counts returns the counts of the calls currently in the window.
+	Counts(now time.Time) WindowCounts
+	// Reset clears the window.
+	Reset()
+}
+
+// WindowCounts are the counts of the calls in a sliding window.
+type WindowCounts struct {
+	Calls     int64 `json:"calls"`
+	Failures  int64 `json:"failures"`
+	SlowCalls int64 `json:"slowCalls"`
+}
+
+// FailureRate returns the fraction of calls that failed, from 0 to 1.
+func (wc WindowCounts) FailureRate() float64 {
+	if wc.Calls == 0 {
+		return 0
+	}
+	return float64(wc.Failures) / float64(wc.Calls)
+}
+
+// SlowCallRate returns the fraction of calls that were slow, from 0 to 1.
+func (wc WindowCounts) SlowCallRate() float64 {
+	if wc.Calls == 0 {
+		return 0
+	}
+	return float64(wc.SlowCalls) / float64(wc.Calls)
+}
+
+var (
+	_ Window = (*CountWindow)(nil)
+	_ Window = (*TimeWindow)(nil)
+)
+
+// NewCountWindow returns a window of the last N calls.
+func NewCountWindow(size int) *CountWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &CountWindow{
+		outcomes: make([]windowOutcome, size),
+	}
+}
+
+// CountWindow is a sliding window of the last N calls.
+type CountWindow struct {
+	outcomes []windowOutcome
+	next     int
+	full     bool
+	counts   WindowCounts
+}
+
+type windowOutcome struct {
+	failure bool
+	slow    bool
+}
+
+// Record implements Window.
+func (cw *CountWindow) Record(_ time.Time, failure, slow bool) {
+	if cw.full {
+		evicted := cw.outcomes[cw.next]
+		cw.counts.Calls--
+		if evicted.failure {
+			cw.counts.Failures--
+		}
+		if evicted.slow {
+			cw.counts.SlowCalls--
+		}
+	}
+	cw.outcomes[cw.next] = windowOutcome{failure: failure, slow: slow}
+	cw.counts.Calls++
+	if failure {
+		cw.counts.Failures++
+	}
+	if slow {
+		cw.counts.SlowCalls++
+	}
+	cw.next = (cw.next + 1) % len(cw.outcomes)
+	if cw.next == 0 {
+		cw.full = true
+	}
+}
+
+// Counts implements Window.
+func (cw *CountWindow) Counts(_ time.Time) WindowCounts {
+	return cw.counts
+}
+
+// Reset implements Window.
+func (cw *CountWindow) Reset() {
+	cw.next = 0
+	cw.full = false
+	cw.counts = WindowCounts{}
+}
+
+// NewTimeWindow returns a window of the calls made in the last duration.
+//
+// Calls are grouped into `DefaultTimeWindowBuckets` buckets, so calls leave the
+// window in steps of the duration divided by the number of buckets.
+func NewTimeWindow(duration time.Duration) *TimeWindow {
+	width := duration / DefaultTimeWindowBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &TimeWindow{
+		width:   width,
+		buckets: make([]timeWindowBucket, DefaultTimeWindowBuckets),
+	}
+}
+
+// TimeWindow is a sliding window of the calls made in the last duration.
+type TimeWindow struct {
+	width   time.Duration
+	buckets []timeWindowBucket
+}
+
+type timeWindowBucket struct {
+	index  int64
+	counts WindowCounts
+}
+
+// Record implements Window.
+func (tw *TimeWindow) Record(now time.Time, failure, slow bool) {
+	index := now.UnixNano() / int64(tw.width)
+	bucket := &tw.buckets[index%int64(len(tw.buckets))]
+	if bucket.index != index {
+		bucket.index = index
+		bucket.counts = WindowCounts{}
+	}
+	bucket.counts.Calls++
+	if failure {
+		bucket.counts.Failures++
+	}
+	if slow {
+		bucket.counts.SlowCalls++
+	}
+}
+
+// Counts implements Window.
+func (tw *TimeWindow) Counts(now time.Time) (counts WindowCounts) {
+	index := now.UnixNano() / int64(tw.width)
+	oldest := index - int64(len(tw.buckets)) + 1
+	for _, bucket := range tw.buckets {
+		if bucket.index < oldest || bucket.index > index {
+			continue
+		}
+		counts.Calls += bucket.counts.Calls
+		counts.Failures += bucket.counts.Failures
+		counts.SlowCalls += bucket.counts.SlowCalls
+	}
+	return
+}
+
+// Reset implements Window.
+func (tw *TimeWindow) Reset() {
+	for index := range tw.buckets {
+		tw.buckets[index] = timeWindowBucket{}
+	}
+}
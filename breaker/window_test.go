@@ -0,0 +1,70 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestWindowCountsRates(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(WindowCounts{}.FailureRate())
+	assert.Zero(WindowCounts{}.SlowCallRate())
+
+	counts := WindowCounts{Calls: 4, Failures: 1, SlowCalls: 2}
+	assert.Equal(0.25, counts.FailureRate())
+	assert.Equal(0.5, counts.SlowCallRate())
+}
+
+func TestCountWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	cw := NewCountWindow(3)
+	cw.Record(now, true, false)
+	cw.Record(now, false, true)
+	assert.Equal(WindowCounts{Calls: 2, Failures: 1, SlowCalls: 1}, cw.Counts(now))
+
+	cw.Record(now, false, false)
+	cw.Record(now, false, false) // evicts the first failure
+	assert.Equal(WindowCounts{Calls: 3, Failures: 0, SlowCalls: 1}, cw.Counts(now))
+
+	cw.Record(now, false, false) // evicts the slow call
+	assert.Equal(WindowCounts{Calls: 3}, cw.Counts(now))
+
+	cw.Reset()
+	assert.Equal(WindowCounts{}, cw.Counts(now))
+	cw.Record(now, true, true)
+	assert.Equal(WindowCounts{Calls: 1, Failures: 1, SlowCalls: 1}, cw.Counts(now))
+}
+
+func TestTimeWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	tw := NewTimeWindow(10 * time.Second)
+	tw.Record(now, true, false)
+	tw.Record(now.Add(5*time.Second), false, true)
+	assert.Equal(WindowCounts{Calls: 2, Failures: 1, SlowCalls: 1}, tw.Counts(now.Add(5*time.Second)))
+
+	// the first call slides out of the window
+	assert.Equal(WindowCounts{Calls: 1, SlowCalls: 1}, tw.Counts(now.Add(10*time.Second)))
+	// both calls slide out of the window
+	assert.Equal(WindowCounts{}, tw.Counts(now.Add(15*time.Second)))
+
+	// buckets are reused once they expire
+	tw.Record(now.Add(20*time.Second), true, false)
+	assert.Equal(WindowCounts{Calls: 1, Failures: 1}, tw.Counts(now.Add(20*time.Second)))
+
+	tw.Reset()
+	assert.Equal(WindowCounts{}, tw.Counts(now.Add(20*time.Second)))
+}
@@ -0,0 +1,83 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	_ Backend = (*MemoryBackend)(nil)
+)
+
+// Backend is a remote cache tier, e.g. a database table, that is shared between processes.
+type Backend interface {
+	// Get returns the entry for a key if it is present and has not expired.
+	Get(ctx context.Context, key string) (entry BackendEntry, found bool, err error)
+	// Set sets the entry for a key; a zero expiry means the entry does not expire.
+	Set(ctx context.Context, key string, entry BackendEntry) error
+	// Remove removes the entry for a key, if it is present.
+	Remove(ctx context.Context, key string) error
+}
+
+// BackendEntry is a serialized value stored in a backend.
+type BackendEntry struct {
+	Data    []byte
+	Expires time.Time
+}
+
+// IsExpired returns if the entry has expired at a given time.
+func (be BackendEntry) IsExpired(now time.Time) bool {
+	return !be.Expires.IsZero() && now.After(be.Expires)
+}
+
+// NewMemoryBackend returns a new in-memory backend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		Entries: make(map[string]BackendEntry),
+	}
+}
+
+// MemoryBackend is an in-memory backend, intended as a reference implementation and for tests.
+type MemoryBackend struct {
+	sync.Mutex
+	Entries map[string]BackendEntry
+}
+
+// Get implements Backend.
+func (mb *MemoryBackend) Get(_ context.Context, key string) (entry BackendEntry, found bool, err error) {
+	mb.Lock()
+	defer mb.Unlock()
+	entry, found = mb.Entries[key]
+	if found && entry.IsExpired(time.Now().UTC()) {
+		delete(mb.Entries, key)
+		return BackendEntry{}, false, nil
+	}
+	return
+}
+
+// Set implements Backend.
+func (mb *MemoryBackend) Set(_ context.Context, key string, entry BackendEntry) error {
+	mb.Lock()
+	defer mb.Unlock()
+	if mb.Entries == nil {
+		mb.Entries = make(map[string]BackendEntry)
+	}
+	mb.Entries[key] = entry
+	return nil
+}
+
+// Remove implements Backend.
+func (mb *MemoryBackend) Remove(_ context.Context, key string) error {
+	mb.Lock()
+	defer mb.Unlock()
+	delete(mb.Entries, key)
+	return nil
+}
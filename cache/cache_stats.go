@@ -14,4 +14,20 @@ type Stats struct {
 	Count     int
 	SizeBytes int
 	MaxAge    time.Duration
+
+	// Expirations is the number of values removed because they expired.
+	Expirations int
+	// Evictions is the number of values evicted because the cache was over capacity.
+	Evictions int
+	// Rejections is the number of new values not admitted by the eviction policy; their remove handlers are not called.
+	Rejections int
+
+	// Refreshes is the number of values refreshed in the background by `GetOrSet`.
+	Refreshes int
+	// RefreshErrors is the number of background refreshes that failed.
+	RefreshErrors int
+	// StaleHits is the number of stale values returned by `GetOrSet` while they were refreshed in the background.
+	StaleHits int
+	// StaleIfErrorHits is the number of stale values returned by `GetOrSet` because the value provider failed.
+	StaleIfErrorHits int
 }
@@ -0,0 +1,87 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+var (
+	_ Codec = (*JSONCodec)(nil)
+	_ Codec = (*GobCodec)(nil)
+)
+
+// Codec serializes cached values so they can be stored in a remote backend.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// JSONCodec serializes values as json.
+//
+// Values are decoded into a new value returned by `New`, which should be a pointer,
+// or into generic json values (maps, slices, strings, numbers etc.) if it is unset.
+type JSONCodec struct {
+	New func() interface{}
+}
+
+// Encode implements Codec.
+func (jc JSONCodec) Encode(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	return data, nil
+}
+
+// Decode implements Codec.
+func (jc JSONCodec) Decode(data []byte) (interface{}, error) {
+	if jc.New == nil {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, ex.New(err)
+		}
+		return value, nil
+	}
+	value := jc.New()
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, ex.New(err)
+	}
+	return value, nil
+}
+
+// GobCodec serializes values with `encoding/gob`, preserving their types.
+//
+// Concrete types of values must be registered with `gob.Register`.
+type GobCodec struct{}
+
+type gobValue struct {
+	Value interface{}
+}
+
+// Encode implements Codec.
+func (gc GobCodec) Encode(value interface{}) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if err := gob.NewEncoder(buffer).Encode(gobValue{Value: value}); err != nil {
+		return nil, ex.New(err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Decode implements Codec.
+func (gc GobCodec) Decode(data []byte) (interface{}, error) {
+	var value gobValue
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, ex.New(err)
+	}
+	return value.Value, nil
+}
@@ -0,0 +1,58 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"encoding/gob"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+type codecTestValue struct {
+	Name  string
+	Count int
+}
+
+func init() {
+	gob.Register(codecTestValue{})
+}
+
+func TestJSONCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	codec := JSONCodec{New: func() interface{} { return new(codecTestValue) }}
+	data, err := codec.Encode(codecTestValue{Name: "foo", Count: 2})
+	assert.Nil(err)
+	value, err := codec.Decode(data)
+	assert.Nil(err)
+	assert.Equal(&codecTestValue{Name: "foo", Count: 2}, value)
+
+	value, err = JSONCodec{}.Decode(data)
+	assert.Nil(err)
+	assert.Equal(map[string]interface{}{"Name": "foo", "Count": 2.0}, value)
+
+	_, err = codec.Decode([]byte("not json"))
+	assert.NotNil(err)
+	_, err = codec.Encode(func() {})
+	assert.NotNil(err)
+}
+
+func TestGobCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	codec := GobCodec{}
+	data, err := codec.Encode(codecTestValue{Name: "foo", Count: 2})
+	assert.Nil(err)
+	value, err := codec.Decode(data)
+	assert.Nil(err)
+	assert.Equal(codecTestValue{Name: "foo", Count: 2}, value)
+
+	_, err = codec.Decode([]byte("not gob"))
+	assert.NotNil(err)
+}
@@ -0,0 +1,101 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"hash/maphash"
+)
+
+const (
+	countMinSketchDepth      = 4
+	countMinSketchMaxCount   = 15
+	countMinSketchMinWidth   = 16
+	countMinSketchSampleSize = 10
+)
+
+// newCountMinSketch returns a new count-min sketch sized for a given number of keys.
+func newCountMinSketch(expectedEntries int) *countMinSketch {
+	width := countMinSketchMinWidth
+	for width < expectedEntries {
+		width <<= 1
+	}
+	cms := countMinSketch{
+		seed:  maphash.MakeSeed(),
+		width: uint64(width),
+		reset: width * countMinSketchSampleSize,
+	}
+	for row := range cms.rows {
+		cms.rows[row] = make([]uint8, width)
+	}
+	return &cms
+}
+
+// countMinSketch estimates how often keys have been seen using small saturating counters.
+//
+// Once the number of increments reaches a sample size all counters are halved,
+// so estimates favor recent accesses.
+type countMinSketch struct {
+	seed       maphash.Seed
+	width      uint64
+	rows       [countMinSketchDepth][]uint8
+	increments int
+	reset      int
+}
+
+// Increment records an access of a key.
+func (cms *countMinSketch) Increment(key interface{}) {
+	h1, h2 := cms.hash(key)
+	for row := range cms.rows {
+		index := (h1 + uint64(row)*h2) & (cms.width - 1)
+		if cms.rows[row][index] < countMinSketchMaxCount {
+			cms.rows[row][index]++
+		}
+	}
+	cms.increments++
+	if cms.increments >= cms.reset {
+		cms.halve()
+	}
+}
+
+// Estimate returns the estimated number of accesses of a key.
+func (cms *countMinSketch) Estimate(key interface{}) int {
+	h1, h2 := cms.hash(key)
+	min := uint8(countMinSketchMaxCount)
+	for row := range cms.rows {
+		index := (h1 + uint64(row)*h2) & (cms.width - 1)
+		if count := cms.rows[row][index]; count < min {
+			min = count
+		}
+	}
+	return int(min)
+}
+
+// Reset clears all counters.
+func (cms *countMinSketch) Reset() {
+	for row := range cms.rows {
+		for index := range cms.rows[row] {
+			cms.rows[row][index] = 0
+		}
+	}
+	cms.increments = 0
+}
+
+func (cms *countMinSketch) halve() {
+	for row := range cms.rows {
+		for index := range cms.rows[row] {
+			cms.rows[row][index] >>= 1
+		}
+	}
+	cms.increments /= 2
+}
+
+// hash returns two hashes of a key used to pick a counter in each row.
+func (cms *countMinSketch) hash(key interface{}) (h1, h2 uint64) {
+	sum := hashKey(cms.seed, key)
+	return sum, (sum >> 32) | 1
+}
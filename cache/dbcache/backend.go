@@ -0,0 +1,100 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+)
+
+var (
+	_ cache.Backend = (*Backend)(nil)
+)
+
+// NewBackend returns a new database table backend.
+func NewBackend(conn *db.Connection, options ...BackendOption) *Backend {
+	b := Backend{
+		Conn:  conn,
+		Table: DefaultTable,
+	}
+	for _, opt := range options {
+		opt(&b)
+	}
+	return &b
+}
+
+// BackendOption is an option for database table backends.
+type BackendOption func(*Backend)
+
+// OptTable sets the table entries are stored in, which may be schema qualified.
+func OptTable(table string) BackendOption {
+	return func(b *Backend) { b.Table = table }
+}
+
+// Backend is a cache backend that stores entries in a postgres table.
+//
+// The table is created by `Initialize`; expired entries are ignored by `Get`
+// and deleted by `Sweep`, which should be called periodically.
+type Backend struct {
+	Conn  *db.Connection
+	Table string
+}
+
+// Initialize creates the table if it doesn't exist.
+func (b *Backend) Initialize(ctx context.Context) error {
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	key TEXT NOT NULL PRIMARY KEY,
+	value BYTEA NOT NULL,
+	expires_utc TIMESTAMP
+)`, quoteIdentifier(b.Table))
+	return db.IgnoreExecResult(b.Conn.ExecContext(ctx, statement))
+}
+
+// Get implements cache.Backend.
+func (b *Backend) Get(ctx context.Context, key string) (entry cache.BackendEntry, found bool, err error) {
+	statement := fmt.Sprintf(`SELECT value, expires_utc FROM %s WHERE key = $1 AND (expires_utc IS NULL OR expires_utc > $2)`, quoteIdentifier(b.Table))
+	var expires sql.NullTime
+	found, err = b.Conn.QueryContext(ctx, statement, key, time.Now().UTC()).Scan(&entry.Data, &expires)
+	if err != nil {
+		err = ex.New(err)
+		return
+	}
+	if expires.Valid {
+		entry.Expires = expires.Time.UTC()
+	}
+	return
+}
+
+// Set implements cache.Backend.
+func (b *Backend) Set(ctx context.Context, key string, entry cache.BackendEntry) error {
+	statement := fmt.Sprintf(`INSERT INTO %s (key, value, expires_utc) VALUES ($1, $2, $3)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_utc = EXCLUDED.expires_utc`, quoteIdentifier(b.Table))
+	var expires sql.NullTime
+	if !entry.Expires.IsZero() {
+		expires = sql.NullTime{Time: entry.Expires.UTC(), Valid: true}
+	}
+	return db.IgnoreExecResult(b.Conn.ExecContext(ctx, statement, key, entry.Data, expires))
+}
+
+// Remove implements cache.Backend.
+func (b *Backend) Remove(ctx context.Context, key string) error {
+	statement := fmt.Sprintf(`DELETE FROM %s WHERE key = $1`, quoteIdentifier(b.Table))
+	return db.IgnoreExecResult(b.Conn.ExecContext(ctx, statement, key))
+}
+
+// Sweep deletes expired entries.
+func (b *Backend) Sweep(ctx context.Context) error {
+	statement := fmt.Sprintf(`DELETE FROM %s WHERE expires_utc < $1`, quoteIdentifier(b.Table))
+	return db.IgnoreExecResult(b.Conn.ExecContext(ctx, statement, time.Now().UTC()))
+}
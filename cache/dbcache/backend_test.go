@@ -0,0 +1,91 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/db"
+)
+
+func createTestBackend(t *testing.T) *Backend {
+	assert := assert.New(t)
+	backend := NewBackend(defaultDB(), OptTable(buildTestName("test_cache")))
+	assert.Nil(backend.Initialize(context.Background()))
+	t.Cleanup(func() {
+		_ = db.IgnoreExecResult(defaultDB().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdentifier(backend.Table))))
+	})
+	return backend
+}
+
+func TestBackend(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	backend := createTestBackend(t)
+	_, found, err := backend.Get(ctx, "foo")
+	assert.Nil(err)
+	assert.False(found)
+
+	expires := time.Date(2100, 01, 02, 03, 04, 05, 0, time.UTC)
+	assert.Nil(backend.Set(ctx, "foo", cache.BackendEntry{Data: []byte("bar"), Expires: expires}))
+	entry, found, err := backend.Get(ctx, "foo")
+	assert.Nil(err)
+	assert.True(found)
+	assert.Equal("bar", string(entry.Data))
+	assert.True(expires.Equal(entry.Expires))
+
+	// upserts replace the entry
+	assert.Nil(backend.Set(ctx, "foo", cache.BackendEntry{Data: []byte("baz")}))
+	entry, found, err = backend.Get(ctx, "foo")
+	assert.Nil(err)
+	assert.True(found)
+	assert.Equal("baz", string(entry.Data))
+	assert.True(entry.Expires.IsZero())
+
+	assert.Nil(backend.Remove(ctx, "foo"))
+	_, found, err = backend.Get(ctx, "foo")
+	assert.Nil(err)
+	assert.False(found)
+}
+
+func TestBackendExpired(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	backend := createTestBackend(t)
+	assert.Nil(backend.Set(ctx, "expired", cache.BackendEntry{Data: []byte("bar"), Expires: time.Now().UTC().Add(-time.Minute)}))
+	assert.Nil(backend.Set(ctx, "current", cache.BackendEntry{Data: []byte("bar"), Expires: time.Now().UTC().Add(time.Minute)}))
+
+	_, found, err := backend.Get(ctx, "expired")
+	assert.Nil(err)
+	assert.False(found)
+
+	assert.Nil(backend.Sweep(ctx))
+	var count int
+	_, err = defaultDB().Query(fmt.Sprintf("SELECT count(*) FROM %s", quoteIdentifier(backend.Table))).Scan(&count)
+	assert.Nil(err)
+	assert.Equal(1, count)
+}
+
+func TestBackendTwoTier(t *testing.T) {
+	assert := assert.New(t)
+
+	tt := cache.NewTwoTier(createTestBackend(t))
+	tt.Set("foo", "bar", cache.OptValueTTL(time.Minute))
+	tt.L1.Reset()
+
+	value, ok := tt.Get("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+}
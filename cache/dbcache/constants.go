@@ -0,0 +1,14 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+// Defaults
+const (
+	DefaultTable   = "cache_entries"
+	DefaultChannel = "cache_invalidations"
+)
@@ -0,0 +1,11 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+/*
+Package dbcache provides a postgres table backend and a postgres LISTEN/NOTIFY invalidation bus for two tier caches.
+*/
+package dbcache // import "github.com/blend/go-sdk/cache/dbcache"
@@ -0,0 +1,15 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import "github.com/blend/go-sdk/ex"
+
+// Error constants
+const (
+	ErrUnsupportedDriver ex.Class = "dbcache; listening for notifications requires the pgx driver"
+)
@@ -0,0 +1,46 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/stringutil"
+)
+
+func TestMain(m *testing.M) {
+	conn, err := db.New(
+		db.OptConfigFromEnv(),
+		db.OptSSLMode(db.SSLModeDisable),
+	)
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	if err = conn.Open(); err != nil {
+		logger.FatalExit(err)
+	}
+	defer conn.Close()
+	defaultConnection = conn
+	os.Exit(m.Run())
+}
+
+var (
+	defaultConnection *db.Connection
+)
+
+func defaultDB() *db.Connection {
+	return defaultConnection
+}
+
+func buildTestName(prefix string) string {
+	return fmt.Sprintf("%s_%s", prefix, stringutil.Random(stringutil.LowerLetters, 10))
+}
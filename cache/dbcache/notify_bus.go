@@ -0,0 +1,109 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+)
+
+var (
+	_ cache.InvalidationBus = (*NotifyBus)(nil)
+)
+
+// NewNotifyBus returns a new postgres LISTEN/NOTIFY invalidation bus.
+func NewNotifyBus(conn *db.Connection, options ...NotifyBusOption) *NotifyBus {
+	nb := NotifyBus{
+		Conn:    conn,
+		Channel: DefaultChannel,
+	}
+	for _, opt := range options {
+		opt(&nb)
+	}
+	return &nb
+}
+
+// NotifyBusOption is an option for notify buses.
+type NotifyBusOption func(*NotifyBus)
+
+// OptChannel sets the notification channel.
+func OptChannel(channel string) NotifyBusOption {
+	return func(nb *NotifyBus) { nb.Channel = channel }
+}
+
+// OptLog sets the logger that malformed notifications are written to.
+func OptLog(log logger.Log) NotifyBusOption {
+	return func(nb *NotifyBus) { nb.Log = log }
+}
+
+// NotifyBus broadcasts invalidations with postgres `NOTIFY` and receives them with `LISTEN`.
+//
+// Listening holds a connection from the pool for as long as it runs, and requires the pgx driver.
+type NotifyBus struct {
+	Conn    *db.Connection
+	Channel string
+	Log     logger.Log
+}
+
+// Publish implements cache.InvalidationBus.
+func (nb *NotifyBus) Publish(ctx context.Context, invalidation cache.Invalidation) error {
+	payload, err := json.Marshal(invalidation)
+	if err != nil {
+		return ex.New(err)
+	}
+	return db.IgnoreExecResult(nb.Conn.ExecContext(ctx, "SELECT pg_notify($1, $2)", nb.Channel, string(payload)))
+}
+
+// Listen implements cache.InvalidationBus.
+func (nb *NotifyBus) Listen(ctx context.Context, handler func(context.Context, cache.Invalidation)) error {
+	conn, err := nb.Conn.Connection.Conn(ctx)
+	if err != nil {
+		return ex.New(err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "LISTEN "+pgx.Identifier{nb.Channel}.Sanitize()); err != nil {
+		return ex.New(err)
+	}
+
+	// the connection is still listening when we stop, so it is
+	// always discarded rather than returned to the pool.
+	var listenErr error
+	_ = conn.Raw(func(driverConn interface{}) error {
+		typed, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			listenErr = ex.New(ErrUnsupportedDriver)
+			return driver.ErrBadConn
+		}
+		for {
+			notification, err := typed.Conn().WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					listenErr = ex.New(err)
+				}
+				return driver.ErrBadConn
+			}
+			var invalidation cache.Invalidation
+			if err := json.Unmarshal([]byte(notification.Payload), &invalidation); err != nil {
+				logger.MaybeErrorContext(ctx, nb.Log, ex.New(err))
+				continue
+			}
+			handler(ctx, invalidation)
+		}
+	})
+	return listenErr
+}
@@ -0,0 +1,49 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/cache"
+)
+
+func TestNotifyBus(t *testing.T) {
+	assert := assert.New(t)
+
+	bus := NewNotifyBus(defaultDB(), OptChannel(buildTestName("test_channel")))
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan cache.Invalidation, 1)
+	done := make(chan error)
+	go func() {
+		done <- bus.Listen(ctx, func(_ context.Context, invalidation cache.Invalidation) {
+			received <- invalidation
+		})
+	}()
+
+	// publish until the listener has subscribed to the channel
+	expected := cache.Invalidation{Source: "test", Key: "foo"}
+	var invalidation cache.Invalidation
+	deadline := time.After(5 * time.Second)
+	for invalidation == (cache.Invalidation{}) {
+		assert.Nil(bus.Publish(context.Background(), expected))
+		select {
+		case invalidation = <-received:
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			assert.FailNow("timed out waiting for notification")
+		}
+	}
+	assert.Equal(expected, invalidation)
+
+	cancel()
+	assert.Nil(<-done)
+}
@@ -0,0 +1,19 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbcache
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// quoteIdentifier quotes a possibly schema qualified identifier, e.g. `schema.table`.
+func quoteIdentifier(identifier string) string {
+	return pgx.Identifier(strings.Split(identifier, ".")).Sanitize()
+}
@@ -0,0 +1,138 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import "container/heap"
+
+var (
+	_ EvictionPolicy = (*LFUEvictionPolicy)(nil)
+	_ heap.Interface = (*lfuEntries)(nil)
+)
+
+// NewLFUEvictionPolicy returns a new least frequently used eviction policy.
+func NewLFUEvictionPolicy() *LFUEvictionPolicy {
+	return &LFUEvictionPolicy{
+		entries: make(map[interface{}]*lfuEntry),
+	}
+}
+
+// LFUEvictionPolicy evicts the least frequently used key, breaking ties by
+// evicting the least recently used of the least frequently used keys.
+//
+// Frequencies are only tracked while keys are in the cache.
+type LFUEvictionPolicy struct {
+	heap    lfuEntries
+	entries map[interface{}]*lfuEntry
+	clock   uint64
+}
+
+type lfuEntry struct {
+	key      interface{}
+	count    uint64
+	accessed uint64
+	index    int
+}
+
+// Len returns the number of keys.
+func (lfu *LFUEvictionPolicy) Len() int {
+	return len(lfu.entries)
+}
+
+// Count returns the access count for a key.
+func (lfu *LFUEvictionPolicy) Count(key interface{}) uint64 {
+	if entry, ok := lfu.entries[key]; ok {
+		return entry.count
+	}
+	return 0
+}
+
+// Add implements EvictionPolicy.
+func (lfu *LFUEvictionPolicy) Add(key interface{}) {
+	if _, ok := lfu.entries[key]; ok {
+		lfu.Touch(key)
+		return
+	}
+	lfu.clock++
+	entry := &lfuEntry{key: key, count: 1, accessed: lfu.clock}
+	lfu.entries[key] = entry
+	heap.Push(&lfu.heap, entry)
+}
+
+// Touch implements EvictionPolicy.
+func (lfu *LFUEvictionPolicy) Touch(key interface{}) {
+	entry, ok := lfu.entries[key]
+	if !ok {
+		return
+	}
+	lfu.clock++
+	entry.count++
+	entry.accessed = lfu.clock
+	heap.Fix(&lfu.heap, entry.index)
+}
+
+// Remove implements EvictionPolicy.
+func (lfu *LFUEvictionPolicy) Remove(key interface{}) {
+	entry, ok := lfu.entries[key]
+	if !ok {
+		return
+	}
+	heap.Remove(&lfu.heap, entry.index)
+	delete(lfu.entries, key)
+}
+
+// Victim implements EvictionPolicy.
+func (lfu *LFUEvictionPolicy) Victim() (interface{}, bool) {
+	if len(lfu.heap) == 0 {
+		return nil, false
+	}
+	return lfu.heap[0].key, true
+}
+
+// Admit implements EvictionPolicy; new keys are always admitted.
+func (lfu *LFUEvictionPolicy) Admit(_, _ interface{}) bool {
+	return true
+}
+
+// Reset implements EvictionPolicy.
+func (lfu *LFUEvictionPolicy) Reset() {
+	lfu.heap = nil
+	lfu.entries = make(map[interface{}]*lfuEntry)
+}
+
+// lfuEntries is a min heap of entries ordered by count and then by last access.
+type lfuEntries []*lfuEntry
+
+func (e lfuEntries) Len() int { return len(e) }
+
+func (e lfuEntries) Less(i, j int) bool {
+	if e[i].count == e[j].count {
+		return e[i].accessed < e[j].accessed
+	}
+	return e[i].count < e[j].count
+}
+
+func (e lfuEntries) Swap(i, j int) {
+	e[i], e[j] = e[j], e[i]
+	e[i].index = i
+	e[j].index = j
+}
+
+func (e *lfuEntries) Push(x interface{}) {
+	entry := x.(*lfuEntry)
+	entry.index = len(*e)
+	*e = append(*e, entry)
+}
+
+func (e *lfuEntries) Pop() interface{} {
+	old := *e
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*e = old[:n-1]
+	return entry
+}
@@ -0,0 +1,79 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import "container/list"
+
+var (
+	_ EvictionPolicy = (*LRUEvictionPolicy)(nil)
+)
+
+// NewLRUEvictionPolicy returns a new least recently used eviction policy.
+func NewLRUEvictionPolicy() *LRUEvictionPolicy {
+	return &LRUEvictionPolicy{
+		order:    list.New(),
+		elements: make(map[interface{}]*list.Element),
+	}
+}
+
+// LRUEvictionPolicy evicts the least recently used key.
+//
+// Unlike `LRUQueue` and `LRUHeap`, which order values by when they expire,
+// keys are ordered by when they were last added or accessed.
+type LRUEvictionPolicy struct {
+	order    *list.List
+	elements map[interface{}]*list.Element
+}
+
+// Len returns the number of keys.
+func (lru *LRUEvictionPolicy) Len() int {
+	return len(lru.elements)
+}
+
+// Add implements EvictionPolicy.
+func (lru *LRUEvictionPolicy) Add(key interface{}) {
+	if element, ok := lru.elements[key]; ok {
+		lru.order.MoveToBack(element)
+		return
+	}
+	lru.elements[key] = lru.order.PushBack(key)
+}
+
+// Touch implements EvictionPolicy.
+func (lru *LRUEvictionPolicy) Touch(key interface{}) {
+	if element, ok := lru.elements[key]; ok {
+		lru.order.MoveToBack(element)
+	}
+}
+
+// Remove implements EvictionPolicy.
+func (lru *LRUEvictionPolicy) Remove(key interface{}) {
+	if element, ok := lru.elements[key]; ok {
+		lru.order.Remove(element)
+		delete(lru.elements, key)
+	}
+}
+
+// Victim implements EvictionPolicy.
+func (lru *LRUEvictionPolicy) Victim() (interface{}, bool) {
+	if front := lru.order.Front(); front != nil {
+		return front.Value, true
+	}
+	return nil, false
+}
+
+// Admit implements EvictionPolicy; new keys are always admitted.
+func (lru *LRUEvictionPolicy) Admit(_, _ interface{}) bool {
+	return true
+}
+
+// Reset implements EvictionPolicy.
+func (lru *LRUEvictionPolicy) Reset() {
+	lru.order.Init()
+	lru.elements = make(map[interface{}]*list.Element)
+}
@@ -0,0 +1,28 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+// EvictionPolicy decides which values a size bounded local cache evicts once
+// it holds more than its maximum entries or bytes.
+//
+// Eviction policies are not safe for concurrent use on their own; the cache
+// guards them with its lock.
+type EvictionPolicy interface {
+	// Add adds a new key.
+	Add(key interface{})
+	// Touch records an access of a key, whether or not the key has been added.
+	Touch(key interface{})
+	// Remove removes a key.
+	Remove(key interface{})
+	// Victim returns the key that should be evicted next, if any.
+	Victim() (interface{}, bool)
+	// Admit returns if a new key should be added when adding it requires evicting the victim.
+	Admit(candidate, victim interface{}) bool
+	// Reset removes all keys.
+	Reset()
+}
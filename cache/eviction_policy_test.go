@@ -0,0 +1,123 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestLRUEvictionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	lru := NewLRUEvictionPolicy()
+	_, ok := lru.Victim()
+	assert.False(ok)
+
+	lru.Add("a")
+	lru.Add("b")
+	lru.Add("c")
+	assert.Equal(3, lru.Len())
+
+	victim, ok := lru.Victim()
+	assert.True(ok)
+	assert.Equal("a", victim)
+
+	lru.Touch("a")
+	lru.Touch("not-added")
+	victim, _ = lru.Victim()
+	assert.Equal("b", victim)
+	assert.Equal(3, lru.Len())
+
+	lru.Remove("b")
+	victim, _ = lru.Victim()
+	assert.Equal("c", victim)
+	assert.True(lru.Admit("d", "c"))
+
+	lru.Reset()
+	assert.Zero(lru.Len())
+	_, ok = lru.Victim()
+	assert.False(ok)
+}
+
+func TestLFUEvictionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	lfu := NewLFUEvictionPolicy()
+	_, ok := lfu.Victim()
+	assert.False(ok)
+
+	lfu.Add("a")
+	lfu.Add("b")
+	lfu.Add("c")
+	lfu.Touch("a")
+	lfu.Touch("a")
+	lfu.Touch("c")
+	assert.Equal(3, lfu.Count("a"))
+	assert.Equal(2, lfu.Count("c"))
+
+	victim, ok := lfu.Victim()
+	assert.True(ok)
+	assert.Equal("b", victim)
+
+	// ties are broken by the least recently used
+	lfu.Touch("b")
+	victim, _ = lfu.Victim()
+	assert.Equal("c", victim)
+
+	lfu.Remove("c")
+	victim, _ = lfu.Victim()
+	assert.Equal("b", victim)
+	assert.Zero(lfu.Count("c"))
+
+	lfu.Reset()
+	assert.Zero(lfu.Len())
+}
+
+func TestTinyLFUEvictionPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	tlfu := NewTinyLFUEvictionPolicy(16)
+	for x := 0; x < 5; x++ {
+		tlfu.Touch("hot")
+	}
+	tlfu.Add("hot")
+	assert.Equal(5, tlfu.Frequency("hot"))
+
+	// a key seen once is not admitted in place of a frequently used key
+	tlfu.Touch("cold")
+	assert.False(tlfu.Admit("cold", "hot"))
+
+	// frequency is tracked for keys that were not admitted
+	for x := 0; x < 5; x++ {
+		tlfu.Touch("cold")
+	}
+	assert.True(tlfu.Admit("cold", "hot"))
+
+	tlfu.Reset()
+	assert.Zero(tlfu.Frequency("hot"))
+	assert.Zero(tlfu.Len())
+}
+
+func TestCountMinSketchHalves(t *testing.T) {
+	assert := assert.New(t)
+
+	cms := newCountMinSketch(16)
+	for x := 0; x < 10; x++ {
+		cms.Increment("key")
+	}
+	assert.Equal(10, cms.Estimate("key"))
+
+	// once the sample size is reached the counters are halved
+	for x := 0; x < cms.reset; x++ {
+		cms.Increment(strconv.Itoa(x))
+	}
+	assert.True(cms.Estimate("key") < 10)
+}
@@ -0,0 +1,55 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+var (
+	_ EvictionPolicy = (*TinyLFUEvictionPolicy)(nil)
+)
+
+// NewTinyLFUEvictionPolicy returns a new TinyLFU eviction policy sized for
+// a given number of expected entries.
+func NewTinyLFUEvictionPolicy(expectedEntries int) *TinyLFUEvictionPolicy {
+	return &TinyLFUEvictionPolicy{
+		LRUEvictionPolicy: NewLRUEvictionPolicy(),
+		sketch:            newCountMinSketch(expectedEntries),
+	}
+}
+
+// TinyLFUEvictionPolicy evicts the least recently used key, but only admits a new key
+// if it has been accessed more frequently than the key it would evict.
+//
+// Access frequencies are estimated for all keys, including keys that are not in the cache,
+// with a count-min sketch that is periodically halved so that old accesses are forgotten.
+// This keeps one-off keys, e.g. from a scan, from flushing frequently used keys out of the cache.
+type TinyLFUEvictionPolicy struct {
+	*LRUEvictionPolicy
+	sketch *countMinSketch
+}
+
+// Frequency returns the estimated access frequency for a key.
+func (tlfu *TinyLFUEvictionPolicy) Frequency(key interface{}) int {
+	return tlfu.sketch.Estimate(key)
+}
+
+// Touch implements EvictionPolicy.
+func (tlfu *TinyLFUEvictionPolicy) Touch(key interface{}) {
+	tlfu.sketch.Increment(key)
+	tlfu.LRUEvictionPolicy.Touch(key)
+}
+
+// Admit implements EvictionPolicy; a new key is admitted if its estimated
+// frequency is greater than the victim's.
+func (tlfu *TinyLFUEvictionPolicy) Admit(candidate, victim interface{}) bool {
+	return tlfu.sketch.Estimate(candidate) > tlfu.sketch.Estimate(victim)
+}
+
+// Reset implements EvictionPolicy.
+func (tlfu *TinyLFUEvictionPolicy) Reset() {
+	tlfu.LRUEvictionPolicy.Reset()
+	tlfu.sketch.Reset()
+}
@@ -0,0 +1,90 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	_ InvalidationBus = (*LocalInvalidationBus)(nil)
+)
+
+// Invalidation is a message that a key has changed and should be removed from local caches.
+type Invalidation struct {
+	// Source identifies the cache that published the invalidation.
+	Source string `json:"source"`
+	// Key is the key that changed.
+	Key string `json:"key"`
+}
+
+// InvalidationBus broadcasts invalidations between caches, e.g. between replicas of a service.
+type InvalidationBus interface {
+	// Publish broadcasts an invalidation to all listeners.
+	Publish(ctx context.Context, invalidation Invalidation) error
+	// Listen calls a handler for each invalidation published, blocking until the context is cancelled.
+	Listen(ctx context.Context, handler func(context.Context, Invalidation)) error
+}
+
+// NewLocalInvalidationBus returns a new in-process invalidation bus.
+func NewLocalInvalidationBus() *LocalInvalidationBus {
+	return &LocalInvalidationBus{
+		listeners: make(map[int]func(context.Context, Invalidation)),
+	}
+}
+
+// LocalInvalidationBus is an in-process invalidation bus, e.g. for tests.
+//
+// Invalidations are delivered synchronously to each listener.
+type LocalInvalidationBus struct {
+	mu        sync.Mutex
+	nextID    int
+	listeners map[int]func(context.Context, Invalidation)
+}
+
+// Publish implements InvalidationBus.
+func (lib *LocalInvalidationBus) Publish(ctx context.Context, invalidation Invalidation) error {
+	lib.mu.Lock()
+	listeners := make([]func(context.Context, Invalidation), 0, len(lib.listeners))
+	for _, listener := range lib.listeners {
+		listeners = append(listeners, listener)
+	}
+	lib.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(ctx, invalidation)
+	}
+	return nil
+}
+
+// Listen implements InvalidationBus.
+func (lib *LocalInvalidationBus) Listen(ctx context.Context, handler func(context.Context, Invalidation)) error {
+	lib.mu.Lock()
+	if lib.listeners == nil {
+		lib.listeners = make(map[int]func(context.Context, Invalidation))
+	}
+	id := lib.nextID
+	lib.nextID++
+	lib.listeners[id] = handler
+	lib.mu.Unlock()
+
+	<-ctx.Done()
+
+	lib.mu.Lock()
+	delete(lib.listeners, id)
+	lib.mu.Unlock()
+	return nil
+}
+
+// Listeners returns the number of active listeners.
+func (lib *LocalInvalidationBus) Listeners() int {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	return len(lib.listeners)
+}
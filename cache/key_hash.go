@@ -0,0 +1,52 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"fmt"
+	"hash/maphash"
+)
+
+// hashKey returns a hash of a cache key.
+//
+// Strings and integers are hashed directly; other keys are hashed by their type and formatted value.
+func hashKey(seed maphash.Seed, key interface{}) uint64 {
+	switch typed := key.(type) {
+	case int:
+		return mixHash(uint64(typed))
+	case int64:
+		return mixHash(uint64(typed))
+	case int32:
+		return mixHash(uint64(typed))
+	case uint:
+		return mixHash(uint64(typed))
+	case uint64:
+		return mixHash(typed)
+	case uint32:
+		return mixHash(uint64(typed))
+	}
+
+	var h maphash.Hash
+	h.SetSeed(seed)
+	if typed, ok := key.(string); ok {
+		_, _ = h.WriteString(typed)
+	} else {
+		_, _ = h.WriteString(fmt.Sprintf("%T:%v", key, key))
+	}
+	return h.Sum64()
+}
+
+// mixHash spreads the bits of an integer key (the splitmix64 finalizer).
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
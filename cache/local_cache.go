@@ -11,6 +11,7 @@ import (
 	"context"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -53,12 +54,66 @@ func OptLRU(lruImplementation LRU) LocalCacheOption {
 	}
 }
 
+// OptMaxEntries sets the maximum number of values the cache holds.
+//
+// Once it is exceeded values are evicted by the eviction policy, which defaults to least recently used.
+func OptMaxEntries(maxEntries int) LocalCacheOption {
+	return func(lc *LocalCache) {
+		lc.MaxEntries = maxEntries
+	}
+}
+
+// OptMaxBytes sets the maximum estimated size in bytes of the values the cache holds.
+//
+// Once it is exceeded values are evicted by the eviction policy, which defaults to least recently used.
+// Sizes are estimated by the sizer, which defaults to `EstimateSize`.
+func OptMaxBytes(maxBytes int) LocalCacheOption {
+	return func(lc *LocalCache) {
+		lc.MaxBytes = maxBytes
+	}
+}
+
+// OptSizer sets the sizer used to estimate the size of values in bytes.
+func OptSizer(sizer Sizer) LocalCacheOption {
+	return func(lc *LocalCache) {
+		lc.Sizer = sizer
+	}
+}
+
+// OptEvictionPolicy sets the eviction policy used once the cache exceeds its maximum entries or bytes.
+func OptEvictionPolicy(policy EvictionPolicy) LocalCacheOption {
+	return func(lc *LocalCache) {
+		lc.EvictionPolicy = policy
+	}
+}
+
 // LocalCache is a memory LocalCache.
 type LocalCache struct {
 	sync.RWMutex
 	Data    map[interface{}]*Value
 	LRU     LRU
 	Sweeper *async.Interval
+
+	// MaxEntries is the maximum number of values held, if set.
+	MaxEntries int
+	// MaxBytes is the maximum estimated size in bytes of the values held, if set.
+	MaxBytes int
+	// Sizer estimates the size of values in bytes; if unset and `MaxBytes` is set it defaults to `EstimateSize`.
+	Sizer Sizer
+	// EvictionPolicy picks values to evict once the cache is over capacity; if unset
+	// and a maximum is set it defaults to least recently used.
+	EvictionPolicy EvictionPolicy
+
+	sizeBytes   int
+	expirations int
+	evictions   int
+	rejections  int
+	refreshing  map[interface{}]struct{}
+
+	refreshes        int64
+	refreshErrors    int64
+	staleHits        int64
+	staleIfErrorHits int64
 }
 
 // Start starts the sweeper.
@@ -95,8 +150,15 @@ func (lc *LocalCache) Sweep(ctx context.Context) error {
 
 	var keysToRemove []interface{}
 	var handlers []removeHandler
+	var stale []*Value
 	lc.LRU.Consume(func(v *Value) bool {
-		if !v.Expires.IsZero() && now.After(v.Expires) {
+		if v.IsExpired(now) {
+			// values that can still be served stale are kept, and
+			// pushed back onto the lru once it has been consumed.
+			if !now.After(v.RemoveAfter()) {
+				stale = append(stale, v)
+				return true
+			}
 			keysToRemove = append(keysToRemove, v.Key)
 			if v.OnRemove != nil {
 				handlers = append(handlers, removeHandler{
@@ -109,9 +171,21 @@ func (lc *LocalCache) Sweep(ctx context.Context) error {
 		return false
 	})
 
+	for _, v := range stale {
+		lc.LRU.Push(v)
+	}
+
+	policy := lc.evictionPolicyUnsafe()
 	for _, key := range keysToRemove {
+		if value, ok := lc.Data[key]; ok {
+			lc.sizeBytes -= value.Size
+		}
 		delete(lc.Data, key)
+		if policy != nil {
+			policy.Remove(key)
+		}
 	}
+	lc.expirations += len(keysToRemove)
 	lc.Unlock()
 
 	// call the handlers outside the critical section.
@@ -131,35 +205,19 @@ func (lc *LocalCache) Set(key, value interface{}, options ...ValueOption) {
 		panic("local cache: key is not comparable")
 	}
 
-	v := Value{
-		Timestamp: time.Now().UTC(),
-		Key:       key,
-		Value:     value,
-	}
-
-	for _, opt := range options {
-		opt(&v)
-	}
+	v := newValue(key, value, options...)
 
 	lc.Lock()
-	if lc.Data == nil {
-		lc.Data = make(map[interface{}]*Value)
-	}
-	if value, ok := lc.Data[key]; ok {
-		lc.LRU.Fix(&v)
-		*value = v
-	} else {
-		lc.Data[key] = &v
-		lc.LRU.Push(&v)
-	}
+	evicted := lc.setUnsafe(&v)
 	lc.Unlock()
+
+	// call the handlers outside the critical section.
+	callEvicted(evicted)
 }
 
 // Get gets a value based on a key.
 func (lc *LocalCache) Get(key interface{}) (value interface{}, hit bool) {
-	lc.RLock()
-	valueNode, ok := lc.Data[key]
-	lc.RUnlock()
+	valueNode, ok := lc.lookup(key)
 	if ok {
 		value = valueNode.Value
 		hit = true
@@ -170,6 +228,12 @@ func (lc *LocalCache) Get(key interface{}) (value interface{}, hit bool) {
 
 // GetOrSet gets a value by a key, and in the case of a miss, sets the value from a given value provider lazily.
 // Hit indicates that the provider was not called.
+//
+// Expired values are treated as misses unless they were set with `OptValueStaleWhileRevalidate`, in which case
+// the stale value is returned while it is refreshed in the background. Values set with `OptValueRefreshAhead`
+// are refreshed in the background shortly before they expire. Only one background refresh runs per key.
+// If a value set with `OptValueStaleIfError` has expired and the value provider returns an error, the
+// stale value is returned as a hit instead of the error.
 func (lc *LocalCache) GetOrSet(key interface{}, valueProvider func() (interface{}, error), options ...ValueOption) (value interface{}, hit bool, err error) {
 	if key == nil {
 		panic("local cache: nil key")
@@ -180,58 +244,48 @@ func (lc *LocalCache) GetOrSet(key interface{}, valueProvider func() (interface{
 	}
 
 	// check if we already have the value
-	lc.RLock()
-	valueNode, ok := lc.Data[key]
-	lc.RUnlock()
-
-	if ok {
-		value = valueNode.Value
-		hit = true
+	value, hit, stale := lc.getOrRefresh(key, valueProvider, options...)
+	if hit {
 		return
 	}
+	return lc.getOrSetSlow(key, valueProvider, stale, options...)
+}
 
+// getOrSetSlow calls the value provider on a miss and sets the value, or returns
+// the stale value (if it's set) if the value provider returns an error.
+func (lc *LocalCache) getOrSetSlow(key interface{}, valueProvider func() (interface{}, error), stale *Value, options ...ValueOption) (value interface{}, hit bool, err error) {
 	// call the value provider outside the critical section.
 	// this will create a meaningful gap between releasing the
 	// read lock and grabbing the write lock.
 	value, err = valueProvider()
 	if err != nil {
+		if stale != nil {
+			atomic.AddInt64(&lc.staleIfErrorHits, 1)
+			return stale.Value, true, nil
+		}
 		return
 	}
 
 	// we didn't have the value, grab the write lock
 	lc.Lock()
-	defer lc.Unlock()
 
 	// double checked locks for the children
 	// we do this because there may have been a write while we waited
 	// for the exclusive lock.
-	valueNode, ok = lc.Data[key]
-	if ok {
+	valueNode, ok := lc.Data[key]
+	if ok && !valueNode.IsExpired(time.Now().UTC()) {
 		value = valueNode.Value
+		lc.Unlock()
 		hit = true
 		return
 	}
 
-	// set up the value
-	v := Value{
-		Timestamp: time.Now().UTC(),
-		Key:       key,
-		Value:     value,
-	}
-	// apply options
-	for _, opt := range options {
-		opt(&v)
-	}
-
-	// upsert
-	if value, ok := lc.Data[key]; ok {
-		lc.LRU.Fix(&v)
-		*value = v
-	} else {
-		lc.Data[key] = &v
-		lc.LRU.Push(&v)
-	}
+	v := newValue(key, value, options...)
+	evicted := lc.setUnsafe(&v)
+	lc.Unlock()
 
+	// call the handlers outside the critical section.
+	callEvicted(evicted)
 	return
 }
 
@@ -250,6 +304,10 @@ func (lc *LocalCache) Remove(key interface{}) (value interface{}, hit bool) {
 	if ok {
 		delete(lc.Data, key)
 		lc.LRU.Remove(key)
+		lc.sizeBytes -= valueData.Size
+		if policy := lc.evictionPolicyUnsafe(); policy != nil {
+			policy.Remove(key)
+		}
 	}
 	lc.Unlock()
 	if !ok {
@@ -279,6 +337,10 @@ func (lc *LocalCache) Reset() {
 	}
 	lc.LRU.Reset()                         // reset the lru queue
 	lc.Data = make(map[interface{}]*Value) // reset the map
+	lc.sizeBytes = 0
+	if policy := lc.evictionPolicyUnsafe(); policy != nil {
+		policy.Reset()
+	}
 	lc.Unlock()
 
 	// call the remove handlers
@@ -292,18 +354,171 @@ func (lc *LocalCache) Reset() {
 // Stats include the number of items held, the age of the items,
 // and the size in bytes represented by each of the items (not including)
 // the fields of the cache itself like the LRU queue.
+//
+// If the cache has a sizer, or a maximum size in bytes, the size is the estimated
+// size of the values held. Stats also include cumulative counts of values
+// removed because they expired or because the cache was over capacity.
 func (lc *LocalCache) Stats() (stats Stats) {
 	lc.RLock()
 	defer lc.RUnlock()
 
 	stats.Count = len(lc.Data)
+	stats.Expirations = lc.expirations
+	stats.Evictions = lc.evictions
+	stats.Rejections = lc.rejections
+	stats.Refreshes = int(atomic.LoadInt64(&lc.refreshes))
+	stats.RefreshErrors = int(atomic.LoadInt64(&lc.refreshErrors))
+	stats.StaleHits = int(atomic.LoadInt64(&lc.staleHits))
+	stats.StaleIfErrorHits = int(atomic.LoadInt64(&lc.staleIfErrorHits))
+	sized := lc.sizer() != nil
+	if sized {
+		stats.SizeBytes = lc.sizeBytes
+	}
 	now := time.Now().UTC()
 	for _, item := range lc.Data {
 		age := now.Sub(item.Timestamp)
 		if stats.MaxAge < age {
 			stats.MaxAge = age
 		}
-		stats.SizeBytes += int(unsafe.Sizeof(item))
+		if !sized {
+			stats.SizeBytes += int(unsafe.Sizeof(item))
+		}
 	}
 	return
 }
+
+// lookup gets a copy of a value by key, recording the access with the eviction policy if there is one.
+func (lc *LocalCache) lookup(key interface{}) (value Value, ok bool) {
+	var valueNode *Value
+	if !lc.isBounded() {
+		lc.RLock()
+		if valueNode, ok = lc.Data[key]; ok {
+			value = *valueNode
+		}
+		lc.RUnlock()
+		return
+	}
+
+	// recording accesses mutates the eviction policy, so this needs the write lock.
+	lc.Lock()
+	if valueNode, ok = lc.Data[key]; ok {
+		value = *valueNode
+	}
+	lc.evictionPolicyUnsafe().Touch(key)
+	lc.Unlock()
+	return
+}
+
+// setUnsafe upserts a value, returning any values evicted to stay within the maximum
+// entries and bytes. A value that is not admitted is never stored, so it is not returned.
+//
+// It must be called while holding the write lock.
+func (lc *LocalCache) setUnsafe(v *Value) (evicted []*Value) {
+	if lc.Data == nil {
+		lc.Data = make(map[interface{}]*Value)
+	}
+	if sizer := lc.sizer(); sizer != nil && v.Size == 0 {
+		v.Size = sizer(v.Key, v.Value)
+	}
+
+	policy := lc.evictionPolicyUnsafe()
+	if existing, ok := lc.Data[v.Key]; ok {
+		lc.sizeBytes += v.Size - existing.Size
+		lc.LRU.Fix(v)
+		*existing = *v
+		if policy != nil {
+			policy.Touch(v.Key)
+		}
+		return lc.evictUnsafe(0, 0)
+	}
+
+	// make room for the new value before it is added, so that
+	// it is not picked as the victim itself.
+	if policy != nil {
+		policy.Touch(v.Key)
+		if lc.isOverCapacityUnsafe(1, v.Size) {
+			if victim, ok := policy.Victim(); ok && !policy.Admit(v.Key, victim) {
+				lc.rejections++
+				return nil
+			}
+			evicted = lc.evictUnsafe(1, v.Size)
+		}
+	}
+	lc.Data[v.Key] = v
+	lc.LRU.Push(v)
+	lc.sizeBytes += v.Size
+	if policy != nil {
+		policy.Add(v.Key)
+	}
+	return append(evicted, lc.evictUnsafe(0, 0)...)
+}
+
+// evictUnsafe evicts values picked by the eviction policy until the cache is within its
+// maximum entries and bytes with a given number of additional entries and bytes.
+//
+// It must be called while holding the write lock.
+func (lc *LocalCache) evictUnsafe(entries, sizeBytes int) (evicted []*Value) {
+	policy := lc.evictionPolicyUnsafe()
+	if policy == nil {
+		return
+	}
+	for lc.isOverCapacityUnsafe(entries, sizeBytes) {
+		key, ok := policy.Victim()
+		if !ok {
+			return
+		}
+		policy.Remove(key)
+		value, ok := lc.Data[key]
+		if !ok {
+			continue
+		}
+		delete(lc.Data, key)
+		lc.LRU.Remove(key)
+		lc.sizeBytes -= value.Size
+		lc.evictions++
+		evicted = append(evicted, value)
+	}
+	return
+}
+
+// isOverCapacityUnsafe returns if the cache would be over its maximum entries or bytes
+// with a given number of additional entries and bytes.
+func (lc *LocalCache) isOverCapacityUnsafe(entries, sizeBytes int) bool {
+	if lc.MaxEntries > 0 && len(lc.Data)+entries > lc.MaxEntries {
+		return true
+	}
+	return lc.MaxBytes > 0 && lc.sizeBytes+sizeBytes > lc.MaxBytes
+}
+
+// isBounded returns if the cache evicts values once it is over capacity.
+func (lc *LocalCache) isBounded() bool {
+	return lc.MaxEntries > 0 || lc.MaxBytes > 0 || lc.EvictionPolicy != nil
+}
+
+// evictionPolicyUnsafe returns the eviction policy, defaulting it to least recently used
+// if the cache is bounded.
+//
+// It must be called while holding the write lock.
+func (lc *LocalCache) evictionPolicyUnsafe() EvictionPolicy {
+	if lc.EvictionPolicy == nil && lc.isBounded() {
+		lc.EvictionPolicy = NewLRUEvictionPolicy()
+	}
+	return lc.EvictionPolicy
+}
+
+// sizer returns the sizer, defaulting it to `EstimateSize` if the cache has a maximum size in bytes.
+func (lc *LocalCache) sizer() Sizer {
+	if lc.Sizer == nil && lc.MaxBytes > 0 {
+		return EstimateSize
+	}
+	return lc.Sizer
+}
+
+// callEvicted calls the remove handlers of evicted values.
+func callEvicted(evicted []*Value) {
+	for _, value := range evicted {
+		if value.OnRemove != nil {
+			value.OnRemove(value.Key, Evicted)
+		}
+	}
+}
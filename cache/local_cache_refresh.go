@@ -0,0 +1,105 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// newValue returns a new value with the given options applied.
+func newValue(key, value interface{}, options ...ValueOption) Value {
+	v := Value{
+		Timestamp: time.Now().UTC(),
+		Key:       key,
+		Value:     value,
+	}
+	for _, opt := range options {
+		opt(&v)
+	}
+	return v
+}
+
+// getOrRefresh returns a value if it can be served, starting a background refresh if it is
+// stale or about to expire. On a miss it returns the expired value if it can be served
+// should the value provider fail.
+func (lc *LocalCache) getOrRefresh(key interface{}, valueProvider func() (interface{}, error), options ...ValueOption) (value interface{}, hit bool, stale *Value) {
+	valueNode, ok := lc.lookup(key)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case !valueNode.IsExpired(now):
+		if valueNode.ShouldRefreshAhead(now) {
+			lc.refresh(key, valueProvider, options...)
+		}
+		return valueNode.Value, true, nil
+	case valueNode.CanServeStale(now):
+		atomic.AddInt64(&lc.staleHits, 1)
+		lc.refresh(key, valueProvider, options...)
+		return valueNode.Value, true, nil
+	case valueNode.CanServeStaleIfError(now):
+		return nil, false, &valueNode
+	default:
+		return nil, false, nil
+	}
+}
+
+// refresh calls the value provider in the background and updates the value, unless
+// a refresh of the key is already running.
+//
+// If the value provider fails the current value is left in place.
+func (lc *LocalCache) refresh(key interface{}, valueProvider func() (interface{}, error), options ...ValueOption) {
+	lc.Lock()
+	if _, ok := lc.refreshing[key]; ok {
+		lc.Unlock()
+		return
+	}
+	if lc.refreshing == nil {
+		lc.refreshing = make(map[interface{}]struct{})
+	}
+	lc.refreshing[key] = struct{}{}
+	lc.Unlock()
+
+	go func() {
+		value, err := callValueProvider(valueProvider)
+
+		lc.Lock()
+		delete(lc.refreshing, key)
+		if err != nil {
+			lc.Unlock()
+			atomic.AddInt64(&lc.refreshErrors, 1)
+			return
+		}
+		// the value may have been removed while it was refreshed.
+		if _, ok := lc.Data[key]; !ok {
+			lc.Unlock()
+			return
+		}
+		v := newValue(key, value, options...)
+		evicted := lc.setUnsafe(&v)
+		lc.Unlock()
+
+		atomic.AddInt64(&lc.refreshes, 1)
+		callEvicted(evicted)
+	}()
+}
+
+// callValueProvider calls a value provider, returning any panic as an error.
+func callValueProvider(valueProvider func() (interface{}, error)) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ex.New(r)
+		}
+	}()
+	return valueProvider()
+}
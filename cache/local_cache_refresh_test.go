@@ -0,0 +1,225 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+// waitForStats polls the stats of a cache until a condition is met.
+func waitForStats(t *testing.T, c interface{ Stats() Stats }, condition func(Stats) bool) {
+	deadline := time.After(time.Second)
+	for !condition(c.Stats()) {
+		select {
+		case <-deadline:
+			assert.New(t).FailNow("timed out waiting for cache stats")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestLocalCacheGetOrSetExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New()
+	lc.Set("foo", "stale", OptValueTTL(-time.Second))
+
+	value, hit, err := lc.GetOrSet("foo", func() (interface{}, error) { return "fresh", nil })
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal("fresh", value)
+}
+
+func TestLocalCacheGetOrSetStaleWhileRevalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New()
+	lc.Set("foo", "stale", OptValueTTL(-time.Second), OptValueStaleWhileRevalidate(time.Minute))
+
+	var calls int32
+	release := make(chan struct{})
+	provider := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "fresh", nil
+	}
+
+	for x := 0; x < 5; x++ {
+		value, hit, err := lc.GetOrSet("foo", provider, OptValueTTL(time.Minute))
+		assert.Nil(err)
+		assert.True(hit)
+		assert.Equal("stale", value)
+	}
+	close(release)
+	waitForStats(t, lc, func(s Stats) bool { return s.Refreshes == 1 })
+	assert.Equal(1, atomic.LoadInt32(&calls), "only one background refresh should run at a time")
+
+	value, hit, err := lc.GetOrSet("foo", provider)
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("fresh", value)
+
+	stats := lc.Stats()
+	assert.Equal(5, stats.StaleHits)
+	assert.Zero(stats.RefreshErrors)
+}
+
+func TestLocalCacheGetOrSetStaleWhileRevalidateError(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New()
+	lc.Set("foo", "stale", OptValueTTL(-time.Second), OptValueStaleWhileRevalidate(time.Minute))
+
+	value, hit, err := lc.GetOrSet("foo", func() (interface{}, error) { return nil, fmt.Errorf("this is only a test") })
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("stale", value)
+	waitForStats(t, lc, func(s Stats) bool { return s.RefreshErrors == 1 })
+
+	// the stale value is left in place
+	found, ok := lc.Get("foo")
+	assert.True(ok)
+	assert.Equal("stale", found)
+	assert.Zero(lc.Stats().Refreshes)
+}
+
+func TestLocalCacheGetOrSetRefreshAhead(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New()
+	lc.Set("foo", "current", OptValueTTL(time.Minute), OptValueRefreshAhead(2*time.Minute))
+
+	value, hit, err := lc.GetOrSet("foo", func() (interface{}, error) { return "fresh", nil }, OptValueTTL(time.Hour))
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("current", value)
+	waitForStats(t, lc, func(s Stats) bool { return s.Refreshes == 1 })
+
+	found, ok := lc.Get("foo")
+	assert.True(ok)
+	assert.Equal("fresh", found)
+	assert.Zero(lc.Stats().StaleHits)
+}
+
+func TestLocalCacheGetOrSetRefreshRemoved(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New()
+	lc.Set("foo", "current", OptValueTTL(time.Minute), OptValueRefreshAhead(2*time.Minute))
+
+	release := make(chan struct{})
+	_, _, err := lc.GetOrSet("foo", func() (interface{}, error) {
+		<-release
+		return "fresh", nil
+	})
+	assert.Nil(err)
+	lc.Remove("foo")
+	close(release)
+
+	// the refresh completes without putting the value back
+	waitForStats(t, lc, func(s Stats) bool {
+		lc.RLock()
+		defer lc.RUnlock()
+		return len(lc.refreshing) == 0
+	})
+	assert.False(lc.Has("foo"))
+}
+
+func TestLocalCacheGetOrSetStaleIfError(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New()
+	lc.Set("foo", "stale", OptValueTTL(-time.Second), OptValueStaleIfError(time.Minute))
+	lc.Set("bar", "stale", OptValueTTL(-time.Minute), OptValueStaleIfError(time.Second))
+
+	failing := func() (interface{}, error) { return nil, fmt.Errorf("this is only a test") }
+	value, hit, err := lc.GetOrSet("foo", failing)
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("stale", value)
+
+	// outside the stale if error window the error is returned
+	_, _, err = lc.GetOrSet("bar", failing)
+	assert.NotNil(err)
+
+	// if the provider succeeds the value is replaced
+	value, hit, err = lc.GetOrSet("foo", func() (interface{}, error) { return "fresh", nil })
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal("fresh", value)
+
+	assert.Equal(1, lc.Stats().StaleIfErrorHits)
+}
+
+func TestLocalCacheSweepStale(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New(OptLRU(NewLRUHeap()))
+	lc.Set("swr", "stale", OptValueTTL(-time.Second), OptValueStaleWhileRevalidate(time.Minute))
+	lc.Set("sie", "stale", OptValueTTL(-time.Second), OptValueStaleIfError(time.Minute))
+	lc.Set("expired", "stale", OptValueTTL(-time.Second))
+	lc.Set("past-grace", "stale", OptValueTTL(-time.Minute), OptValueStaleWhileRevalidate(time.Second))
+
+	assert.Nil(lc.Sweep(context.Background()))
+	assert.True(lc.Has("swr"))
+	assert.True(lc.Has("sie"))
+	assert.False(lc.Has("expired"))
+	assert.False(lc.Has("past-grace"))
+	assert.Equal(2, lc.LRU.Len())
+
+	// the stale values are kept by later sweeps until their grace windows pass
+	assert.Nil(lc.Sweep(context.Background()))
+	assert.Equal(2, lc.LRU.Len())
+}
+
+func TestShardedCacheGetOrSetStaleWhileRevalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded(OptShards(2))
+	sc.Set("foo", "stale", OptValueTTL(-time.Second), OptValueStaleWhileRevalidate(time.Minute))
+
+	value, hit, err := sc.GetOrSet("foo", func() (interface{}, error) { return "fresh", nil })
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("stale", value)
+	waitForStats(t, sc, func(s Stats) bool { return s.Refreshes == 1 })
+	assert.Equal(1, sc.Stats().StaleHits)
+
+	value, _ = sc.Get("foo")
+	assert.Equal("fresh", value)
+}
+
+func TestValueFreshness(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 01, 01, 12, 0, 0, 0, time.UTC)
+	v := Value{
+		Expires:              now,
+		RefreshAhead:         time.Minute,
+		StaleWhileRevalidate: time.Minute,
+		StaleIfError:         time.Hour,
+	}
+	assert.False(v.IsExpired(now))
+	assert.False(v.ShouldRefreshAhead(now.Add(-2 * time.Minute)))
+	assert.True(v.ShouldRefreshAhead(now.Add(-time.Second)))
+	assert.True(v.IsExpired(now.Add(time.Second)))
+	assert.False(v.ShouldRefreshAhead(now.Add(time.Second)))
+	assert.True(v.CanServeStale(now.Add(time.Second)))
+	assert.False(v.CanServeStale(now.Add(2 * time.Minute)))
+	assert.True(v.CanServeStaleIfError(now.Add(2 * time.Minute)))
+	assert.Equal(now.Add(time.Hour), v.RemoveAfter())
+
+	assert.False(Value{}.IsExpired(now))
+	assert.True(Value{}.RemoveAfter().IsZero())
+}
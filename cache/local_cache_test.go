@@ -342,6 +342,155 @@ func TestLocalCacheResetHeap(t *testing.T) {
 	assert.Equal(2, len(lc.Data))
 }
 
+func TestLocalCacheMaxEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	var evicted []interface{}
+	onRemove := OptValueOnRemove(func(key interface{}, reason RemovalReason) {
+		if reason == Evicted {
+			evicted = append(evicted, key)
+		}
+	})
+
+	lc := New(OptMaxEntries(2))
+	lc.Set("a", "a-value", onRemove)
+	lc.Set("b", "b-value", onRemove)
+	_, ok := lc.Get("a")
+	assert.True(ok)
+
+	// "b" is the least recently used
+	lc.Set("c", "c-value", onRemove)
+	assert.Equal([]interface{}{"b"}, evicted)
+	assert.True(lc.Has("a"))
+	assert.False(lc.Has("b"))
+	assert.True(lc.Has("c"))
+	assert.Equal(2, lc.LRU.Len())
+
+	// updating an existing key doesn't evict anything
+	lc.Set("a", "a-value-2", onRemove)
+	assert.Len(evicted, 1)
+
+	stats := lc.Stats()
+	assert.Equal(2, stats.Count)
+	assert.Equal(1, stats.Evictions)
+	assert.Zero(stats.Expirations)
+}
+
+func TestLocalCacheMaxBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	sizer := func(_, value interface{}) int { return len(value.(string)) }
+	lc := New(OptMaxBytes(10), OptSizer(sizer))
+	lc.Set("a", "aaaa")
+	lc.Set("b", "bbbb")
+	assert.Equal(8, lc.Stats().SizeBytes)
+
+	lc.Set("c", "cccc")
+	assert.False(lc.Has("a"))
+	assert.Equal(8, lc.Stats().SizeBytes)
+
+	// replacing a value updates the size
+	lc.Set("c", "cc")
+	assert.Equal(6, lc.Stats().SizeBytes)
+
+	// explicit sizes override the sizer
+	lc.Set("d", "d", OptValueSize(6))
+	assert.False(lc.Has("b"))
+	assert.True(lc.Has("c"))
+	assert.Equal(8, lc.Stats().SizeBytes)
+
+	lc.Remove("d")
+	assert.Equal(2, lc.Stats().SizeBytes)
+	lc.Reset()
+	assert.Zero(lc.Stats().SizeBytes)
+	assert.Equal(2, lc.Stats().Evictions)
+}
+
+func TestLocalCacheMaxEntriesGetOrSet(t *testing.T) {
+	assert := assert.New(t)
+
+	var reasons []RemovalReason
+	lc := New(OptMaxEntries(1))
+	_, _, err := lc.GetOrSet("a", func() (interface{}, error) { return "a-value", nil }, OptValueOnRemove(func(_ interface{}, reason RemovalReason) {
+		reasons = append(reasons, reason)
+	}))
+	assert.Nil(err)
+	_, hit, err := lc.GetOrSet("b", func() (interface{}, error) { return "b-value", nil })
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal([]RemovalReason{Evicted}, reasons)
+	assert.False(lc.Has("a"))
+	assert.True(lc.Has("b"))
+}
+
+func TestLocalCacheLFU(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New(OptMaxEntries(2), OptEvictionPolicy(NewLFUEvictionPolicy()))
+	lc.Set("a", "a-value")
+	lc.Set("b", "b-value")
+	for x := 0; x < 3; x++ {
+		_, _ = lc.Get("a")
+	}
+	_, _ = lc.Get("b")
+
+	lc.Set("c", "c-value")
+	assert.True(lc.Has("a"))
+	assert.False(lc.Has("b"))
+	assert.True(lc.Has("c"))
+}
+
+func TestLocalCacheTinyLFU(t *testing.T) {
+	assert := assert.New(t)
+
+	var removed bool
+	lc := New(OptMaxEntries(2), OptEvictionPolicy(NewTinyLFUEvictionPolicy(2)))
+	lc.Set("a", "a-value")
+	lc.Set("b", "b-value")
+	for x := 0; x < 3; x++ {
+		_, _ = lc.Get("a")
+		_, _ = lc.Get("b")
+	}
+
+	// a one-off key is not admitted, and as it was never stored its remove handler is not called
+	lc.Set("scan", "scan-value", OptValueOnRemove(func(_ interface{}, _ RemovalReason) {
+		removed = true
+	}))
+	assert.False(removed)
+	assert.False(lc.Has("scan"))
+	assert.True(lc.Has("a"))
+	assert.True(lc.Has("b"))
+	assert.Equal(1, lc.Stats().Rejections)
+
+	// a frequently requested key is admitted, evicting the least recently used
+	for x := 0; x < 5; x++ {
+		_, _ = lc.Get("hot")
+	}
+	lc.Set("hot", "hot-value")
+	assert.True(lc.Has("hot"))
+	assert.False(lc.Has("a"))
+	assert.True(lc.Has("b"))
+}
+
+func TestLocalCacheSweepBounded(t *testing.T) {
+	assert := assert.New(t)
+
+	lc := New(OptMaxEntries(2))
+	lc.Set("a", "a-value", OptValueTTL(-time.Second))
+	lc.Set("b", "b-value")
+	assert.Nil(lc.Sweep(context.Background()))
+	assert.False(lc.Has("a"))
+
+	// the expired value no longer counts towards the max entries
+	lc.Set("c", "c-value")
+	assert.True(lc.Has("b"))
+	assert.True(lc.Has("c"))
+
+	stats := lc.Stats()
+	assert.Equal(1, stats.Expirations)
+	assert.Zero(stats.Evictions)
+}
+
 func BenchmarkLocalCache(b *testing.B) {
 	for x := 0; x < b.N; x++ {
 		benchLocalCache(1024)
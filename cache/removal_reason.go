@@ -17,6 +17,8 @@ func (rr RemovalReason) String() string {
 		return "expired"
 	case Removed:
 		return "removed"
+	case Evicted:
+		return "evicted"
 	default:
 		return "unknown"
 	}
@@ -26,4 +28,5 @@ func (rr RemovalReason) String() string {
 const (
 	Expired RemovalReason = iota
 	Removed RemovalReason = iota
+	Evicted RemovalReason = iota
 )
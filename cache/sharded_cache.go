@@ -0,0 +1,258 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"hash/maphash"
+	"reflect"
+	"time"
+
+	"github.com/blend/go-sdk/async"
+)
+
+// DefaultShards is the default number of shards in a sharded cache.
+const DefaultShards = 16
+
+var (
+	_ Cache = (*ShardedCache)(nil)
+)
+
+// NewSharded returns a new sharded cache.
+// It defaults to `DefaultShards` shards and 500ms sweep intervals.
+func NewSharded(options ...ShardedCacheOption) *ShardedCache {
+	sc := ShardedCache{
+		seed:       maphash.MakeSeed(),
+		shardCount: DefaultShards,
+	}
+	sc.Sweeper = async.NewInterval(sc.Sweep, 500*time.Millisecond)
+	for _, opt := range options {
+		opt(&sc)
+	}
+	if sc.shardCount < 1 {
+		sc.shardCount = 1
+	}
+
+	shardOptions := sc.shardOptions
+	if sc.maxEntries > 0 {
+		shardOptions = append(shardOptions, OptMaxEntries(divideCapacity(sc.maxEntries, sc.shardCount)))
+	}
+	if sc.maxBytes > 0 {
+		shardOptions = append(shardOptions, OptMaxBytes(divideCapacity(sc.maxBytes, sc.shardCount)))
+	}
+	sc.Shards = make([]*LocalCache, sc.shardCount)
+	sc.flights = make([]singleFlight, sc.shardCount)
+	for index := range sc.Shards {
+		sc.Shards[index] = New(shardOptions...)
+	}
+	return &sc
+}
+
+// ShardedCacheOption is a sharded cache option.
+type ShardedCacheOption func(*ShardedCache)
+
+// OptShards sets the number of shards.
+func OptShards(shards int) ShardedCacheOption {
+	return func(sc *ShardedCache) {
+		sc.shardCount = shards
+	}
+}
+
+// OptShardOptions sets options applied to each of the shards, e.g. `OptLRU` or `OptEvictionPolicy`.
+//
+// Options that hold state, like an eviction policy instance, should not be shared between
+// shards; use `OptShardedEvictionPolicy` to give each shard its own eviction policy.
+func OptShardOptions(options ...LocalCacheOption) ShardedCacheOption {
+	return func(sc *ShardedCache) {
+		sc.shardOptions = append(sc.shardOptions, options...)
+	}
+}
+
+// OptShardedSweepInterval sets the sharded cache sweep interval.
+func OptShardedSweepInterval(d time.Duration) ShardedCacheOption {
+	return func(sc *ShardedCache) {
+		sc.Sweeper = async.NewInterval(sc.Sweep, d)
+	}
+}
+
+// OptShardedMaxEntries sets the maximum number of values the cache holds, divided evenly between the shards.
+func OptShardedMaxEntries(maxEntries int) ShardedCacheOption {
+	return func(sc *ShardedCache) {
+		sc.maxEntries = maxEntries
+	}
+}
+
+// OptShardedMaxBytes sets the maximum estimated size in bytes of the values the cache holds,
+// divided evenly between the shards.
+func OptShardedMaxBytes(maxBytes int) ShardedCacheOption {
+	return func(sc *ShardedCache) {
+		sc.maxBytes = maxBytes
+	}
+}
+
+// OptShardedEvictionPolicy sets a provider for the eviction policy of each shard.
+func OptShardedEvictionPolicy(provider func() EvictionPolicy) ShardedCacheOption {
+	return func(sc *ShardedCache) {
+		sc.shardOptions = append(sc.shardOptions, func(lc *LocalCache) {
+			lc.EvictionPolicy = provider()
+		})
+	}
+}
+
+// ShardedCache is a memory cache that hashes keys across a number of independent
+// local caches, each with its own lock and LRU, to reduce lock contention.
+//
+// The shards are swept by a single sweeper, one shard at a time, rather than each
+// shard running its own sweeper.
+type ShardedCache struct {
+	Shards  []*LocalCache
+	Sweeper *async.Interval
+
+	seed         maphash.Seed
+	flights      []singleFlight
+	shardCount   int
+	shardOptions []LocalCacheOption
+	maxEntries   int
+	maxBytes     int
+}
+
+// Start starts the sweeper.
+func (sc *ShardedCache) Start() error {
+	return sc.Sweeper.Start()
+}
+
+// NotifyStarted returns the underlying started signal.
+func (sc *ShardedCache) NotifyStarted() <-chan struct{} {
+	return sc.Sweeper.NotifyStarted()
+}
+
+// Stop stops the sweeper.
+func (sc *ShardedCache) Stop() error {
+	return sc.Sweeper.Stop()
+}
+
+// NotifyStopped returns the underlying stopped signal.
+func (sc *ShardedCache) NotifyStopped() <-chan struct{} {
+	return sc.Sweeper.NotifyStopped()
+}
+
+// Sweep checks the keys of each shard for expired ttls, one shard at a time.
+func (sc *ShardedCache) Sweep(ctx context.Context) error {
+	for _, shard := range sc.Shards {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := shard.Sweep(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shard returns the shard that holds a given key.
+func (sc *ShardedCache) Shard(key interface{}) *LocalCache {
+	return sc.Shards[sc.shardIndex(key)]
+}
+
+// Has returns if the key is present in the cache.
+func (sc *ShardedCache) Has(key interface{}) bool {
+	return sc.Shard(key).Has(key)
+}
+
+// Set adds a cache item.
+func (sc *ShardedCache) Set(key, value interface{}, options ...ValueOption) {
+	if key == nil {
+		panic("sharded cache: nil key")
+	}
+
+	if !reflect.TypeOf(key).Comparable() {
+		panic("sharded cache: key is not comparable")
+	}
+	sc.Shard(key).Set(key, value, options...)
+}
+
+// Get gets a value based on a key.
+func (sc *ShardedCache) Get(key interface{}) (interface{}, bool) {
+	return sc.Shard(key).Get(key)
+}
+
+// GetOrSet gets a value by a key, and in the case of a miss, sets the value from a given value provider lazily.
+// Hit indicates that the provider was not called by this caller.
+//
+// Concurrent misses for the same key call the value provider once; the other
+// callers wait for and share its result. Values are refreshed and served stale
+// as they are by `LocalCache.GetOrSet`.
+func (sc *ShardedCache) GetOrSet(key interface{}, valueProvider func() (interface{}, error), options ...ValueOption) (value interface{}, hit bool, err error) {
+	if key == nil {
+		panic("sharded cache: nil key")
+	}
+
+	if !reflect.TypeOf(key).Comparable() {
+		panic("sharded cache: key is not comparable")
+	}
+
+	index := sc.shardIndex(key)
+	shard := sc.Shards[index]
+	var stale *Value
+	if value, hit, stale = shard.getOrRefresh(key, valueProvider, options...); hit {
+		return
+	}
+
+	var shared bool
+	value, hit, err, shared = sc.flights[index].Do(key, func() (interface{}, bool, error) {
+		return shard.getOrSetSlow(key, valueProvider, stale, options...)
+	})
+	if shared && err == nil {
+		hit = true
+	}
+	return
+}
+
+// Remove removes a specific key.
+func (sc *ShardedCache) Remove(key interface{}) (interface{}, bool) {
+	return sc.Shard(key).Remove(key)
+}
+
+// Reset removes all items from each of the shards, leaving an empty cache.
+func (sc *ShardedCache) Reset() {
+	for _, shard := range sc.Shards {
+		shard.Reset()
+	}
+}
+
+// Stats returns the aggregate stats of the shards.
+func (sc *ShardedCache) Stats() (stats Stats) {
+	for _, shard := range sc.Shards {
+		shardStats := shard.Stats()
+		stats.Count += shardStats.Count
+		stats.SizeBytes += shardStats.SizeBytes
+		if stats.MaxAge < shardStats.MaxAge {
+			stats.MaxAge = shardStats.MaxAge
+		}
+		stats.Expirations += shardStats.Expirations
+		stats.Evictions += shardStats.Evictions
+		stats.Rejections += shardStats.Rejections
+		stats.Refreshes += shardStats.Refreshes
+		stats.RefreshErrors += shardStats.RefreshErrors
+		stats.StaleHits += shardStats.StaleHits
+		stats.StaleIfErrorHits += shardStats.StaleIfErrorHits
+	}
+	return
+}
+
+func (sc *ShardedCache) shardIndex(key interface{}) int {
+	if len(sc.Shards) == 1 {
+		return 0
+	}
+	return int(hashKey(sc.seed, key) % uint64(len(sc.Shards)))
+}
+
+// divideCapacity divides a capacity between shards, rounding up.
+func divideCapacity(capacity, shards int) int {
+	return (capacity + shards - 1) / shards
+}
@@ -0,0 +1,251 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/graceful"
+)
+
+var (
+	_ graceful.Graceful = (*ShardedCache)(nil)
+)
+
+func TestShardedCache(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded(OptShards(4))
+	assert.Len(sc.Shards, 4)
+
+	for x := 0; x < 100; x++ {
+		sc.Set(x, strconv.Itoa(x))
+	}
+	sc.Set(itemKey{}, "foo")
+	for x := 0; x < 100; x++ {
+		value, ok := sc.Get(x)
+		assert.True(ok)
+		assert.Equal(strconv.Itoa(x), value)
+		assert.True(sc.Shard(x).Has(x))
+	}
+	assert.True(sc.Has(itemKey{}))
+	assert.False(sc.Has(altItemKey{}))
+
+	// keys are spread across the shards
+	for _, shard := range sc.Shards {
+		assert.NotZero(len(shard.Data))
+	}
+
+	value, ok := sc.Remove(itemKey{})
+	assert.True(ok)
+	assert.Equal("foo", value)
+	assert.False(sc.Has(itemKey{}))
+	assert.Equal(100, sc.Stats().Count)
+
+	sc.Reset()
+	assert.Zero(sc.Stats().Count)
+}
+
+func TestShardedCacheKeyPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded()
+
+	assert.NotNil(try(func() {
+		sc.Set(nil, "bar")
+	}))
+	assert.NotNil(try(func() {
+		sc.Set([]int{}, "bar")
+	}))
+	assert.NotNil(try(func() {
+		_, _, _ = sc.GetOrSet(nil, func() (interface{}, error) { return "bar", nil })
+	}))
+	assert.NotNil(try(func() {
+		_, _, _ = sc.GetOrSet([]int{}, func() (interface{}, error) { return "bar", nil })
+	}))
+}
+
+func TestShardedCacheGetOrSet(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded()
+	value, hit, err := sc.GetOrSet("foo", func() (interface{}, error) { return "bar", nil })
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal("bar", value)
+
+	value, hit, err = sc.GetOrSet("foo", func() (interface{}, error) { return "baz", nil })
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("bar", value)
+
+	_, _, err = sc.GetOrSet("error", func() (interface{}, error) { return nil, fmt.Errorf("this is only a test") })
+	assert.NotNil(err)
+	assert.False(sc.Has("error"))
+}
+
+func TestShardedCacheGetOrSetSingleFlight(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded()
+	var calls int32
+	release := make(chan struct{})
+	provider := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "bar", nil
+	}
+
+	const callers = 8
+	var started, wg sync.WaitGroup
+	started.Add(callers)
+	wg.Add(callers)
+	var hits int32
+	for x := 0; x < callers; x++ {
+		go func() {
+			defer wg.Done()
+			started.Done()
+			value, hit, err := sc.GetOrSet("foo", provider)
+			if err == nil && value == "bar" && hit {
+				atomic.AddInt32(&hits, 1)
+			}
+		}()
+	}
+	started.Wait()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(1, atomic.LoadInt32(&calls))
+	assert.Equal(callers-1, atomic.LoadInt32(&hits))
+}
+
+func TestShardedCacheMaxEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded(OptShards(4), OptShardedMaxEntries(10), OptShardedEvictionPolicy(func() EvictionPolicy {
+		return NewLFUEvictionPolicy()
+	}))
+	for _, shard := range sc.Shards {
+		assert.Equal(3, shard.MaxEntries)
+		_, isLFU := shard.EvictionPolicy.(*LFUEvictionPolicy)
+		assert.True(isLFU)
+	}
+	assert.True(sc.Shards[0].EvictionPolicy != sc.Shards[1].EvictionPolicy)
+
+	for x := 0; x < 100; x++ {
+		sc.Set(x, x)
+	}
+	stats := sc.Stats()
+	assert.True(stats.Count <= 12)
+	assert.Equal(100, stats.Count+stats.Evictions)
+}
+
+func TestShardedCacheSweep(t *testing.T) {
+	assert := assert.New(t)
+
+	var removed int32
+	sc := NewSharded(OptShards(4), OptShardedSweepInterval(time.Millisecond))
+	for x := 0; x < 20; x++ {
+		sc.Set(x, x, OptValueTTL(-time.Second), OptValueOnRemove(func(_ interface{}, reason RemovalReason) {
+			if reason == Expired {
+				atomic.AddInt32(&removed, 1)
+			}
+		}))
+	}
+	sc.Set("keep", "keep")
+
+	go func() { _ = sc.Start() }()
+	<-sc.NotifyStarted()
+	defer func() { _ = sc.Stop() }()
+
+	deadline := time.After(time.Second)
+	for atomic.LoadInt32(&removed) < 20 {
+		select {
+		case <-deadline:
+			assert.FailNow("sweeper should have removed the expired values")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	stats := sc.Stats()
+	assert.Equal(1, stats.Count)
+	assert.Equal(20, stats.Expirations)
+}
+
+func TestShardedCacheSweepCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	sc := NewSharded()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(context.Canceled, sc.Sweep(ctx))
+}
+
+func BenchmarkShardedCache(b *testing.B) {
+	for x := 0; x < b.N; x++ {
+		benchShardedCache(1024)
+	}
+}
+
+func benchShardedCache(items int) {
+	sc := NewSharded()
+	for x := 0; x < items; x++ {
+		sc.Set(x, strconv.Itoa(x), OptValueTTL(time.Millisecond))
+	}
+	for x := 0; x < items; x++ {
+		sc.Set(x, strconv.Itoa(x), OptValueTTL(time.Second))
+	}
+	var value interface{}
+	var ok bool
+	for x := 0; x < items; x++ {
+		value, ok = sc.Get(x)
+		if !ok {
+			panic("value not found")
+		}
+		if value.(string) != strconv.Itoa(x) {
+			panic("wrong value")
+		}
+	}
+	_ = sc.Sweep(context.Background())
+}
+
+func BenchmarkLocalCacheParallel(b *testing.B) {
+	benchCacheParallel(b, New(), 1024)
+}
+
+func BenchmarkShardedCacheParallel(b *testing.B) {
+	benchCacheParallel(b, NewSharded(), 1024)
+}
+
+// benchCacheParallel benchmarks a read heavy workload, with one write for every
+// sixteen reads, from many goroutines.
+func benchCacheParallel(b *testing.B, c Cache, items int) {
+	for x := 0; x < items; x++ {
+		c.Set(x, strconv.Itoa(x))
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var x int
+		for pb.Next() {
+			key := x % items
+			if x%16 == 0 {
+				c.Set(key, strconv.Itoa(key))
+			} else if _, _, err := c.GetOrSet(key, func() (interface{}, error) { return strconv.Itoa(key), nil }); err != nil {
+				panic(err)
+			}
+			x++
+		}
+	})
+}
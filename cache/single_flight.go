@@ -0,0 +1,50 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import "sync"
+
+// singleFlight deduplicates concurrent calls for the same key.
+type singleFlight struct {
+	mu    sync.Mutex
+	calls map[interface{}]*singleFlightCall
+}
+
+type singleFlightCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	hit   bool
+	err   error
+}
+
+// Do calls a function for a key, unless a call for the key is already in flight, in
+// which case it waits for that call and returns its results with `shared` set.
+func (sf *singleFlight) Do(key interface{}, fn func() (interface{}, bool, error)) (value interface{}, hit bool, err error, shared bool) {
+	sf.mu.Lock()
+	if sf.calls == nil {
+		sf.calls = make(map[interface{}]*singleFlightCall)
+	}
+	if call, ok := sf.calls[key]; ok {
+		sf.mu.Unlock()
+		call.wg.Wait()
+		return call.value, call.hit, call.err, true
+	}
+	call := new(singleFlightCall)
+	call.wg.Add(1)
+	sf.calls[key] = call
+	sf.mu.Unlock()
+
+	defer func() {
+		sf.mu.Lock()
+		delete(sf.calls, key)
+		sf.mu.Unlock()
+		call.wg.Done()
+	}()
+	call.value, call.hit, call.err = fn()
+	return call.value, call.hit, call.err, false
+}
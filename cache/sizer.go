@@ -0,0 +1,93 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"reflect"
+)
+
+var (
+	_ Sizer = EstimateSize
+)
+
+// Sizer returns the estimated size in bytes of a cached key and value.
+type Sizer func(key, value interface{}) int
+
+// EstimateSize estimates the size in bytes of a key and value, including the memory they reference
+// through pointers, strings, slices, maps and interfaces.
+//
+// Memory referenced more than once through the same pointer is only counted once, and map
+// overhead is not counted, so it is an estimate rather than an exact accounting.
+func EstimateSize(key, value interface{}) int {
+	seen := make(map[uintptr]struct{})
+	return estimateSize(reflect.ValueOf(key), seen) + estimateSize(reflect.ValueOf(value), seen)
+}
+
+// estimateSize returns the size of a value, including the memory it references.
+func estimateSize(v reflect.Value, seen map[uintptr]struct{}) int {
+	if !v.IsValid() {
+		return 0
+	}
+	return int(v.Type().Size()) + estimateReferencedSize(v, seen)
+}
+
+// estimateReferencedSize returns the size of the memory a value references, excluding the value itself.
+func estimateReferencedSize(v reflect.Value, seen map[uintptr]struct{}) (size int) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len()
+	case reflect.Ptr:
+		if v.IsNil() || isSeen(v.Pointer(), seen) {
+			return 0
+		}
+		return estimateSize(v.Elem(), seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		return estimateSize(v.Elem(), seen)
+	case reflect.Slice:
+		if v.IsNil() || isSeen(v.Pointer(), seen) {
+			return 0
+		}
+		size = v.Cap() * int(v.Type().Elem().Size())
+		for index := 0; index < v.Len(); index++ {
+			size += estimateReferencedSize(v.Index(index), seen)
+		}
+		return size
+	case reflect.Array:
+		for index := 0; index < v.Len(); index++ {
+			size += estimateReferencedSize(v.Index(index), seen)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() || isSeen(v.Pointer(), seen) {
+			return 0
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key(), seen) + estimateSize(iter.Value(), seen)
+		}
+		return size
+	case reflect.Struct:
+		for index := 0; index < v.NumField(); index++ {
+			size += estimateReferencedSize(v.Field(index), seen)
+		}
+		return size
+	default:
+		return 0
+	}
+}
+
+func isSeen(pointer uintptr, seen map[uintptr]struct{}) bool {
+	if _, ok := seen[pointer]; ok {
+		return true
+	}
+	seen[pointer] = struct{}{}
+	return false
+}
@@ -0,0 +1,38 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+type sizerTestValue struct {
+	Name string
+	Data []byte
+	Next *sizerTestValue
+}
+
+func TestEstimateSize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(EstimateSize(nil, nil))
+	assert.Equal(16+3+8, EstimateSize("key", int64(1)))
+	assert.Equal(16+3+24+10, EstimateSize("key", make([]byte, 5, 10)))
+
+	value := &sizerTestValue{Name: "test", Data: make([]byte, 100)}
+	value.Next = value
+	size := EstimateSize(nil, value)
+	assert.True(size >= 100+4, size)
+
+	// cycles are only counted once
+	assert.Equal(size, EstimateSize(nil, &sizerTestValue{Name: "test", Data: make([]byte, 100)}))
+
+	assert.True(EstimateSize(nil, map[string]string{"foo": "bar"}) > 6)
+}
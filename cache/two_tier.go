@@ -0,0 +1,312 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/uuid"
+)
+
+var (
+	_ Cache = (*TwoTier)(nil)
+)
+
+// NewTwoTier returns a new two tier cache with a given remote backend.
+// It defaults to a new local cache, a gob codec and no invalidation bus.
+func NewTwoTier(l2 Backend, options ...TwoTierOption) *TwoTier {
+	tt := TwoTier{
+		L1:     New(),
+		L2:     l2,
+		Codec:  GobCodec{},
+		Source: uuid.V4().String(),
+	}
+	for _, opt := range options {
+		opt(&tt)
+	}
+	return &tt
+}
+
+// TwoTierOption is a two tier cache option.
+type TwoTierOption func(*TwoTier)
+
+// OptTwoTierL1 sets the local cache.
+func OptTwoTierL1(l1 *LocalCache) TwoTierOption {
+	return func(tt *TwoTier) { tt.L1 = l1 }
+}
+
+// OptTwoTierCodec sets the codec used to serialize values for the remote backend.
+func OptTwoTierCodec(codec Codec) TwoTierOption {
+	return func(tt *TwoTier) { tt.Codec = codec }
+}
+
+// OptTwoTierInvalidationBus sets the bus used to broadcast invalidations to peers.
+func OptTwoTierInvalidationBus(bus InvalidationBus) TwoTierOption {
+	return func(tt *TwoTier) { tt.Bus = bus }
+}
+
+// OptTwoTierL1TTL sets the maximum time values are held by the local cache.
+func OptTwoTierL1TTL(d time.Duration) TwoTierOption {
+	return func(tt *TwoTier) { tt.L1TTL = d }
+}
+
+// OptTwoTierKeyFormatter sets the function used to format keys as strings.
+func OptTwoTierKeyFormatter(formatter func(interface{}) string) TwoTierOption {
+	return func(tt *TwoTier) { tt.KeyFormatter = formatter }
+}
+
+// OptTwoTierSource sets the source the cache publishes invalidations as.
+func OptTwoTierSource(source string) TwoTierOption {
+	return func(tt *TwoTier) { tt.Source = source }
+}
+
+// OptTwoTierLog sets the logger errors from the backend and bus are written to.
+func OptTwoTierLog(log logger.Log) TwoTierOption {
+	return func(tt *TwoTier) { tt.Log = log }
+}
+
+// TwoTier is a cache that combines a local cache (L1) with a remote backend (L2) shared between processes.
+//
+// Reads are served from the local cache, falling back to the backend. Writes go to both tiers, and
+// publish an invalidation on the bus (if one is set) so that peers remove the key from their local caches.
+//
+// Keys are formatted as strings for both tiers; by default strings are used as is and other keys
+// are formatted with `fmt.Sprint`. Remove handlers set with `OptValueOnRemove` are still passed the original key.
+//
+// Values are held by both tiers as they are returned by the codec, so reads return the same types
+// whichever tier serves them. The default `GobCodec` preserves types, but concrete types other than
+// the builtin ones must be registered with `gob.Register`; a `JSONCodec` should set `New`.
+type TwoTier struct {
+	// L1 is the local cache.
+	L1 *LocalCache
+	// L2 is the remote backend.
+	L2 Backend
+	// Codec serializes values for the remote backend.
+	Codec Codec
+	// Bus broadcasts invalidations to peers, if set.
+	Bus InvalidationBus
+	// L1TTL is the maximum time values are held by the local cache, if set.
+	L1TTL time.Duration
+	// KeyFormatter formats keys as strings.
+	KeyFormatter func(interface{}) string
+	// Source identifies the cache in the invalidations it publishes, so it can ignore its own.
+	Source string
+	// Log receives errors from the backend and bus.
+	Log logger.Log
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	flight singleFlight
+}
+
+// Start starts listening for invalidations and starts the local cache sweeper.
+//
+// This call will block.
+func (tt *TwoTier) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	tt.mu.Lock()
+	tt.cancel = cancel
+	tt.mu.Unlock()
+
+	if tt.Bus != nil {
+		go func() {
+			if err := tt.Bus.Listen(ctx, tt.handleInvalidation); err != nil && ctx.Err() == nil {
+				logger.MaybeErrorContext(ctx, tt.Log, err)
+			}
+		}()
+	}
+	return tt.L1.Start()
+}
+
+// NotifyStarted returns the local cache started signal.
+func (tt *TwoTier) NotifyStarted() <-chan struct{} {
+	return tt.L1.NotifyStarted()
+}
+
+// Stop stops listening for invalidations and stops the local cache sweeper.
+func (tt *TwoTier) Stop() error {
+	tt.mu.Lock()
+	if tt.cancel != nil {
+		tt.cancel()
+		tt.cancel = nil
+	}
+	tt.mu.Unlock()
+	return tt.L1.Stop()
+}
+
+// NotifyStopped returns the local cache stopped signal.
+func (tt *TwoTier) NotifyStopped() <-chan struct{} {
+	return tt.L1.NotifyStopped()
+}
+
+// Has returns if the key is present in either tier.
+func (tt *TwoTier) Has(key interface{}) bool {
+	_, ok := tt.Get(key)
+	return ok
+}
+
+// Set sets a value in both tiers and publishes an invalidation for the key.
+//
+// The local cache is set to the value as decoded by the codec, or to the value as is (and the backend is
+// left unset) if it cannot be round tripped.
+func (tt *TwoTier) Set(key, value interface{}, options ...ValueOption) {
+	tt.set(context.Background(), key, value, options...)
+}
+
+// set sets a value in both tiers and returns the value held by the local cache.
+func (tt *TwoTier) set(ctx context.Context, key, value interface{}, options ...ValueOption) interface{} {
+	formatted := tt.formatKey(key)
+	v := newValue(formatted, value, options...)
+	data, err := tt.Codec.Encode(value)
+	if err != nil {
+		logger.MaybeErrorContext(ctx, tt.Log, err)
+	} else if decoded, err := tt.Codec.Decode(data); err != nil {
+		logger.MaybeErrorContext(ctx, tt.Log, err)
+		data = nil
+	} else {
+		value = decoded
+	}
+	tt.setL1(formatted, value, append(append([]ValueOption{}, options...), optValueOriginalKey(key))...)
+
+	if data != nil {
+		if err := tt.L2.Set(ctx, formatted, BackendEntry{Data: data, Expires: v.Expires}); err != nil {
+			logger.MaybeErrorContext(ctx, tt.Log, err)
+		}
+	}
+	tt.publish(ctx, formatted)
+	return value
+}
+
+// Get gets a value from the local cache, falling back to the remote backend.
+func (tt *TwoTier) Get(key interface{}) (interface{}, bool) {
+	return tt.get(context.Background(), tt.formatKey(key))
+}
+
+// GetOrSet gets a value by a key, and in the case of a miss in both tiers, sets the value from a given value provider lazily.
+// Hit indicates that the provider was not called.
+//
+// Concurrent misses for the same key in the same process call the value provider once.
+func (tt *TwoTier) GetOrSet(key interface{}, valueProvider func() (interface{}, error), options ...ValueOption) (value interface{}, hit bool, err error) {
+	ctx := context.Background()
+	formatted := tt.formatKey(key)
+	if value, hit = tt.get(ctx, formatted); hit {
+		return
+	}
+
+	var shared bool
+	value, hit, err, shared = tt.flight.Do(formatted, func() (interface{}, bool, error) {
+		if value, ok := tt.get(ctx, formatted); ok {
+			return value, true, nil
+		}
+		value, err := valueProvider()
+		if err != nil {
+			return nil, false, err
+		}
+		return tt.set(ctx, key, value, options...), false, nil
+	})
+	if shared && err == nil {
+		hit = true
+	}
+	return
+}
+
+// Remove removes a key from both tiers and publishes an invalidation for the key.
+func (tt *TwoTier) Remove(key interface{}) (value interface{}, hit bool) {
+	ctx := context.Background()
+	formatted := tt.formatKey(key)
+	if value, hit = tt.L1.Remove(formatted); !hit {
+		value, hit = tt.getL2(ctx, formatted)
+	}
+	if err := tt.L2.Remove(ctx, formatted); err != nil {
+		logger.MaybeErrorContext(ctx, tt.Log, err)
+	}
+	tt.publish(ctx, formatted)
+	return
+}
+
+// get gets a value from the local cache, falling back to the remote backend
+// and populating the local cache.
+func (tt *TwoTier) get(ctx context.Context, key string) (interface{}, bool) {
+	if v, ok := tt.L1.lookup(key); ok && !v.IsExpired(time.Now().UTC()) {
+		return v.Value, true
+	}
+	return tt.getL2(ctx, key)
+}
+
+// getL2 gets a value from the remote backend, populating the local cache.
+func (tt *TwoTier) getL2(ctx context.Context, key string) (interface{}, bool) {
+	entry, found, err := tt.L2.Get(ctx, key)
+	if err != nil {
+		logger.MaybeErrorContext(ctx, tt.Log, err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	value, err := tt.Codec.Decode(entry.Data)
+	if err != nil {
+		logger.MaybeErrorContext(ctx, tt.Log, err)
+		return nil, false
+	}
+	tt.setL1(key, value, OptValueExpires(entry.Expires))
+	return value, true
+}
+
+// setL1 sets a value in the local cache, capping its expiry at the L1 ttl.
+func (tt *TwoTier) setL1(key string, value interface{}, options ...ValueOption) {
+	if tt.L1TTL > 0 {
+		options = append(options, func(v *Value) {
+			if maxExpires := v.Timestamp.Add(tt.L1TTL); v.Expires.IsZero() || v.Expires.After(maxExpires) {
+				v.Expires = maxExpires
+			}
+		})
+	}
+	tt.L1.Set(key, value, options...)
+}
+
+func (tt *TwoTier) publish(ctx context.Context, key string) {
+	if tt.Bus == nil {
+		return
+	}
+	if err := tt.Bus.Publish(ctx, Invalidation{Source: tt.Source, Key: key}); err != nil {
+		logger.MaybeErrorContext(ctx, tt.Log, err)
+	}
+}
+
+// handleInvalidation removes keys changed by peers from the local cache.
+func (tt *TwoTier) handleInvalidation(_ context.Context, invalidation Invalidation) {
+	if invalidation.Source == tt.Source {
+		return
+	}
+	tt.L1.Remove(invalidation.Key)
+}
+
+// optValueOriginalKey passes the original key, rather than the formatted key, to the remove handler of a value.
+func optValueOriginalKey(key interface{}) ValueOption {
+	return func(v *Value) {
+		if handler := v.OnRemove; handler != nil {
+			v.OnRemove = func(_ interface{}, reason RemovalReason) {
+				handler(key, reason)
+			}
+		}
+	}
+}
+
+func (tt *TwoTier) formatKey(key interface{}) string {
+	if tt.KeyFormatter != nil {
+		return tt.KeyFormatter(key)
+	}
+	if typed, ok := key.(string); ok {
+		return typed
+	}
+	return fmt.Sprint(key)
+}
@@ -0,0 +1,254 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/graceful"
+)
+
+var (
+	_ graceful.Graceful = (*TwoTier)(nil)
+)
+
+func TestTwoTier(t *testing.T) {
+	assert := assert.New(t)
+
+	l2 := NewMemoryBackend()
+	tt := NewTwoTier(l2)
+
+	tt.Set("foo", "bar", OptValueTTL(time.Minute))
+	assert.True(tt.L1.Has("foo"))
+	assert.NotNil(l2.Entries["foo"].Data)
+	assert.False(l2.Entries["foo"].Expires.IsZero())
+
+	value, ok := tt.Get("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+
+	// values missing from the local cache are read from the backend
+	tt.L1.Reset()
+	value, ok = tt.Get("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+	assert.True(tt.L1.Has("foo"))
+	assert.Equal(l2.Entries["foo"].Expires, tt.L1.Data["foo"].Expires)
+
+	value, ok = tt.Remove("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+	assert.False(tt.Has("foo"))
+	assert.Empty(l2.Entries)
+
+	// keys are formatted as strings
+	tt.Set(1234, "number")
+	assert.True(tt.Has(1234))
+	assert.NotNil(l2.Entries["1234"].Data)
+}
+
+type twoTierTestValue struct {
+	ID    int
+	Names []string
+}
+
+func TestTwoTierValueTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	gob.Register(twoTierTestValue{})
+	l2 := NewMemoryBackend()
+	tt := NewTwoTier(l2)
+	peer := NewTwoTier(l2)
+
+	tt.Set("int", 1234)
+	tt.Set("struct", twoTierTestValue{ID: 1, Names: []string{"foo", "bar"}})
+
+	for _, c := range []*TwoTier{tt, peer} {
+		value, ok := c.Get("int")
+		assert.True(ok)
+		assert.Equal(1234, value)
+
+		value, ok = c.Get("struct")
+		assert.True(ok)
+		assert.Equal(twoTierTestValue{ID: 1, Names: []string{"foo", "bar"}}, value)
+	}
+	assert.True(peer.L1.Has("struct"), "the peer should read the value from the backend")
+}
+
+func TestTwoTierJSONCodecValueTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	l2 := NewMemoryBackend()
+	tt := NewTwoTier(l2, OptTwoTierCodec(JSONCodec{}))
+	peer := NewTwoTier(l2, OptTwoTierCodec(JSONCodec{}))
+
+	// both tiers hold the json decoded value
+	value, hit, err := tt.GetOrSet("int", func() (interface{}, error) { return 1234, nil })
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal(float64(1234), value)
+	for _, c := range []*TwoTier{tt, peer} {
+		value, ok := c.Get("int")
+		assert.True(ok)
+		assert.Equal(float64(1234), value)
+	}
+}
+
+func TestTwoTierRemoveL2Only(t *testing.T) {
+	assert := assert.New(t)
+
+	l2 := NewMemoryBackend()
+	tt := NewTwoTier(l2, OptTwoTierCodec(JSONCodec{}))
+	assert.Nil(l2.Set(context.Background(), "foo", BackendEntry{Data: []byte(`"bar"`)}))
+
+	value, ok := tt.Remove("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+	assert.Empty(l2.Entries)
+}
+
+func TestTwoTierOnRemoveOriginalKey(t *testing.T) {
+	assert := assert.New(t)
+
+	var removedKey int
+	var removedReason RemovalReason
+	tt := NewTwoTier(NewMemoryBackend())
+	tt.Set(123, "bar", OptValueOnRemoveTyped(func(key int, reason RemovalReason) {
+		removedKey = key
+		removedReason = reason
+	}))
+
+	_, ok := tt.Remove(123)
+	assert.True(ok)
+	assert.Equal(123, removedKey)
+	assert.Equal(Removed, removedReason)
+}
+
+func TestTwoTierGetOrSet(t *testing.T) {
+	assert := assert.New(t)
+
+	tt := NewTwoTier(NewMemoryBackend())
+	var calls int32
+	provider := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "bar", nil
+	}
+
+	value, hit, err := tt.GetOrSet("foo", provider)
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal("bar", value)
+
+	value, hit, err = tt.GetOrSet("foo", provider)
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal("bar", value)
+	assert.Equal(1, calls)
+
+	_, _, err = tt.GetOrSet("error", func() (interface{}, error) { return nil, fmt.Errorf("this is only a test") })
+	assert.NotNil(err)
+	assert.False(tt.Has("error"))
+}
+
+func TestTwoTierL1TTL(t *testing.T) {
+	assert := assert.New(t)
+
+	l2 := NewMemoryBackend()
+	tt := NewTwoTier(l2, OptTwoTierL1TTL(time.Second))
+	tt.Set("foo", "bar", OptValueTTL(time.Hour))
+	tt.Set("forever", "bar")
+
+	assert.True(tt.L1.Data["foo"].Expires.Before(time.Now().UTC().Add(time.Minute)))
+	assert.False(tt.L1.Data["forever"].Expires.IsZero())
+	assert.True(l2.Entries["foo"].Expires.After(time.Now().UTC().Add(time.Minute)))
+	assert.True(l2.Entries["forever"].Expires.IsZero())
+
+	// expired local values are read again from the backend
+	tt.L1.Set("foo", "stale", OptValueTTL(-time.Second))
+	value, ok := tt.Get("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+}
+
+func TestTwoTierInvalidation(t *testing.T) {
+	assert := assert.New(t)
+
+	l2 := NewMemoryBackend()
+	bus := NewLocalInvalidationBus()
+	replica0 := NewTwoTier(l2, OptTwoTierInvalidationBus(bus))
+	replica1 := NewTwoTier(l2, OptTwoTierInvalidationBus(bus))
+
+	for _, replica := range []*TwoTier{replica0, replica1} {
+		go func(tt *TwoTier) { _ = tt.Start() }(replica)
+		<-replica.NotifyStarted()
+	}
+	defer func() {
+		_ = replica0.Stop()
+		_ = replica1.Stop()
+	}()
+	for bus.Listeners() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	replica0.Set("foo", "bar")
+	value, ok := replica1.Get("foo")
+	assert.True(ok)
+	assert.Equal("bar", value)
+	assert.True(replica1.L1.Has("foo"))
+
+	// the write on one replica removes the stale local copy on the other
+	replica0.Set("foo", "baz")
+	assert.True(replica0.L1.Has("foo"), "a replica should ignore its own invalidations")
+	assert.False(replica1.L1.Has("foo"))
+	value, _ = replica1.Get("foo")
+	assert.Equal("baz", value)
+
+	replica1.Remove("foo")
+	assert.False(replica0.L1.Has("foo"))
+	assert.False(replica0.Has("foo"))
+}
+
+func TestLocalInvalidationBusListen(t *testing.T) {
+	assert := assert.New(t)
+
+	bus := NewLocalInvalidationBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan Invalidation, 1)
+	done := make(chan error)
+	go func() {
+		done <- bus.Listen(ctx, func(_ context.Context, invalidation Invalidation) { received <- invalidation })
+	}()
+	for bus.Listeners() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Nil(bus.Publish(context.Background(), Invalidation{Source: "test", Key: "foo"}))
+	assert.Equal(Invalidation{Source: "test", Key: "foo"}, <-received)
+
+	cancel()
+	assert.Nil(<-done)
+	assert.Zero(bus.Listeners())
+}
+
+func TestMemoryBackendExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	mb := NewMemoryBackend()
+	assert.Nil(mb.Set(ctx, "foo", BackendEntry{Data: []byte("bar"), Expires: time.Now().UTC().Add(-time.Second)}))
+	_, found, err := mb.Get(ctx, "foo")
+	assert.Nil(err)
+	assert.False(found)
+	assert.Empty(mb.Entries)
+}
@@ -0,0 +1,148 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"reflect"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/graceful"
+)
+
+// ErrUnexpectedValueType is returned by typed caches when a cached value is not of the expected type.
+const ErrUnexpectedValueType ex.Class = "cache; unexpected value type"
+
+// NewTyped returns a new type safe cache backed by a local cache.
+func NewTyped[K comparable, V any](options ...LocalCacheOption) *Typed[K, V] {
+	return &Typed[K, V]{
+		Cache: New(options...),
+	}
+}
+
+// NewTypedSharded returns a new type safe cache backed by a sharded cache.
+func NewTypedSharded[K comparable, V any](options ...ShardedCacheOption) *Typed[K, V] {
+	return &Typed[K, V]{
+		Cache: NewSharded(options...),
+	}
+}
+
+// OptValueOnRemoveTyped sets a typed on remove handler.
+func OptValueOnRemoveTyped[K comparable](handler func(K, RemovalReason)) ValueOption {
+	return OptValueOnRemove(func(key interface{}, reason RemovalReason) {
+		typed, _ := key.(K)
+		handler(typed, reason)
+	})
+}
+
+// Typed is a type safe cache of values of type `V` keyed by type `K`, wrapping an untyped cache.
+//
+// Values set through the untyped cache that are not of type `V` are treated as misses
+// by `Get` and `Remove`, and as an `ErrUnexpectedValueType` error by `GetOrSet`.
+type Typed[K comparable, V any] struct {
+	Cache Cache
+}
+
+// Start starts the sweeper of the underlying cache, if it has one.
+func (t *Typed[K, V]) Start() error {
+	if typed, ok := t.Cache.(graceful.Graceful); ok {
+		return typed.Start()
+	}
+	return nil
+}
+
+// Stop stops the sweeper of the underlying cache, if it has one.
+func (t *Typed[K, V]) Stop() error {
+	if typed, ok := t.Cache.(graceful.Graceful); ok {
+		return typed.Stop()
+	}
+	return nil
+}
+
+// NotifyStarted returns the started signal of the underlying cache, or nil if it has no sweeper.
+func (t *Typed[K, V]) NotifyStarted() <-chan struct{} {
+	if typed, ok := t.Cache.(interface{ NotifyStarted() <-chan struct{} }); ok {
+		return typed.NotifyStarted()
+	}
+	return nil
+}
+
+// NotifyStopped returns the stopped signal of the underlying cache, or nil if it has no sweeper.
+func (t *Typed[K, V]) NotifyStopped() <-chan struct{} {
+	if typed, ok := t.Cache.(interface{ NotifyStopped() <-chan struct{} }); ok {
+		return typed.NotifyStopped()
+	}
+	return nil
+}
+
+// Has returns if the key is present in the cache.
+func (t *Typed[K, V]) Has(key K) bool {
+	return t.Cache.Has(key)
+}
+
+// Set adds a cache item.
+func (t *Typed[K, V]) Set(key K, value V, options ...ValueOption) {
+	t.Cache.Set(key, value, options...)
+}
+
+// Get gets a value based on a key.
+func (t *Typed[K, V]) Get(key K) (value V, hit bool) {
+	if untyped, ok := t.Cache.Get(key); ok {
+		value, hit = asTyped[V](untyped)
+	}
+	return
+}
+
+// GetOrSet gets a value by a key, and in the case of a miss, sets the value from a given value provider lazily.
+// Hit indicates that the provider was not called.
+func (t *Typed[K, V]) GetOrSet(key K, valueProvider func() (V, error), options ...ValueOption) (value V, hit bool, err error) {
+	var untyped interface{}
+	untyped, hit, err = t.Cache.GetOrSet(key, func() (interface{}, error) {
+		return valueProvider()
+	}, options...)
+	if err != nil {
+		return
+	}
+	var ok bool
+	if value, ok = asTyped[V](untyped); !ok {
+		err = ex.New(ErrUnexpectedValueType, ex.OptMessagef("expected: %v, actual: %T", reflect.TypeOf((*V)(nil)).Elem(), untyped))
+	}
+	return
+}
+
+// Remove removes a specific key.
+func (t *Typed[K, V]) Remove(key K) (value V, hit bool) {
+	if untyped, ok := t.Cache.Remove(key); ok {
+		value, hit = asTyped[V](untyped)
+	}
+	return
+}
+
+// Reset removes all items from the underlying cache, if it supports it.
+func (t *Typed[K, V]) Reset() {
+	if typed, ok := t.Cache.(interface{ Reset() }); ok {
+		typed.Reset()
+	}
+}
+
+// Stats returns the stats of the underlying cache, if it supports them.
+func (t *Typed[K, V]) Stats() Stats {
+	if typed, ok := t.Cache.(interface{ Stats() Stats }); ok {
+		return typed.Stats()
+	}
+	return Stats{}
+}
+
+// asTyped asserts an untyped cached value is a `V`, allowing nil values for interface types.
+func asTyped[V any](untyped interface{}) (value V, ok bool) {
+	if untyped == nil {
+		ok = reflect.TypeOf((*V)(nil)).Elem().Kind() == reflect.Interface
+		return
+	}
+	value, ok = untyped.(V)
+	return
+}
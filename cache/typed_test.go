@@ -0,0 +1,136 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/graceful"
+)
+
+var (
+	_ graceful.Graceful = (*Typed[string, int])(nil)
+)
+
+type typedTestKey struct {
+	ID int
+}
+
+func TestTyped(t *testing.T) {
+	assert := assert.New(t)
+
+	c := NewTyped[typedTestKey, string]()
+	c.Set(typedTestKey{1}, "foo")
+	assert.True(c.Has(typedTestKey{1}))
+	assert.False(c.Has(typedTestKey{2}))
+
+	value, ok := c.Get(typedTestKey{1})
+	assert.True(ok)
+	assert.Equal("foo", value)
+
+	value, ok = c.Get(typedTestKey{2})
+	assert.False(ok)
+	assert.Empty(value)
+
+	value, ok = c.Remove(typedTestKey{1})
+	assert.True(ok)
+	assert.Equal("foo", value)
+	assert.False(c.Has(typedTestKey{1}))
+
+	// values set through the untyped cache with the wrong type are misses
+	c.Cache.Set(typedTestKey{3}, 3)
+	value, ok = c.Get(typedTestKey{3})
+	assert.False(ok)
+	assert.Empty(value)
+
+	value, _, err := c.GetOrSet(typedTestKey{3}, func() (string, error) { return "bar", nil })
+	assert.True(ex.Is(err, ErrUnexpectedValueType))
+	assert.Empty(value)
+
+	value, ok = c.Remove(typedTestKey{3})
+	assert.False(ok)
+	assert.Empty(value)
+	assert.False(c.Has(typedTestKey{3}))
+
+	c.Reset()
+	assert.Zero(c.Stats().Count)
+}
+
+func TestTypedGetOrSet(t *testing.T) {
+	assert := assert.New(t)
+
+	c := NewTypedSharded[string, int](OptShards(2))
+	value, hit, err := c.GetOrSet("foo", func() (int, error) { return 1, nil }, OptValueTTL(time.Minute))
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Equal(1, value)
+
+	value, hit, err = c.GetOrSet("foo", func() (int, error) { return 2, nil })
+	assert.Nil(err)
+	assert.True(hit)
+	assert.Equal(1, value)
+
+	value, _, err = c.GetOrSet("bar", func() (int, error) { return 0, fmt.Errorf("this is only a test") })
+	assert.NotNil(err)
+	assert.Zero(value)
+	assert.False(c.Has("bar"))
+	assert.Equal(1, c.Stats().Count)
+}
+
+func TestTypedNilInterfaceValue(t *testing.T) {
+	assert := assert.New(t)
+
+	c := NewTyped[string, error]()
+	c.Set("foo", nil)
+	value, ok := c.Get("foo")
+	assert.True(ok)
+	assert.Nil(value)
+
+	value, hit, err := c.GetOrSet("bar", func() (error, error) { return nil, nil })
+	assert.Nil(err)
+	assert.False(hit)
+	assert.Nil(value)
+}
+
+func TestTypedOnRemove(t *testing.T) {
+	assert := assert.New(t)
+
+	var removedKey string
+	var removedReason RemovalReason
+	c := NewTyped[string, int](OptMaxEntries(1))
+	c.Set("foo", 1, OptValueOnRemoveTyped(func(key string, reason RemovalReason) {
+		removedKey = key
+		removedReason = reason
+	}))
+	c.Set("bar", 2)
+	assert.Equal("foo", removedKey)
+	assert.Equal(Evicted, removedReason)
+}
+
+func TestTypedSweeper(t *testing.T) {
+	assert := assert.New(t)
+
+	c := NewTyped[string, int](OptSweepInterval(time.Millisecond))
+	c.Set("foo", 1, OptValueTTL(-time.Second))
+	go func() { _ = c.Start() }()
+	<-c.NotifyStarted()
+	defer func() { _ = c.Stop() }()
+
+	deadline := time.After(time.Second)
+	for c.Has("foo") {
+		select {
+		case <-deadline:
+			assert.FailNow("sweeper should have removed the expired value")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
@@ -42,6 +42,37 @@ func OptValueOnRemove(handler func(interface{}, RemovalReason)) ValueOption {
 	}
 }
 
+// OptValueSize sets the size of the value in bytes, overriding the cache's sizer.
+func OptValueSize(sizeBytes int) ValueOption {
+	return func(v *Value) {
+		v.Size = sizeBytes
+	}
+}
+
+// OptValueRefreshAhead sets how long before the value expires `GetOrSet` starts refreshing
+// it in the background, while continuing to return the current value.
+func OptValueRefreshAhead(d time.Duration) ValueOption {
+	return func(v *Value) {
+		v.RefreshAhead = d
+	}
+}
+
+// OptValueStaleWhileRevalidate sets how long after the value expires `GetOrSet` returns the
+// stale value while refreshing it in the background, rather than blocking on the value provider.
+func OptValueStaleWhileRevalidate(d time.Duration) ValueOption {
+	return func(v *Value) {
+		v.StaleWhileRevalidate = d
+	}
+}
+
+// OptValueStaleIfError sets how long after the value expires `GetOrSet` returns the stale value
+// if the value provider returns an error.
+func OptValueStaleIfError(d time.Duration) ValueOption {
+	return func(v *Value) {
+		v.StaleIfError = d
+	}
+}
+
 // Value is a cached item.
 type Value struct {
 	Timestamp time.Time
@@ -49,4 +80,46 @@ type Value struct {
 	Key       interface{}
 	Value     interface{}
 	OnRemove  func(interface{}, RemovalReason)
+	// Size is the estimated size of the value in bytes.
+	Size int
+	// RefreshAhead is how long before expiry the value is refreshed in the background.
+	RefreshAhead time.Duration
+	// StaleWhileRevalidate is how long after expiry the stale value is served while it is refreshed in the background.
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is how long after expiry the stale value is served if refreshing it fails.
+	StaleIfError time.Duration
+}
+
+// IsExpired returns if the value has expired at a given time.
+func (v Value) IsExpired(now time.Time) bool {
+	return !v.Expires.IsZero() && now.After(v.Expires)
+}
+
+// ShouldRefreshAhead returns if the value has not expired, but is close enough
+// to expiring that it should be refreshed in the background.
+func (v Value) ShouldRefreshAhead(now time.Time) bool {
+	return v.RefreshAhead > 0 && !v.Expires.IsZero() && !v.IsExpired(now) && !now.Before(v.Expires.Add(-v.RefreshAhead))
+}
+
+// CanServeStale returns if the value has expired but can be served while it is refreshed in the background.
+func (v Value) CanServeStale(now time.Time) bool {
+	return v.IsExpired(now) && !now.After(v.Expires.Add(v.StaleWhileRevalidate))
+}
+
+// CanServeStaleIfError returns if the value has expired but can be served if refreshing it fails.
+func (v Value) CanServeStaleIfError(now time.Time) bool {
+	return v.IsExpired(now) && !now.After(v.Expires.Add(v.StaleIfError))
+}
+
+// RemoveAfter returns when the value can be removed by a sweep, after it has expired
+// and can no longer be served stale.
+func (v Value) RemoveAfter() time.Time {
+	if v.Expires.IsZero() {
+		return v.Expires
+	}
+	grace := v.StaleWhileRevalidate
+	if v.StaleIfError > grace {
+		grace = v.StaleIfError
+	}
+	return v.Expires.Add(grace)
 }
@@ -15,8 +15,10 @@ import (
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/blend/go-sdk/certutil"
+	"github.com/blend/go-sdk/configutil"
 	"github.com/blend/go-sdk/graceful"
 	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/proxyprotocol"
@@ -27,7 +29,7 @@ import (
 func main() {
 	log, err := logger.New(
 		logger.OptConfigFromEnv(),
-		logger.OptEnabled(webutil.FlagHTTPRequest),
+		logger.OptEnabled(webutil.FlagHTTPRequest, reverseproxy.FlagUpstreamState),
 		logger.OptPath("reverse-proxy"),
 	)
 	if err != nil {
@@ -55,9 +57,60 @@ func main() {
 	var upstreamHeaders UpstreamHeader
 	flag.Var(&upstreamHeaders, "upstream-header", "Upstream heaeders to add for all requests.")
 
+	var resolver string
+	flag.StringVar(&resolver, "resolver", reverseproxy.ResolverRoundRobin, "The load balancing resolver (round-robin, least-connections, random-two-choices, or consistent-hash).")
+
+	var hashHeader string
+	flag.StringVar(&hashHeader, "hash-header", "", "The request header to pin requests by for the consistent-hash resolver.")
+
+	var hashCookie string
+	flag.StringVar(&hashCookie, "hash-cookie", "", "The request cookie to pin requests by for the consistent-hash resolver (defaults to the request path).")
+
+	var healthCheckPath string
+	flag.StringVar(&healthCheckPath, "health-check-path", "", "The path to actively probe on each upstream (disabled if unset).")
+
+	var healthCheckInterval time.Duration
+	flag.DurationVar(&healthCheckInterval, "health-check-interval", reverseproxy.DefaultHealthCheckInterval, "The interval between active health check probes.")
+
+	var passiveMaxFailures int
+	flag.IntVar(&passiveMaxFailures, "passive-max-failures", 0, "The number of consecutive failed requests that eject an upstream (disabled if unset).")
+
+	var passiveEjectionDuration time.Duration
+	flag.DurationVar(&passiveEjectionDuration, "passive-ejection-duration", reverseproxy.DefaultPassiveHealthCheckEjectionDuration, "How long an upstream is ejected for after failed requests.")
+
+	var routes Routes
+	flag.Var(&routes, "route", "A route in the form host/path=upstream[,upstream...]; the host is optional.")
+
+	var configPath string
+	flag.StringVar(&configPath, "config", "", "The path to a yaml config file of routes.")
+
+	var statusAddr string
+	flag.StringVar(&statusAddr, "status-addr", "", "The address to serve upstream status on (disabled if unset).")
+
 	flag.Parse()
 
-	if len(upstreams) == 0 {
+	var cfg reverseproxy.Config
+	if configPath != "" {
+		paths, err := configutil.Read(&cfg, configutil.OptPaths(configPath))
+		if !configutil.IsIgnored(err) {
+			log.Fatal(err)
+			os.Exit(1)
+		}
+		if len(paths) == 0 {
+			log.Fatal(fmt.Errorf("config file not found: %s", configPath))
+			os.Exit(1)
+		}
+	}
+	for _, route := range routes {
+		routeConfig, err := reverseproxy.ParseRouteConfig(route)
+		if err != nil {
+			log.Fatal(err)
+			os.Exit(1)
+		}
+		cfg.Routes = append(cfg.Routes, routeConfig)
+	}
+
+	if len(upstreams) == 0 && len(cfg.Routes) == 0 {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -67,6 +120,12 @@ func main() {
 	proxy, _ := reverseproxy.NewProxy()
 	proxy.Log = log
 
+	var upstreamOptions []reverseproxy.UpstreamOption
+	upstreamOptions = append(upstreamOptions, reverseproxy.OptUpstreamLog(log))
+	if passiveMaxFailures > 0 {
+		upstreamOptions = append(upstreamOptions, reverseproxy.OptUpstreamPassiveHealthCheck(passiveMaxFailures, passiveEjectionDuration))
+	}
+
 	var servers []graceful.Graceful
 	for _, upstream := range upstreams {
 		log.Infof("upstream: %s", upstream)
@@ -76,8 +135,7 @@ func main() {
 			os.Exit(1)
 		}
 
-		proxyUpstream := reverseproxy.NewUpstream(target)
-		proxyUpstream.Log = log
+		proxyUpstream := reverseproxy.NewUpstream(target, upstreamOptions...)
 		if err = proxyUpstream.UseHTTP2(); err != nil {
 			log.Fatal(err)
 			os.Exit(1)
@@ -85,6 +143,52 @@ func main() {
 		proxy.Upstreams = append(proxy.Upstreams, proxyUpstream)
 	}
 
+	for _, routeConfig := range cfg.Routes {
+		route, err := routeConfig.Route(upstreamOptions...)
+		if err != nil {
+			log.Fatal(err)
+			os.Exit(1)
+		}
+		for _, routeUpstream := range route.Upstreams {
+			log.Infof("route: %s upstream: %s", routeConfig.Name, routeUpstream.URL)
+			if err = routeUpstream.UseHTTP2(); err != nil {
+				log.Fatal(err)
+				os.Exit(1)
+			}
+		}
+		proxy.Routes = append(proxy.Routes, route)
+	}
+
+	var hashKeyProvider reverseproxy.HashKeyProvider
+	switch {
+	case hashHeader != "":
+		hashKeyProvider = reverseproxy.HashKeyHeader(hashHeader)
+	case hashCookie != "":
+		hashKeyProvider = reverseproxy.HashKeyCookie(hashCookie)
+	}
+	proxy.Resolver, err = reverseproxy.ParseResolver(resolver, hashKeyProvider)
+	if err != nil {
+		log.Fatal(err)
+		os.Exit(1)
+	}
+	log.Infof("proxy using resolver: %s", resolver)
+
+	if healthCheckPath != "" {
+		log.Infof("proxy health checking upstreams at: %s every %v", healthCheckPath, healthCheckInterval)
+		servers = append(servers, reverseproxy.NewHealthCheck(proxy.AllUpstreams(),
+			reverseproxy.OptHealthCheckPath(healthCheckPath),
+			reverseproxy.OptHealthCheckInterval(healthCheckInterval),
+		))
+	}
+
+	if statusAddr != "" {
+		log.Infof("upstream status listening on: %s", statusAddr)
+		servers = append(servers, webutil.NewGracefulHTTPServer(&http.Server{
+			Addr:    statusAddr,
+			Handler: http.HandlerFunc(proxy.StatusHandler),
+		}))
+	}
+
 	for _, header := range upstreamHeaders {
 		pieces := strings.SplitN(header, "=", 2)
 		if len(pieces) < 2 {
@@ -191,3 +295,20 @@ func (u *UpstreamHeader) Set(value string) error {
 	*u = append(*u, value)
 	return nil
 }
+
+// Routes is a flag variable for routes.
+type Routes []string
+
+// String returns a string representation of the routes.
+func (r *Routes) String() string {
+	if r == nil {
+		return "<nil>"
+	}
+	return strings.Join(*r, ", ")
+}
+
+// Set adds a flag value.
+func (r *Routes) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
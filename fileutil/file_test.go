@@ -9,6 +9,9 @@ package fileutil
 
 import (
 	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/blend/go-sdk/assert"
@@ -58,3 +61,21 @@ func TestFileReadByChunks(t *testing.T) {
 	assert.True(called, "We should have called the handler for `README.md`")
 	assert.True(lengthCorrect, "We should have been passed 32 bytes")
 }
+
+func TestNewTempFromReader(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := NewTempFromReader(strings.NewReader("this is a test"))
+	assert.Nil(err)
+	defer f.Close()
+
+	contents, err := ioutil.ReadAll(f)
+	assert.Nil(err)
+	assert.Equal("this is a test", string(contents))
+
+	_, err = f.Seek(0, io.SeekStart)
+	assert.Nil(err)
+	contents, err = ioutil.ReadAll(f)
+	assert.Nil(err)
+	assert.Equal("this is a test", string(contents))
+}
@@ -1,6 +1,6 @@
 module github.com/blend/go-sdk
 
-go 1.15
+go 1.18
 
 require (
 	github.com/DataDog/datadog-go v4.2.0+incompatible
@@ -8,7 +8,6 @@ require (
 	github.com/golang/protobuf v1.4.3
 	github.com/jackc/pgx/v4 v4.10.0
 	github.com/opentracing/opentracing-go v1.2.0
-	github.com/philhofer/fwd v1.1.1 // indirect
 	github.com/spf13/cobra v1.1.1
 	github.com/tinylib/msgp v1.1.2
 	golang.org/x/crypto v0.0.0-20201203163018-be400aefbc4c
@@ -20,3 +19,23 @@ require (
 	gopkg.in/DataDog/dd-trace-go.v1 v1.27.1
 	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 )
+
+require (
+	cloud.google.com/go v0.65.0 // indirect
+	github.com/google/uuid v1.1.2 // indirect
+	github.com/jackc/chunkreader/v2 v2.0.1 // indirect
+	github.com/jackc/pgconn v1.8.0 // indirect
+	github.com/jackc/pgio v1.0.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgproto3/v2 v2.0.6 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20200714003250-2b9c44734f2b // indirect
+	github.com/jackc/pgtype v1.6.2 // indirect
+	github.com/philhofer/fwd v1.1.1 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.0.0-20200930185726-fdedc70b468f // indirect
+	golang.org/x/term v0.0.0-20201117132131-f5c789dd3221 // indirect
+	golang.org/x/text v0.3.3 // indirect
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	google.golang.org/genproto v0.0.0-20200825200019-8632dd797987 // indirect
+)
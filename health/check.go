@@ -0,0 +1,58 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// CheckFunc is a function that returns an error if a component is unhealthy.
+type CheckFunc func(context.Context) error
+
+// Check is a named health check.
+type Check struct {
+	// Name is the name of the check, and should be unique.
+	Name string
+	// Check is the check function.
+	Check CheckFunc
+	// Timeout is the timeout for the check; if unset `DefaultCheckTimeout` is used.
+	Timeout time.Duration
+	// Critical determines if a failure of the check fails the report.
+	Critical bool
+	// Liveness determines if the check is also used for liveness.
+	Liveness bool
+}
+
+// TimeoutOrDefault returns the timeout or a default.
+func (c Check) TimeoutOrDefault() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultCheckTimeout
+}
+
+// CheckResult is the result of an individual check.
+type CheckResult struct {
+	Status    Status        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Error     string        `json:"error,omitempty"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// Report is the result of a set of checks.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// IsHealthy returns if the report status should be considered healthy.
+func (r Report) IsHealthy() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
@@ -0,0 +1,65 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/blend/go-sdk/cron"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/vault"
+)
+
+// CheckDB returns a check that pings a database connection.
+func CheckDB(conn *db.Connection) CheckFunc {
+	return func(ctx context.Context) error {
+		if conn == nil || conn.Connection == nil {
+			return ex.New(db.ErrConnectionClosed)
+		}
+		return ex.New(conn.Connection.PingContext(ctx))
+	}
+}
+
+// CheckVault returns a check that vault is reachable, initialized and unsealed.
+//
+// It uses the unauthenticated `sys/health` endpoint, and treats standby nodes as healthy.
+func CheckVault(client *vault.APIClient) CheckFunc {
+	return func(ctx context.Context) error {
+		remote := *client.Remote
+		remote.Path = "/v1/sys/health"
+		remote.RawQuery = "standbyok=true&perfstandbyok=true"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, remote.String(), nil)
+		if err != nil {
+			return ex.New(err)
+		}
+		res, err := client.Client.Do(req)
+		if err != nil {
+			return ex.New(err)
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		if res.StatusCode != http.StatusOK {
+			return ex.New(ErrVaultUnhealthy, ex.OptMessagef("status code: %d", res.StatusCode))
+		}
+		return nil
+	}
+}
+
+// CheckJobManager returns a check that a job manager is running.
+func CheckJobManager(jm *cron.JobManager) CheckFunc {
+	return func(_ context.Context) error {
+		if state := jm.State(); state != cron.JobManagerStateRunning {
+			return ex.New(ErrJobManagerState, ex.OptMessagef("state: %s", state))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/cron"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/vault"
+)
+
+func TestCheckDBUnopened(t *testing.T) {
+	assert := assert.New(t)
+
+	err := CheckDB(nil)(context.Background())
+	assert.True(ex.Is(err, db.ErrConnectionClosed))
+
+	conn, err := db.New()
+	assert.Nil(err)
+	err = CheckDB(conn)(context.Background())
+	assert.True(ex.Is(err, db.ErrConnectionClosed))
+}
+
+func TestCheckJobManager(t *testing.T) {
+	assert := assert.New(t)
+
+	jm := cron.New()
+	err := CheckJobManager(jm)(context.Background())
+	assert.True(ex.Is(err, ErrJobManagerState))
+
+	assert.Nil(jm.StartAsync())
+	defer func() { _ = jm.Stop() }()
+	assert.Nil(CheckJobManager(jm)(context.Background()))
+}
+
+func TestCheckVault(t *testing.T) {
+	assert := assert.New(t)
+
+	client, err := vault.New()
+	assert.Nil(err)
+	healthURL, err := url.Parse(client.Remote.String() + "/v1/sys/health?standbyok=true&perfstandbyok=true")
+	assert.Nil(err)
+
+	client.Client = vault.NewMockHTTPClient().WithString("GET", healthURL, `{"initialized":true,"sealed":false}`)
+	assert.Nil(CheckVault(client)(context.Background()))
+
+	client.Client = vault.NewMockHTTPClient().With("GET", healthURL, &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"initialized":true,"sealed":true}`)),
+	})
+	err = CheckVault(client)(context.Background())
+	assert.True(ex.Is(err, ErrVaultUnhealthy))
+
+	client.Client = vault.NewMockHTTPClient()
+	assert.NotNil(CheckVault(client)(context.Background()))
+}
@@ -0,0 +1,36 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import "time"
+
+const (
+	// DefaultReadinessPath is the default readiness route path.
+	DefaultReadinessPath = "/readyz"
+	// DefaultLivenessPath is the default liveness route path.
+	DefaultLivenessPath = "/livez"
+	// DefaultCheckTimeout is the default timeout for an individual check.
+	DefaultCheckTimeout = 5 * time.Second
+	// DefaultCacheTTL is the default time check results are cached for.
+	DefaultCacheTTL = time.Second
+)
+
+// Status is the status of a check or a report.
+type Status string
+
+// Status values.
+const (
+	// StatusOK is returned if all checks passed.
+	StatusOK Status = "ok"
+	// StatusDegraded is returned if only non-critical checks failed.
+	StatusDegraded Status = "degraded"
+	// StatusFailing is returned if any critical check failed.
+	StatusFailing Status = "failing"
+	// StatusShuttingDown is returned for readiness once shutdown has started.
+	StatusShuttingDown Status = "shutting_down"
+)
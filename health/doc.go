@@ -0,0 +1,25 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+/*
+Package health provides readiness and liveness checks for `web.App` services.
+
+Components register named checks, each with a timeout and a criticality:
+
+	h := health.New(
+		health.OptCheck(health.Check{Name: "db", Critical: true, Check: health.CheckDB(conn)}),
+		health.OptCheck(health.Check{Name: "cron", Check: health.CheckJobManager(jm)}),
+	)
+	h.RegisterRoutes(app)
+	if err := graceful.Shutdown(app, h); err != nil {
+		logger.FatalExit(err)
+	}
+
+Readiness fails if any critical check fails, or as soon as shutdown begins (i.e. when `Stop` is called
+on the health instance by `graceful`). Liveness only runs the checks flagged with `Liveness`.
+*/
+package health // import "github.com/blend/go-sdk/health"
@@ -0,0 +1,18 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import "github.com/blend/go-sdk/ex"
+
+// Errors
+const (
+	ErrCheckUnset      ex.Class = "health; check function is unset"
+	ErrCheckTimeout    ex.Class = "health; check timed out"
+	ErrJobManagerState ex.Class = "health; job manager is not running"
+	ErrVaultUnhealthy  ex.Class = "health; vault is unhealthy"
+)
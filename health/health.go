@@ -0,0 +1,227 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/async"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/graceful"
+)
+
+var (
+	_ graceful.Graceful = (*Health)(nil)
+)
+
+// New returns a new health instance.
+func New(options ...Option) *Health {
+	h := Health{
+		Latch:    async.NewLatch(),
+		CacheTTL: DefaultCacheTTL,
+		results:  make(map[string]CheckResult),
+	}
+	for _, option := range options {
+		option(&h)
+	}
+	return &h
+}
+
+// Option is an option for health instances.
+type Option func(*Health)
+
+// OptCheck adds a check.
+func OptCheck(check Check) Option {
+	return func(h *Health) { h.Checks = append(h.Checks, check) }
+}
+
+// OptCacheTTL sets the time check results are cached for.
+func OptCacheTTL(ttl time.Duration) Option {
+	return func(h *Health) { h.CacheTTL = ttl }
+}
+
+// Health runs a set of checks and reports on them.
+//
+// It implements `graceful.Graceful` so that readiness reports fail as soon as shutdown begins.
+type Health struct {
+	sync.Mutex
+	Latch *async.Latch
+
+	Checks   []Check
+	CacheTTL time.Duration
+
+	shuttingDown bool
+	results      map[string]CheckResult
+}
+
+// Register adds a check, it is safe to call concurrently with running reports.
+func (h *Health) Register(check Check) {
+	h.Lock()
+	defer h.Unlock()
+	h.Checks = append(h.Checks, check)
+}
+
+// Start blocks until the health instance is stopped.
+func (h *Health) Start() error {
+	if !h.Latch.CanStart() {
+		return ex.New(async.ErrCannotStart)
+	}
+	h.Latch.Starting()
+	h.Lock()
+	h.shuttingDown = false
+	h.Unlock()
+	h.Latch.Started()
+	<-h.Latch.NotifyStopping()
+	h.Latch.Stopped()
+	return nil
+}
+
+// Stop marks the health instance as shutting down, which fails readiness.
+func (h *Health) Stop() error {
+	h.Lock()
+	h.shuttingDown = true
+	h.Unlock()
+	if !h.Latch.CanStop() {
+		return nil
+	}
+	h.Latch.WaitStopped()
+	h.Latch.Reset()
+	return nil
+}
+
+// IsShuttingDown returns if shutdown has begun.
+func (h *Health) IsShuttingDown() bool {
+	h.Lock()
+	defer h.Unlock()
+	return h.shuttingDown
+}
+
+// Readiness runs all the checks and returns a report.
+//
+// The report status is `StatusShuttingDown` once shutdown has begun.
+func (h *Health) Readiness(ctx context.Context) Report {
+	if h.IsShuttingDown() {
+		return Report{Status: StatusShuttingDown}
+	}
+	return h.run(ctx, func(Check) bool { return true })
+}
+
+// Liveness runs the checks flagged for liveness and returns a report.
+func (h *Health) Liveness(ctx context.Context) Report {
+	return h.run(ctx, func(c Check) bool { return c.Liveness })
+}
+
+func (h *Health) run(ctx context.Context, filter func(Check) bool) Report {
+	h.Lock()
+	var checks []Check
+	for _, check := range h.Checks {
+		if filter(check) {
+			checks = append(checks, check)
+		}
+	}
+	h.Unlock()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	var reportMu sync.Mutex
+	wg := sync.WaitGroup{}
+	wg.Add(len(checks))
+	for _, check := range checks {
+		go func(c Check) {
+			defer wg.Done()
+			result := h.resultOrCached(ctx, c)
+			reportMu.Lock()
+			defer reportMu.Unlock()
+			report.Checks[c.Name] = result
+			if result.Status == StatusOK {
+				return
+			}
+			if c.Critical {
+				report.Status = StatusFailing
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *Health) resultOrCached(ctx context.Context, check Check) CheckResult {
+	now := time.Now().UTC()
+	if h.CacheTTL > 0 {
+		h.Lock()
+		cached, ok := h.results[check.Name]
+		h.Unlock()
+		if ok && now.Sub(cached.Timestamp) < h.CacheTTL {
+			return cached
+		}
+	}
+	result := runCheck(ctx, check)
+	if h.CacheTTL > 0 {
+		h.Lock()
+		if h.results == nil {
+			h.results = make(map[string]CheckResult)
+		}
+		h.results[check.Name] = result
+		h.Unlock()
+	}
+	return result
+}
+
+// runCheck runs a check with its timeout, recovering panics.
+func runCheck(ctx context.Context, check Check) CheckResult {
+	started := time.Now().UTC()
+	timeoutCtx, cancel := context.WithTimeout(ctx, check.TimeoutOrDefault())
+	defer cancel()
+
+	errors := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errors <- ex.New(r)
+			}
+		}()
+		if check.Check == nil {
+			errors <- ex.New(ErrCheckUnset)
+			return
+		}
+		errors <- check.Check(timeoutCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errors:
+	case <-timeoutCtx.Done():
+		err = ex.New(ErrCheckTimeout, ex.OptMessagef("timeout: %v", check.TimeoutOrDefault()))
+	}
+
+	result := CheckResult{
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		Elapsed:   time.Since(started),
+		Timestamp: started,
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = errorMessage(err)
+	}
+	return result
+}
+
+func errorMessage(err error) string {
+	if message := ex.ErrMessage(err); message != "" {
+		return fmt.Sprintf("%v; %s", ex.ErrClass(err), message)
+	}
+	return fmt.Sprint(ex.ErrClass(err))
+}
@@ -0,0 +1,156 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/web"
+)
+
+func okCheck(_ context.Context) error { return nil }
+
+func failCheck(_ context.Context) error { return fmt.Errorf("this is just a test") }
+
+func TestHealthReadiness(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(
+		OptCheck(Check{Name: "ok", Critical: true, Check: okCheck}),
+		OptCheck(Check{Name: "optional", Check: failCheck}),
+	)
+	report := h.Readiness(context.Background())
+	assert.Equal(StatusDegraded, report.Status)
+	assert.True(report.IsHealthy())
+	assert.Len(report.Checks, 2)
+	assert.Equal(StatusOK, report.Checks["ok"].Status)
+	assert.True(report.Checks["ok"].Critical)
+	assert.Equal(StatusFailing, report.Checks["optional"].Status)
+	assert.Equal("this is just a test", report.Checks["optional"].Error)
+
+	h.Register(Check{Name: "critical", Critical: true, Check: failCheck})
+	report = h.Readiness(context.Background())
+	assert.Equal(StatusFailing, report.Status)
+	assert.False(report.IsHealthy())
+}
+
+func TestHealthLiveness(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(
+		OptCheck(Check{Name: "db", Critical: true, Check: failCheck}),
+		OptCheck(Check{Name: "process", Critical: true, Liveness: true, Check: okCheck}),
+	)
+	report := h.Liveness(context.Background())
+	assert.Equal(StatusOK, report.Status)
+	assert.Len(report.Checks, 1)
+	assert.Equal(StatusOK, report.Checks["process"].Status)
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(OptCheck(Check{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  time.Millisecond,
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+	}))
+	report := h.Readiness(context.Background())
+	assert.Equal(StatusFailing, report.Status)
+	assert.Contains(report.Checks["slow"].Error, string(ErrCheckTimeout))
+}
+
+func TestHealthCheckPanicAndUnset(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(
+		OptCheck(Check{Name: "panics", Critical: true, Check: func(_ context.Context) error { panic("this is just a test") }}),
+		OptCheck(Check{Name: "unset", Critical: true}),
+	)
+	report := h.Readiness(context.Background())
+	assert.Equal(StatusFailing, report.Status)
+	assert.Equal("this is just a test", report.Checks["panics"].Error)
+	assert.Equal(string(ErrCheckUnset), report.Checks["unset"].Error)
+}
+
+func TestHealthCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	check := Check{Name: "counted", Check: func(_ context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}}
+
+	h := New(OptCheck(check), OptCacheTTL(time.Hour))
+	h.Readiness(context.Background())
+	h.Readiness(context.Background())
+	assert.Equal(1, atomic.LoadInt32(&calls))
+
+	h = New(OptCheck(check), OptCacheTTL(0))
+	h.Readiness(context.Background())
+	h.Readiness(context.Background())
+	assert.Equal(3, atomic.LoadInt32(&calls))
+}
+
+func TestHealthGraceful(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(OptCheck(Check{Name: "ok", Critical: true, Check: okCheck}))
+
+	started := h.Latch.NotifyStarted()
+	stopped := make(chan error)
+	go func() { stopped <- h.Start() }()
+	<-started
+	assert.False(h.IsShuttingDown())
+	assert.Equal(StatusOK, h.Readiness(context.Background()).Status)
+
+	assert.Nil(h.Stop())
+	assert.Nil(<-stopped)
+	assert.True(h.IsShuttingDown())
+	report := h.Readiness(context.Background())
+	assert.Equal(StatusShuttingDown, report.Status)
+	assert.False(report.IsHealthy())
+	assert.Equal(StatusOK, h.Liveness(context.Background()).Status)
+}
+
+func TestHealthRegisterRoutes(t *testing.T) {
+	assert := assert.New(t)
+
+	h := New(OptCheck(Check{Name: "ok", Critical: true, Check: okCheck}))
+	app := web.MustNew()
+	h.RegisterRoutes(app)
+
+	var report Report
+	meta, err := web.MockGet(app, DefaultReadinessPath).JSON(&report)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, meta.StatusCode)
+	assert.Equal(StatusOK, report.Status)
+	assert.Equal(StatusOK, report.Checks["ok"].Status)
+
+	meta, err = web.MockGet(app, DefaultLivenessPath).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, meta.StatusCode)
+
+	assert.Nil(h.Stop())
+	meta, err = web.MockGet(app, DefaultReadinessPath).JSON(&report)
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, meta.StatusCode)
+	assert.Equal(StatusShuttingDown, report.Status)
+}
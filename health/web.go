@@ -0,0 +1,43 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package health
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/web"
+)
+
+// RegisterRoutes registers the readiness and liveness routes on an app at their default paths.
+func (h *Health) RegisterRoutes(app *web.App) {
+	app.GET(DefaultReadinessPath, h.ReadinessAction)
+	app.HEAD(DefaultReadinessPath, h.ReadinessAction)
+	app.GET(DefaultLivenessPath, h.LivenessAction)
+	app.HEAD(DefaultLivenessPath, h.LivenessAction)
+}
+
+// ReadinessAction is a web action that returns the readiness report as json.
+//
+// It returns 200 if the report is healthy and 503 otherwise.
+func (h *Health) ReadinessAction(ctx *web.Ctx) web.Result {
+	return reportResult(h.Readiness(ctx.Context()))
+}
+
+// LivenessAction is a web action that returns the liveness report as json.
+//
+// It returns 200 if the report is healthy and 503 otherwise.
+func (h *Health) LivenessAction(ctx *web.Ctx) web.Result {
+	return reportResult(h.Liveness(ctx.Context()))
+}
+
+func reportResult(report Report) web.Result {
+	if report.IsHealthy() {
+		return web.JSON.Status(http.StatusOK, report)
+	}
+	return web.JSON.Status(http.StatusServiceUnavailable, report)
+}
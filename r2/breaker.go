@@ -0,0 +1,159 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/blend/go-sdk/breaker"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+)
+
+// errBreakerFailure is returned to a breaker for responses that are classified as failures.
+const errBreakerFailure ex.Class = "r2; response classified as failure"
+
+// BreakerProvider returns the circuit breaker for a request.
+type BreakerProvider func(*http.Request) (*breaker.Breaker, error)
+
+// BreakerClassifier returns if the result of an attempt counts as a failure for a circuit breaker.
+type BreakerClassifier func(*http.Response, error) bool
+
+// DefaultBreakerClassifier counts network errors, timeouts and 5xx responses as failures.
+//
+// Errors caused by the request context being canceled are not counted.
+func DefaultBreakerClassifier(res *http.Response, err error) bool {
+	if err != nil {
+		return !ex.Is(err, context.Canceled)
+	}
+	return res != nil && res.StatusCode >= http.StatusInternalServerError
+}
+
+// BreakerOptions are the options for sending requests through a circuit breaker.
+type BreakerOptions struct {
+	// Provider returns the breaker for a request.
+	Provider BreakerProvider
+	// Classifier returns if the result of an attempt counts as a failure.
+	// If unset, `DefaultBreakerClassifier` is used.
+	Classifier BreakerClassifier
+}
+
+// Do sends a request with a given client through the circuit breaker.
+//
+// If the breaker does not allow the request, an `ErrBreakerOpen` error is returned,
+// unless the breaker has an open action that returns an `*http.Response`.
+func (bo BreakerOptions) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	b, err := bo.Provider(req)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return client.Do(req)
+	}
+
+	var called bool
+	var res *http.Response
+	var resErr error
+	result, err := b.Do(req.Context(), func(_ context.Context) (interface{}, error) {
+		called = true
+		res, resErr = client.Do(req)
+		if bo.isFailure(res, resErr) {
+			return nil, ex.New(errBreakerFailure)
+		}
+		return nil, nil
+	})
+	if called {
+		return res, resErr
+	}
+	if typed, ok := result.(*http.Response); ok && typed != nil && err == nil {
+		return typed, nil
+	}
+	return nil, ex.New(ErrBreakerOpen, ex.OptMessagef("host: %s", req.URL.Host), ex.OptInner(err))
+}
+
+func (bo BreakerOptions) isFailure(res *http.Response, err error) bool {
+	if bo.Classifier != nil {
+		return bo.Classifier(res, err)
+	}
+	return DefaultBreakerClassifier(res, err)
+}
+
+// NewBreakerRegistry returns a new breaker registry.
+func NewBreakerRegistry(options ...BreakerRegistryOption) *BreakerRegistry {
+	br := BreakerRegistry{
+		Breakers: make(map[string]*breaker.Breaker),
+	}
+	for _, option := range options {
+		option(&br)
+	}
+	return &br
+}
+
+// BreakerRegistryOption mutates a breaker registry.
+type BreakerRegistryOption func(*BreakerRegistry)
+
+// OptBreakerRegistryBreakerOptions sets the options used to create each breaker.
+func OptBreakerRegistryBreakerOptions(options ...breaker.Option) BreakerRegistryOption {
+	return func(br *BreakerRegistry) { br.BreakerOptions = options }
+}
+
+// OptBreakerRegistryLog sets the logger that breaker state changes are triggered on.
+func OptBreakerRegistryLog(log logger.Triggerable) BreakerRegistryOption {
+	return func(br *BreakerRegistry) { br.Log = log }
+}
+
+// BreakerRegistry holds a circuit breaker per host, created on first use.
+type BreakerRegistry struct {
+	sync.Mutex
+	// BreakerOptions are the options used to create each breaker.
+	BreakerOptions []breaker.Option
+	// Log is an optional logger that breaker state changes are triggered on as `BreakerEvent`s.
+	Log logger.Triggerable
+	// Breakers are the breakers by host.
+	Breakers map[string]*breaker.Breaker
+}
+
+// Get returns the breaker for a given host, creating it if it does not exist.
+func (br *BreakerRegistry) Get(host string) (*breaker.Breaker, error) {
+	br.Lock()
+	defer br.Unlock()
+
+	if b, ok := br.Breakers[host]; ok {
+		return b, nil
+	}
+	options := append([]breaker.Option{}, br.BreakerOptions...)
+	if br.Log != nil {
+		options = append(options, breaker.OptOnStateChange(NewBreakerStateChangeHandler(br.Log, host)))
+	}
+	b, err := breaker.New(options...)
+	if err != nil {
+		return nil, err
+	}
+	if br.Breakers == nil {
+		br.Breakers = make(map[string]*breaker.Breaker)
+	}
+	br.Breakers[host] = b
+	return b, nil
+}
+
+// Provider returns a breaker provider that returns the breaker for the host of each request.
+func (br *BreakerRegistry) Provider() BreakerProvider {
+	return func(req *http.Request) (*breaker.Breaker, error) {
+		return br.Get(req.URL.Host)
+	}
+}
+
+// NewBreakerStateChangeHandler returns a breaker state change handler that triggers
+// a `BreakerEvent` for a given host on a logger.
+func NewBreakerStateChangeHandler(log logger.Triggerable, host string) breaker.OnStateChangeHandler {
+	return func(ctx context.Context, from, to breaker.State, generation int64) {
+		logger.MaybeTriggerContext(ctx, log, NewBreakerEvent(host, from, to, generation))
+	}
+}
@@ -0,0 +1,77 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/blend/go-sdk/breaker"
+	"github.com/blend/go-sdk/logger"
+)
+
+const (
+	// FlagBreaker is a logger event flag for circuit breaker state changes.
+	FlagBreaker = "http.client.breaker"
+)
+
+// NewBreakerEvent returns a new breaker event.
+func NewBreakerEvent(host string, from, to breaker.State, generation int64) BreakerEvent {
+	return BreakerEvent{
+		Host:       host,
+		From:       from,
+		To:         to,
+		Generation: generation,
+	}
+}
+
+// NewBreakerEventListener returns a new r2 breaker event listener.
+func NewBreakerEventListener(listener func(context.Context, BreakerEvent)) logger.Listener {
+	return func(ctx context.Context, e logger.Event) {
+		if typed, isTyped := e.(BreakerEvent); isTyped {
+			listener(ctx, typed)
+		}
+	}
+}
+
+var (
+	_ logger.Event        = (*BreakerEvent)(nil)
+	_ logger.TextWritable = (*BreakerEvent)(nil)
+	_ logger.JSONWritable = (*BreakerEvent)(nil)
+)
+
+// BreakerEvent is a circuit breaker state change for a host.
+type BreakerEvent struct {
+	// Host is the host the breaker applies to.
+	Host string
+	// From is the previous state.
+	From breaker.State
+	// To is the new state.
+	To breaker.State
+	// Generation is the breaker generation after the change.
+	Generation int64
+}
+
+// GetFlag implements logger.Event.
+func (e BreakerEvent) GetFlag() string { return FlagBreaker }
+
+// WriteText writes the event to a text writer.
+func (e BreakerEvent) WriteText(tf logger.TextFormatter, wr io.Writer) {
+	fmt.Fprintf(wr, "%s %s -> %s", e.Host, e.From, e.To)
+}
+
+// Decompose implements logger.JSONWritable.
+func (e BreakerEvent) Decompose() map[string]interface{} {
+	return map[string]interface{}{
+		"host":       e.Host,
+		"from":       e.From.String(),
+		"to":         e.To.String(),
+		"generation": e.Generation,
+	}
+}
@@ -0,0 +1,34 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/breaker"
+	"github.com/blend/go-sdk/logger"
+)
+
+func TestBreakerEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewBreakerEvent("foo.invalid", breaker.StateClosed, breaker.StateOpen, 3)
+	assert.Equal(FlagBreaker, e.GetFlag())
+
+	buffer := new(bytes.Buffer)
+	e.WriteText(logger.NewTextOutputFormatter(logger.OptTextNoColor()), buffer)
+	assert.Equal("foo.invalid closed -> open", buffer.String())
+
+	decomposed := e.Decompose()
+	assert.Equal("foo.invalid", decomposed["host"])
+	assert.Equal("closed", decomposed["from"])
+	assert.Equal("open", decomposed["to"])
+	assert.Equal(3, decomposed["generation"])
+}
@@ -7,7 +7,12 @@ Use of this source code is governed by a MIT license that can be found in the LI
 
 package r2
 
-import "github.com/blend/go-sdk/webutil"
+import (
+	"net/http"
+	"time"
+
+	"github.com/blend/go-sdk/webutil"
+)
 
 const (
 	// TestURL can be used in tests for the URL passed to r2.New(...)
@@ -58,3 +63,24 @@ const (
 	// ContentTypeApplicationOctetStream is a content type header value.
 	ContentTypeApplicationOctetStream = webutil.ContentTypeApplicationOctetStream
 )
+
+const (
+	// DefaultRetryMaxAttempts is the default maximum number of attempts, including the first, for retried requests.
+	DefaultRetryMaxAttempts uint = 3
+	// DefaultRetryBaseDelay is the default base delay for the retry backoff.
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	// DefaultRetryMaxDelay is the default maximum delay a `Retry-After` header can specify; retries that would wait longer are not made.
+	DefaultRetryMaxDelay = 30 * time.Second
+	// DefaultHTTPCacheValidatorTTL is the default time a stale response with a validator is kept in an http cache.
+	DefaultHTTPCacheValidatorTTL = 24 * time.Hour
+)
+
+var (
+	// DefaultRetryStatusCodes are the default response status codes that are retried.
+	DefaultRetryStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
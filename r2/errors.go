@@ -30,8 +30,22 @@ const (
 	// ErrInvalidMethod is an error that is returned from `r2.Request.Do()` if a method
 	// is specified on the request that violates the valid charset for HTTP methods.
 	ErrInvalidMethod ex.Class = "r2; invalid http method"
+	// ErrBreakerOpen is an error that is returned from `r2.Request.Do()` if the request's
+	// circuit breaker did not allow it to be sent.
+	ErrBreakerOpen ex.Class = "r2; circuit breaker is open"
+	// ErrUnexpectedStatus is an error that is returned from `r2.Request.Do()` if the response
+	// status code is not one of the expected status codes.
+	ErrUnexpectedStatus ex.Class = "r2; unexpected response status code"
+	// ErrMultipartBodyNotRewindable is an error that is returned from a multipart body's `GetBody`
+	// if one of its parts was read from a reader that cannot be read again from the start.
+	ErrMultipartBodyNotRewindable ex.Class = "r2; multipart body cannot be sent again, a part reader is not an io.Seeker"
 )
 
+// ErrIsBreakerOpen returns if the error is a circuit breaker open error.
+func ErrIsBreakerOpen(err error) bool {
+	return ex.Is(err, ErrBreakerOpen)
+}
+
 // ErrIsTooManyRedirects returns if the error is too many redirects.
 func ErrIsTooManyRedirects(err error) bool {
 	if ex.Is(err, http.ErrUseLastResponse) {
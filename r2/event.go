@@ -74,6 +74,10 @@ type Event struct {
 	Body []byte
 	// Elapsed is the time elapsed.
 	Elapsed time.Duration
+	// Attempt is the attempt number for retried requests, it is 0 for requests that are not retried.
+	Attempt uint
+	// CacheStatus is the `X-Cache` status of responses from an http cache, e.g. `HIT` or `MISS`.
+	CacheStatus string
 }
 
 // GetFlag implements logger.Event.
@@ -86,6 +90,12 @@ func (e Event) WriteText(tf logger.TextFormatter, wr io.Writer) {
 	} else if e.Request != nil {
 		fmt.Fprintf(wr, "%s %s", e.Request.Method, e.Request.URL.String())
 	}
+	if e.Attempt > 0 {
+		fmt.Fprintf(wr, " (attempt %d)", e.Attempt)
+	}
+	if e.CacheStatus != "" {
+		fmt.Fprintf(wr, " (cache %s)", e.CacheStatus)
+	}
 	if e.Body != nil {
 		fmt.Fprint(wr, logger.Newline)
 		fmt.Fprint(wr, string(e.Body))
@@ -120,6 +130,12 @@ func (e Event) Decompose() map[string]interface{} {
 	if e.Body != nil {
 		output["body"] = string(e.Body)
 	}
+	if e.Attempt > 0 {
+		output["attempt"] = e.Attempt
+	}
+	if e.CacheStatus != "" {
+		output["cacheStatus"] = e.CacheStatus
+	}
 
 	return output
 }
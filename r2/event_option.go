@@ -10,6 +10,8 @@ package r2
 import (
 	"net/http"
 	"time"
+
+	"github.com/blend/go-sdk/webutil"
 )
 
 // EventOption is an event option.
@@ -22,10 +24,13 @@ func OptEventRequest(req *http.Request) EventOption {
 	}
 }
 
-// OptEventResponse sets the response.
+// OptEventResponse sets the response, and the cache status if the response is from an http cache.
 func OptEventResponse(res *http.Response) EventOption {
 	return func(e *Event) {
 		e.Response = res
+		if res != nil && res.Header != nil {
+			e.CacheStatus = res.Header.Get(webutil.HeaderXCache)
+		}
 	}
 }
 
@@ -42,3 +47,10 @@ func OptEventBody(body []byte) EventOption {
 		e.Body = body
 	}
 }
+
+// OptEventAttempt sets the attempt number.
+func OptEventAttempt(attempt uint) EventOption {
+	return func(e *Event) {
+		e.Attempt = attempt
+	}
+}
@@ -33,4 +33,8 @@ func TestEventOptions(t *testing.T) {
 	OptEventBody([]byte(`example-string`))(&e)
 	assert.NotNil(e.Body)
 	assert.Equal("example-string", string(e.Body))
+
+	assert.Zero(e.Attempt)
+	OptEventAttempt(2)(&e)
+	assert.Equal(2, e.Attempt)
 }
@@ -0,0 +1,399 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// Cache status values set on the `X-Cache` header of responses returned by an `HTTPCache`.
+const (
+	CacheStatusHit         = "HIT"
+	CacheStatusMiss        = "MISS"
+	CacheStatusRevalidated = "REVALIDATED"
+)
+
+var (
+	_ http.RoundTripper = (*HTTPCache)(nil)
+)
+
+// NewHTTPCache returns a new http cache.
+//
+// By default it stores responses in a `cache.LocalCache`.
+func NewHTTPCache(options ...HTTPCacheOption) *HTTPCache {
+	hc := HTTPCache{
+		Store: cache.New(),
+	}
+	for _, option := range options {
+		option(&hc)
+	}
+	return &hc
+}
+
+// HTTPCacheOption mutates an http cache.
+type HTTPCacheOption func(*HTTPCache)
+
+// OptHTTPCacheStore sets the store for cached responses.
+func OptHTTPCacheStore(store cache.Cache) HTTPCacheOption {
+	return func(hc *HTTPCache) { hc.Store = store }
+}
+
+// OptHTTPCacheValidatorTTL sets how long responses with a validator are kept for revalidation after they were received.
+func OptHTTPCacheValidatorTTL(ttl time.Duration) HTTPCacheOption {
+	return func(hc *HTTPCache) { hc.ValidatorTTL = ttl }
+}
+
+// OptHTTPCacheTransport sets the transport used when the http cache is used as a round tripper.
+func OptHTTPCacheTransport(transport http.RoundTripper) HTTPCacheOption {
+	return func(hc *HTTPCache) { hc.Transport = transport }
+}
+
+// HTTPCache is a private http cache per RFC 7234.
+//
+// It stores responses to `GET` requests and serves them while they are fresh according to their
+// `Cache-Control` or `Expires` headers. Stale responses with an `ETag` or `Last-Modified` validator
+// are revalidated with a conditional request, and are kept for at least the validator ttl. Responses are
+// matched on the request headers named by their `Vary` header. Responses to requests with an `Authorization`
+// or `Cookie` header are only stored if they are marked `public`. Successful requests with an unsafe method invalidate the stored response
+// for their url.
+//
+// Responses it returns have an `X-Cache` header set to one of `HIT`, `MISS` or `REVALIDATED`.
+//
+// It can be used as a round tripper directly, or set on an r2 request with `OptHTTPCache`.
+type HTTPCache struct {
+	// Store holds the cached responses.
+	Store cache.Cache
+	// Transport is the transport used when the cache is used as a round tripper.
+	// If unset, `http.DefaultTransport` is used.
+	Transport http.RoundTripper
+	// ValidatorTTL is how long responses with a validator are kept for revalidation after they were received.
+	// If unset, `DefaultHTTPCacheValidatorTTL` is used.
+	ValidatorTTL time.Duration
+}
+
+// ValidatorTTLOrDefault returns the validator ttl or a default.
+func (hc *HTTPCache) ValidatorTTLOrDefault() time.Duration {
+	if hc.ValidatorTTL > 0 {
+		return hc.ValidatorTTL
+	}
+	return DefaultHTTPCacheValidatorTTL
+}
+
+// RoundTrip implements http.RoundTripper.
+func (hc *HTTPCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := hc.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return hc.Do(req, transport.RoundTrip)
+}
+
+// Do sends a request with a given send function, serving it from the cache if possible.
+func (hc *HTTPCache) Do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := hc.Key(req)
+	if req.Method != http.MethodGet {
+		res, err := send(req)
+		if err == nil && !isSafeMethod(req.Method) && res.StatusCode < http.StatusBadRequest {
+			hc.Store.Remove(key)
+		}
+		return res, err
+	}
+
+	reqCacheControl := webutil.ParseCacheControl(req.Header)
+	if reqCacheControl.Has("no-store") {
+		return send(req)
+	}
+
+	cached, ok := hc.get(key, req)
+	if !ok {
+		return hc.fetch(key, req, send, CacheStatusMiss)
+	}
+
+	now := time.Now().UTC()
+	if cached.IsFresh(now, reqCacheControl) {
+		return cached.Response(req, now, CacheStatusHit), nil
+	}
+	if !cached.HasValidator() {
+		return hc.fetch(key, req, send, CacheStatusMiss)
+	}
+
+	conditional := req.Clone(req.Context())
+	if etag := cached.Header.Get(webutil.HeaderETag); etag != "" {
+		conditional.Header.Set(webutil.HeaderIfNoneMatch, etag)
+	}
+	if lastModified := cached.Header.Get(webutil.HeaderLastModified); lastModified != "" {
+		conditional.Header.Set(webutil.HeaderIfModifiedSince, lastModified)
+	}
+	requested := time.Now().UTC()
+	res, err := send(conditional)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusNotModified {
+		return hc.store(key, req, res, requested, CacheStatusMiss)
+	}
+	_ = res.Body.Close()
+
+	revalidated := *cached
+	revalidated.Header = cached.Header.Clone()
+	for header, values := range res.Header {
+		revalidated.Header[header] = values
+	}
+	revalidated.RequestTime = requested
+	revalidated.ResponseTime = time.Now().UTC()
+	hc.set(key, &revalidated)
+	return revalidated.Response(req, revalidated.ResponseTime, CacheStatusRevalidated), nil
+}
+
+// Key returns the cache key for a request, which is the request url.
+func (hc *HTTPCache) Key(req *http.Request) string {
+	return req.URL.String()
+}
+
+func (hc *HTTPCache) get(key string, req *http.Request) (*CachedHTTPResponse, bool) {
+	value, ok := hc.Store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	cached, ok := value.(*CachedHTTPResponse)
+	if !ok || !cached.MatchesVary(req) {
+		return nil, false
+	}
+	return cached, true
+}
+
+func (hc *HTTPCache) fetch(key string, req *http.Request, send func(*http.Request) (*http.Response, error), status string) (*http.Response, error) {
+	requested := time.Now().UTC()
+	res, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+	return hc.store(key, req, res, requested, status)
+}
+
+// store reads a response into the cache if it is storable, and returns a response with the read body.
+func (hc *HTTPCache) store(key string, req *http.Request, res *http.Response, requested time.Time, status string) (*http.Response, error) {
+	if res.Header == nil {
+		res.Header = make(http.Header)
+	}
+	res.Header.Set(webutil.HeaderXCache, status)
+	if !isStorable(req, res) {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	cached := &CachedHTTPResponse{
+		StatusCode:   res.StatusCode,
+		Proto:        res.Proto,
+		ProtoMajor:   res.ProtoMajor,
+		ProtoMinor:   res.ProtoMinor,
+		Header:       res.Header.Clone(),
+		Body:         body,
+		VaryHeader:   make(http.Header),
+		RequestTime:  requested,
+		ResponseTime: time.Now().UTC(),
+	}
+	cached.Header.Del(webutil.HeaderXCache)
+	for _, header := range varyHeaders(res.Header) {
+		cached.VaryHeader[header] = req.Header.Values(header)
+	}
+	hc.set(key, cached)
+	return res, nil
+}
+
+func (hc *HTTPCache) set(key string, cached *CachedHTTPResponse) {
+	ttl := cached.FreshnessLifetime()
+	if cached.HasValidator() {
+		if validatorTTL := hc.ValidatorTTLOrDefault(); validatorTTL > ttl {
+			ttl = validatorTTL
+		}
+	}
+	hc.Store.Set(key, cached, cache.OptValueExpires(cached.ResponseTime.Add(ttl)))
+}
+
+// CachedHTTPResponse is a response held by an `HTTPCache`.
+type CachedHTTPResponse struct {
+	StatusCode int
+	Proto      string
+	ProtoMajor int
+	ProtoMinor int
+	Header     http.Header
+	Body       []byte
+	// VaryHeader holds the values of the request headers named by the response `Vary` header.
+	VaryHeader http.Header
+	// RequestTime is when the request that produced the response was sent.
+	RequestTime time.Time
+	// ResponseTime is when the response was received.
+	ResponseTime time.Time
+}
+
+// MatchesVary returns if a request has the same values as the stored request for the headers named by `Vary`.
+func (chr CachedHTTPResponse) MatchesVary(req *http.Request) bool {
+	for header, values := range chr.VaryHeader {
+		if strings.Join(req.Header.Values(header), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// HasValidator returns if the response has an `ETag` or `Last-Modified` header to revalidate it with.
+func (chr CachedHTTPResponse) HasValidator() bool {
+	return chr.Header.Get(webutil.HeaderETag) != "" || chr.Header.Get(webutil.HeaderLastModified) != ""
+}
+
+// FreshnessLifetime returns how long the response is fresh for after it was generated.
+//
+// It uses `Cache-Control: max-age`, then `Expires`, then a heuristic of 10% of the time since
+// `Last-Modified`, per RFC 7234 section 4.2.
+func (chr CachedHTTPResponse) FreshnessLifetime() time.Duration {
+	cacheControl := webutil.ParseCacheControl(chr.Header)
+	if cacheControl.Has("no-cache") {
+		return 0
+	}
+	if maxAge, ok := cacheControl.Duration("max-age"); ok {
+		return maxAge
+	}
+	date := chr.date()
+	if expires := chr.Header.Get(webutil.HeaderExpires); expires != "" {
+		ts, err := http.ParseTime(expires)
+		if err != nil || !ts.After(date) {
+			return 0
+		}
+		return ts.Sub(date)
+	}
+	if lastModified := chr.Header.Get(webutil.HeaderLastModified); lastModified != "" {
+		if ts, err := http.ParseTime(lastModified); err == nil && ts.Before(date) {
+			return date.Sub(ts) / 10
+		}
+	}
+	return 0
+}
+
+// Age returns the current age of the response, per RFC 7234 section 4.2.3.
+func (chr CachedHTTPResponse) Age(now time.Time) time.Duration {
+	apparentAge := chr.ResponseTime.Sub(chr.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	var ageValue time.Duration
+	if age, err := strconv.ParseInt(chr.Header.Get(webutil.HeaderAge), 10, 64); err == nil && age > 0 {
+		ageValue = time.Duration(age) * time.Second
+	}
+	correctedAgeValue := ageValue + chr.ResponseTime.Sub(chr.RequestTime)
+	if correctedAgeValue > apparentAge {
+		apparentAge = correctedAgeValue
+	}
+	return apparentAge + now.Sub(chr.ResponseTime)
+}
+
+// IsFresh returns if the response can be served without revalidation for a request with given `Cache-Control` directives.
+func (chr CachedHTTPResponse) IsFresh(now time.Time, requestCacheControl webutil.CacheControl) bool {
+	if requestCacheControl.Has("no-cache") {
+		return false
+	}
+	age := chr.Age(now)
+	if maxAge, ok := requestCacheControl.Duration("max-age"); ok && age > maxAge {
+		return false
+	}
+	freshness := chr.FreshnessLifetime()
+	if minFresh, ok := requestCacheControl.Duration("min-fresh"); ok {
+		freshness -= minFresh
+	}
+	return age < freshness
+}
+
+// Response returns the cached response as an http response for a given request.
+func (chr CachedHTTPResponse) Response(req *http.Request, now time.Time, status string) *http.Response {
+	header := chr.Header.Clone()
+	header.Set(webutil.HeaderAge, strconv.FormatInt(int64(chr.Age(now)/time.Second), 10))
+	header.Set(webutil.HeaderXCache, status)
+	return &http.Response{
+		Status:        strconv.Itoa(chr.StatusCode) + " " + http.StatusText(chr.StatusCode),
+		StatusCode:    chr.StatusCode,
+		Proto:         chr.Proto,
+		ProtoMajor:    chr.ProtoMajor,
+		ProtoMinor:    chr.ProtoMinor,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(chr.Body)),
+		ContentLength: int64(len(chr.Body)),
+		Request:       req,
+	}
+}
+
+func (chr CachedHTTPResponse) date() time.Time {
+	if date, err := http.ParseTime(chr.Header.Get(webutil.HeaderDate)); err == nil {
+		return date
+	}
+	return chr.ResponseTime
+}
+
+// isStorable returns if a response to a request can be stored, per RFC 7234 section 3.
+func isStorable(req *http.Request, res *http.Response) bool {
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusNotFound,
+		http.StatusMethodNotAllowed, http.StatusGone, http.StatusRequestURITooLong, http.StatusNotImplemented:
+	default:
+		return false
+	}
+	cacheControl := webutil.ParseCacheControl(res.Header)
+	if cacheControl.Has("no-store") {
+		return false
+	}
+	if isCredentialed(req) && !cacheControl.Has("public") {
+		return false
+	}
+	for _, header := range varyHeaders(res.Header) {
+		if header == "*" {
+			return false
+		}
+	}
+	cached := CachedHTTPResponse{Header: res.Header, ResponseTime: time.Now().UTC()}
+	return cached.HasValidator() || cached.FreshnessLifetime() > 0
+}
+
+// isCredentialed returns if a request carries credentials whose responses should not be shared.
+func isCredentialed(req *http.Request) bool {
+	return req.Header.Get(webutil.HeaderAuthorization) != "" || req.Header.Get(webutil.HeaderCookie) != ""
+}
+
+func varyHeaders(header http.Header) (output []string) {
+	for _, value := range header.Values(webutil.HeaderVary) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				output = append(output, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,289 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func httpCacheGet(assert *assert.Assertions, client *http.Client, url string, header http.Header) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Nil(err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := client.Do(req)
+	assert.Nil(err)
+	defer res.Body.Close()
+	contents, err := ioutil.ReadAll(res.Body)
+	assert.Nil(err)
+	return res, string(contents)
+}
+
+func TestHTTPCacheMaxAge(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Header().Set(webutil.HeaderCacheControl, "max-age=60")
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPCache()}
+	res, body := httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal("OK!", body)
+
+	res, body = httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusHit, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("OK!", body)
+	assert.NotEmpty(res.Header.Get(webutil.HeaderAge))
+	assert.Equal(1, atomic.LoadInt32(&requests))
+
+	res, _ = httpCacheGet(assert, client, server.URL, http.Header{webutil.HeaderCacheControl: {"no-cache"}})
+	assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal(2, atomic.LoadInt32(&requests))
+}
+
+func TestHTTPCacheNoStore(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Header().Set(webutil.HeaderCacheControl, "no-store, max-age=60")
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPCache()}
+	for x := 0; x < 2; x++ {
+		res, body := httpCacheGet(assert, client, server.URL, nil)
+		assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+		assert.Equal("OK!", body)
+	}
+	assert.Equal(2, atomic.LoadInt32(&requests))
+}
+
+func TestHTTPCacheRevalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Header().Set(webutil.HeaderCacheControl, "no-cache")
+		rw.Header().Set(webutil.HeaderETag, `"v1"`)
+		if req.Header.Get(webutil.HeaderIfNoneMatch) == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPCache()}
+	res, body := httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal("OK!", body)
+
+	res, body = httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusRevalidated, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("OK!", body)
+	assert.Equal(2, atomic.LoadInt32(&requests))
+	assert.Equal(1, atomic.LoadInt32(&notModified))
+}
+
+func TestHTTPCacheValidatorTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set(webutil.HeaderCacheControl, "no-cache")
+		rw.Header().Set(webutil.HeaderETag, `"v1"`)
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	assert.Equal(DefaultHTTPCacheValidatorTTL, NewHTTPCache().ValidatorTTLOrDefault())
+
+	client := &http.Client{Transport: NewHTTPCache(OptHTTPCacheValidatorTTL(time.Millisecond))}
+	res, _ := httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+
+	time.Sleep(5 * time.Millisecond)
+	res, _ = httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+}
+
+func TestHTTPCacheCredentialed(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if req.URL.Path == "/public" {
+			rw.Header().Set(webutil.HeaderCacheControl, "public, max-age=60")
+		} else {
+			rw.Header().Set(webutil.HeaderCacheControl, "max-age=60")
+		}
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPCache()}
+	for _, header := range []string{webutil.HeaderAuthorization, webutil.HeaderCookie} {
+		atomic.StoreInt32(&requests, 0)
+		credentials := http.Header{header: {"secret"}}
+		for x := 0; x < 2; x++ {
+			res, body := httpCacheGet(assert, client, server.URL+"/private", credentials)
+			assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+			assert.Equal("OK!", body)
+		}
+		assert.Equal(2, atomic.LoadInt32(&requests))
+	}
+
+	httpCacheGet(assert, client, server.URL+"/public", http.Header{webutil.HeaderAuthorization: {"secret"}})
+	res, _ := httpCacheGet(assert, client, server.URL+"/public", http.Header{webutil.HeaderAuthorization: {"secret"}})
+	assert.Equal(CacheStatusHit, res.Header.Get(webutil.HeaderXCache))
+}
+
+func TestHTTPCacheLastModified(t *testing.T) {
+	assert := assert.New(t)
+
+	lastModified := time.Now().UTC().Add(-time.Hour).Format(http.TimeFormat)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Header().Set(webutil.HeaderCacheControl, "max-age=0")
+		rw.Header().Set(webutil.HeaderLastModified, lastModified)
+		if req.Header.Get(webutil.HeaderIfModifiedSince) == lastModified {
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPCache()}
+	_, _ = httpCacheGet(assert, client, server.URL, nil)
+	res, body := httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusRevalidated, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal("OK!", body)
+}
+
+func TestHTTPCacheVary(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Header().Set(webutil.HeaderCacheControl, "max-age=60")
+		rw.Header().Set(webutil.HeaderVary, "Accept")
+		_, _ = rw.Write([]byte(req.Header.Get(webutil.HeaderAccept)))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewHTTPCache()}
+	_, body := httpCacheGet(assert, client, server.URL, http.Header{webutil.HeaderAccept: {"text/plain"}})
+	assert.Equal("text/plain", body)
+
+	res, body := httpCacheGet(assert, client, server.URL, http.Header{webutil.HeaderAccept: {"text/plain"}})
+	assert.Equal(CacheStatusHit, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal("text/plain", body)
+
+	res, body = httpCacheGet(assert, client, server.URL, http.Header{webutil.HeaderAccept: {"application/json"}})
+	assert.Equal(CacheStatusMiss, res.Header.Get(webutil.HeaderXCache))
+	assert.Equal("application/json", body)
+	assert.Equal(2, atomic.LoadInt32(&requests))
+}
+
+func TestHTTPCacheExpires(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set(webutil.HeaderExpires, time.Now().UTC().Add(time.Hour).Format(http.TimeFormat))
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	store := cache.New()
+	client := &http.Client{Transport: NewHTTPCache(OptHTTPCacheStore(store))}
+	_, _ = httpCacheGet(assert, client, server.URL, nil)
+	assert.True(store.Has(server.URL))
+	res, _ := httpCacheGet(assert, client, server.URL, nil)
+	assert.Equal(CacheStatusHit, res.Header.Get(webutil.HeaderXCache))
+}
+
+func TestHTTPCacheInvalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set(webutil.HeaderCacheControl, "max-age=60")
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	hc := NewHTTPCache()
+	client := &http.Client{Transport: hc}
+	_, _ = httpCacheGet(assert, client, server.URL, nil)
+	assert.True(hc.Store.Has(server.URL))
+
+	res, err := client.Post(server.URL, ContentTypeApplicationJSON, nil)
+	assert.Nil(err)
+	_ = res.Body.Close()
+	assert.False(hc.Store.Has(server.URL))
+}
+
+func TestCachedHTTPResponseFreshnessLifetime(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 06, 01, 12, 00, 00, 00, time.UTC)
+	header := http.Header{}
+	header.Set(webutil.HeaderDate, now.Format(http.TimeFormat))
+
+	chr := CachedHTTPResponse{Header: header.Clone(), ResponseTime: now}
+	assert.Zero(chr.FreshnessLifetime())
+
+	chr.Header.Set(webutil.HeaderLastModified, now.Add(-10*time.Hour).Format(http.TimeFormat))
+	assert.Equal(time.Hour, chr.FreshnessLifetime())
+
+	chr.Header.Set(webutil.HeaderExpires, now.Add(2*time.Hour).Format(http.TimeFormat))
+	assert.Equal(2*time.Hour, chr.FreshnessLifetime())
+
+	chr.Header.Set(webutil.HeaderCacheControl, "max-age=30")
+	assert.Equal(30*time.Second, chr.FreshnessLifetime())
+
+	chr.Header.Set(webutil.HeaderCacheControl, "no-cache, max-age=30")
+	assert.Zero(chr.FreshnessLifetime())
+}
+
+func TestCachedHTTPResponseAge(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 06, 01, 12, 00, 00, 00, time.UTC)
+	header := http.Header{}
+	header.Set(webutil.HeaderDate, now.Format(http.TimeFormat))
+	header.Set(webutil.HeaderAge, "10")
+
+	chr := CachedHTTPResponse{Header: header, RequestTime: now, ResponseTime: now.Add(time.Second)}
+	assert.Equal(11*time.Second, chr.Age(now.Add(time.Second)))
+	assert.Equal(21*time.Second, chr.Age(now.Add(11*time.Second)))
+}
@@ -0,0 +1,53 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/breaker"
+)
+
+// BreakerOption mutates breaker options.
+type BreakerOption func(*BreakerOptions)
+
+// OptBreaker sets the request to be sent through a circuit breaker.
+//
+// Failures are classified with `DefaultBreakerClassifier` unless a classifier is set.
+// If the request is also retried, each attempt is sent through the breaker.
+//
+// The breaker is owned by the caller, so its state changes are not reported; to trigger `BreakerEvent`s
+// for them create it with `breaker.OptOnStateChange(NewBreakerStateChangeHandler(log, host))`,
+// or use `OptBreakerRegistry` with a registry log.
+func OptBreaker(b *breaker.Breaker, options ...BreakerOption) Option {
+	return optBreaker(func(_ *http.Request) (*breaker.Breaker, error) { return b, nil }, options...)
+}
+
+// OptBreakerRegistry sets the request to be sent through the circuit breaker for its host.
+//
+// If the registry has a log, breaker state changes are triggered on it as `BreakerEvent`s.
+func OptBreakerRegistry(registry *BreakerRegistry, options ...BreakerOption) Option {
+	return optBreaker(registry.Provider(), options...)
+}
+
+// OptBreakerClassifier sets the classifier that determines if an attempt counts as a failure.
+func OptBreakerClassifier(classifier BreakerClassifier) BreakerOption {
+	return func(bo *BreakerOptions) { bo.Classifier = classifier }
+}
+
+func optBreaker(provider BreakerProvider, options ...BreakerOption) Option {
+	return func(r *Request) error {
+		r.Breaker = &BreakerOptions{
+			Provider: provider,
+		}
+		for _, option := range options {
+			option(r.Breaker)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,229 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/breaker"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/retry"
+)
+
+func TestOptBreaker(t *testing.T) {
+	assert := assert.New(t)
+
+	b := breaker.MustNew()
+	r := New(TestURL, OptBreaker(b))
+	assert.NotNil(r.Breaker)
+	assert.Nil(r.Breaker.Classifier)
+	provided, err := r.Breaker.Provider(r.Request)
+	assert.Nil(err)
+	assert.True(b == provided)
+
+	r = New(TestURL, OptBreaker(b, OptBreakerClassifier(func(_ *http.Response, _ error) bool { return true })))
+	assert.NotNil(r.Breaker.Classifier)
+}
+
+func TestOptBreakerOpens(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	b := breaker.MustNew(breaker.OptShouldOpenProvider(func(_ context.Context, counts breaker.Counts) bool {
+		return counts.ConsecutiveFailures >= 2
+	}))
+
+	for x := 0; x < 2; x++ {
+		res, err := New(server.URL, OptBreaker(b)).Discard()
+		assert.Nil(err)
+		assert.Equal(http.StatusInternalServerError, res.StatusCode)
+	}
+	assert.Equal(breaker.StateOpen, b.EvaluateState(context.Background()))
+
+	_, err := New(server.URL, OptBreaker(b)).Discard()
+	assert.True(ErrIsBreakerOpen(err))
+	assert.True(breaker.ErrIsOpen(ex.ErrInner(err)))
+	assert.Equal(2, atomic.LoadInt32(&requests))
+}
+
+func TestOptBreakerClassifier(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	b := breaker.MustNew(breaker.OptShouldOpenProvider(func(_ context.Context, counts breaker.Counts) bool {
+		return counts.ConsecutiveFailures >= 1
+	}))
+	for x := 0; x < 3; x++ {
+		res, err := New(server.URL,
+			OptBreaker(b, OptBreakerClassifier(func(_ *http.Response, err error) bool { return err != nil })),
+		).Discard()
+		assert.Nil(err)
+		assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	}
+	assert.Equal(breaker.StateClosed, b.EvaluateState(context.Background()))
+}
+
+func TestOptBreakerOpenAction(t *testing.T) {
+	assert := assert.New(t)
+
+	b := breaker.MustNew(breaker.OptOpenAction(func(_ context.Context) (interface{}, error) {
+		return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+	}))
+	for x := 0; x <= breaker.DefaultConsecutiveFailures; x++ {
+		_, _ = b.Do(context.Background(), func(_ context.Context) (interface{}, error) {
+			return nil, fmt.Errorf("failure")
+		})
+	}
+	assert.Equal(breaker.StateOpen, b.EvaluateState(context.Background()))
+
+	res, err := New(TestURL, OptBreaker(b)).Do()
+	assert.Nil(err)
+	assert.Equal(http.StatusTeapot, res.StatusCode)
+}
+
+func TestOptBreakerWithRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	b := breaker.MustNew(breaker.OptShouldOpenProvider(func(_ context.Context, counts breaker.Counts) bool {
+		return counts.ConsecutiveFailures >= 2
+	}))
+	_, err := New(server.URL,
+		OptBreaker(b),
+		OptRetry(OptRetryMaxAttempts(5), OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond))),
+	).Discard()
+	assert.True(ErrIsBreakerOpen(err))
+	assert.Equal(2, atomic.LoadInt32(&requests))
+}
+
+func TestBreakerRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	log := logger.None()
+	registry := NewBreakerRegistry(
+		OptBreakerRegistryBreakerOptions(breaker.OptHalfOpenMaxActions(5)),
+		OptBreakerRegistryLog(log),
+	)
+	assert.Len(registry.BreakerOptions, 1)
+	assert.NotNil(registry.Log)
+
+	foo, err := registry.Get("foo.invalid")
+	assert.Nil(err)
+	assert.Equal(5, foo.HalfOpenMaxActions)
+	assert.NotNil(foo.OnStateChange)
+
+	fooAgain, err := registry.Get("foo.invalid")
+	assert.Nil(err)
+	assert.True(foo == fooAgain)
+
+	bar, err := registry.Get("bar.invalid")
+	assert.Nil(err)
+	assert.False(foo == bar)
+	assert.Len(registry.Breakers, 2)
+
+	r := New(TestURL, OptBreakerRegistry(registry))
+	provided, err := r.Breaker.Provider(r.Request)
+	assert.Nil(err)
+	assert.True(provided == registry.Breakers[r.Request.URL.Host])
+}
+
+func TestBreakerRegistryStateChangeEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	events := make(chan BreakerEvent, 1)
+	log := logger.MustNew(logger.OptOutput(ioutil.Discard), logger.OptAll())
+	defer log.Close()
+	log.Listen(FlagBreaker, "test", NewBreakerEventListener(func(_ context.Context, e BreakerEvent) {
+		events <- e
+	}))
+
+	registry := NewBreakerRegistry(
+		OptBreakerRegistryLog(log),
+		OptBreakerRegistryBreakerOptions(breaker.OptShouldOpenProvider(func(_ context.Context, counts breaker.Counts) bool {
+			return counts.ConsecutiveFailures >= 1
+		})),
+	)
+	_, err := New(server.URL, OptBreakerRegistry(registry)).Discard()
+	assert.Nil(err)
+
+	e := <-events
+	assert.NotEmpty(e.Host)
+	assert.Equal(breaker.StateClosed, e.From)
+	assert.Equal(breaker.StateOpen, e.To)
+}
+
+func TestOptBreakerStateChangeEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	events := make(chan BreakerEvent, 1)
+	log := logger.MustNew(logger.OptOutput(ioutil.Discard), logger.OptAll())
+	defer log.Close()
+	log.Listen(FlagBreaker, "test", NewBreakerEventListener(func(_ context.Context, e BreakerEvent) {
+		events <- e
+	}))
+
+	b := breaker.MustNew(
+		breaker.OptOnStateChange(NewBreakerStateChangeHandler(log, "upstream")),
+		breaker.OptShouldOpenProvider(func(_ context.Context, counts breaker.Counts) bool {
+			return counts.ConsecutiveFailures >= 1
+		}),
+	)
+	_, err := New(server.URL, OptBreaker(b)).Discard()
+	assert.Nil(err)
+
+	e := <-events
+	assert.Equal("upstream", e.Host)
+	assert.Equal(breaker.StateClosed, e.From)
+	assert.Equal(breaker.StateOpen, e.To)
+}
+
+func TestDefaultBreakerClassifier(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(DefaultBreakerClassifier(nil, fmt.Errorf("network error")))
+	assert.True(DefaultBreakerClassifier(nil, context.DeadlineExceeded))
+	assert.False(DefaultBreakerClassifier(nil, context.Canceled))
+	assert.True(DefaultBreakerClassifier(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil))
+	assert.False(DefaultBreakerClassifier(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	assert.False(DefaultBreakerClassifier(&http.Response{StatusCode: http.StatusOK}, nil))
+}
@@ -0,0 +1,19 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+// OptErrorJSON sets an object that the bodies of unexpected responses are decoded into as json.
+//
+// If no status codes are expected with `OptExpectStatus`, any non-2xx response is unexpected.
+// The decoded object is set as the `Payload` of the `ResponseError` returned by `ErrResponse`.
+func OptErrorJSON(dst interface{}) Option {
+	return func(r *Request) error {
+		r.ErrorJSON = dst
+		return nil
+	}
+}
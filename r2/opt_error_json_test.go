@@ -0,0 +1,50 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestOptErrorJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/ok" {
+			rw.WriteHeader(http.StatusCreated)
+			fmt.Fprint(rw, `{"id":"foo"}`)
+			return
+		}
+		rw.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(rw, `{"code":"invalid","message":"name is required"}`)
+	}))
+	defer server.Close()
+
+	type apiError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	var apiErr apiError
+	var output map[string]interface{}
+	_, err := New(server.URL, OptErrorJSON(&apiErr)).JSON(&output)
+	assert.NotNil(err)
+	assert.Equal(http.StatusBadRequest, ErrStatusCode(err))
+	assert.Equal("invalid", apiErr.Code)
+	assert.Equal("name is required", apiErr.Message)
+	assert.True(ErrResponse(err).Payload == &apiErr)
+
+	_, err = New(server.URL, OptPath("/ok"), OptErrorJSON(&apiErr)).JSON(&output)
+	assert.Nil(err)
+	assert.Equal("foo", output["id"])
+}
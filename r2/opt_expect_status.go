@@ -0,0 +1,19 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+// OptExpectStatus sets the response status codes that are expected.
+//
+// Responses with any other status code are closed and returned as `ErrUnexpectedStatus` errors,
+// whose details can be read with `ErrResponse` and `ErrStatusCode`.
+func OptExpectStatus(statusCodes ...int) Option {
+	return func(r *Request) error {
+		r.ExpectStatus = statusCodes
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestOptExpectStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(TestURL, OptExpectStatus(http.StatusOK, http.StatusCreated))
+	assert.Equal([]int{http.StatusOK, http.StatusCreated}, r.ExpectStatus)
+}
+
+func TestOptExpectStatusUnexpected(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Request-ID", "abc123")
+		rw.WriteHeader(http.StatusConflict)
+		fmt.Fprint(rw, strings.Repeat("a", 2*ResponseErrorBodyTruncateSize))
+	}))
+	defer server.Close()
+
+	var output map[string]interface{}
+	res, err := New(server.URL, OptExpectStatus(http.StatusOK, http.StatusCreated)).JSON(&output)
+	assert.Nil(res)
+	assert.NotNil(err)
+	assert.True(ex.Is(err, ErrUnexpectedStatus))
+	assert.Equal(http.StatusConflict, ErrStatusCode(err))
+
+	re := ErrResponse(err)
+	assert.NotNil(re)
+	assert.Equal("abc123", re.Header.Get("X-Request-ID"))
+	assert.Len(re.Body, ResponseErrorBodyTruncateSize)
+	assert.Nil(re.Payload)
+
+	_, _, err = New(server.URL, OptExpectStatus(http.StatusConflict)).Bytes()
+	assert.Nil(err)
+}
+
+func TestErrStatusCode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(ErrStatusCode(nil))
+	assert.Zero(ErrStatusCode(fmt.Errorf("not a response error")))
+	assert.Zero(ErrStatusCode(ex.New(ErrUnexpectedStatus)))
+	assert.Nil(ErrResponse(ex.New(ErrInvalidMethod)))
+	assert.Equal(http.StatusTeapot, ErrStatusCode(ex.New(ErrUnexpectedStatus, ex.OptInnerClass(&ResponseError{StatusCode: http.StatusTeapot}))))
+}
@@ -0,0 +1,58 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// OptGzipBody compresses the body of the request with gzip and sets the `Content-Encoding` header.
+//
+// It must be given after the option that sets the body. The body is compressed as it is sent.
+func OptGzipBody() Option {
+	return func(r *Request) error {
+		if r.Request == nil {
+			return ex.New(ErrRequestUnset)
+		}
+		if r.Request.Body == nil || r.Request.Body == http.NoBody {
+			return nil
+		}
+		r.Request.Body = gzipBody(r.Request.Body)
+		if getBody := r.Request.GetBody; getBody != nil {
+			r.Request.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return gzipBody(body), nil
+			}
+		}
+		r.Request.ContentLength = 0
+		if r.Request.Header == nil {
+			r.Request.Header = make(http.Header)
+		}
+		r.Request.Header.Set(webutil.HeaderContentEncoding, webutil.ContentEncodingGZIP)
+		return nil
+	}
+}
+
+func gzipBody(body io.ReadCloser) io.ReadCloser {
+	return newPipeBody(func(wr io.Writer) error {
+		defer body.Close()
+		gzw := gzip.NewWriter(wr)
+		if _, err := io.Copy(gzw, body); err != nil {
+			return ex.New(err)
+		}
+		return gzw.Close()
+	}, body)
+}
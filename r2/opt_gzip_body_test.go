@@ -0,0 +1,69 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestOptGzipBody(t *testing.T) {
+	assert := assert.New(t)
+
+	var contentEncoding, body string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		contentEncoding = req.Header.Get(webutil.HeaderContentEncoding)
+		gzr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		contents, _ := ioutil.ReadAll(gzr)
+		body = string(contents)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL, OptPost(), OptJSONBody(map[string]string{"foo": "bar"}), OptGzipBody()).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(webutil.ContentEncodingGZIP, contentEncoding)
+	assert.Equal(`{"foo":"bar"}`, body)
+}
+
+func TestOptGzipBodyGetBody(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(TestURL, OptBodyBytes([]byte("hello")), OptGzipBody())
+	assert.Nil(r.Err)
+	assert.NotNil(r.Request.GetBody)
+
+	for x := 0; x < 2; x++ {
+		body, err := r.Request.GetBody()
+		assert.Nil(err)
+		gzr, err := gzip.NewReader(body)
+		assert.Nil(err)
+		contents, err := ioutil.ReadAll(gzr)
+		assert.Nil(err)
+		assert.Equal("hello", string(contents))
+	}
+}
+
+func TestOptGzipBodyUnset(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(TestURL, OptGzipBody())
+	assert.Nil(r.Err)
+	assert.Empty(r.Request.Header.Get(webutil.HeaderContentEncoding))
+}
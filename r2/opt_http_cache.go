@@ -0,0 +1,18 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+// OptHTTPCache sets the http cache that responses are served from and stored in.
+//
+// The cache sits in front of any circuit breaker, so cache hits do not count towards it.
+func OptHTTPCache(hc *HTTPCache) Option {
+	return func(r *Request) error {
+		r.Cache = hc
+		return nil
+	}
+}
@@ -0,0 +1,50 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestOptHTTPCache(t *testing.T) {
+	assert := assert.New(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Header().Set(webutil.HeaderCacheControl, "max-age=60")
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	events := make(chan Event, 2)
+	log := logger.MustNew(logger.OptOutput(ioutil.Discard), logger.OptAll())
+	defer log.Close()
+	log.Listen(FlagResponse, "test", NewEventListener(func(_ context.Context, e Event) {
+		events <- e
+	}))
+
+	hc := NewHTTPCache()
+	for x := 0; x < 2; x++ {
+		contents, _, err := New(server.URL, OptHTTPCache(hc), OptLogResponse(log)).Bytes()
+		assert.Nil(err)
+		assert.Equal("OK!", string(contents))
+	}
+	assert.Equal(1, atomic.LoadInt32(&requests))
+	assert.Equal(CacheStatusMiss, (<-events).CacheStatus)
+	assert.Equal(CacheStatusHit, (<-events).CacheStatus)
+}
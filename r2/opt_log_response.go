@@ -27,6 +27,7 @@ func OptLogResponse(log logger.Triggerable) Option {
 			OptEventRequest(req),
 			OptEventResponse(res),
 			OptEventElapsed(time.Now().UTC().Sub(startedUTC)),
+			OptEventAttempt(GetAttempt(req.Context())),
 		)
 
 		logger.MaybeTriggerContext(req.Context(), log, event)
@@ -57,6 +58,7 @@ func OptLogResponseWithBody(log logger.Triggerable) Option {
 			OptEventResponse(res),
 			OptEventBody(buffer.Bytes()),
 			OptEventElapsed(time.Now().UTC().Sub(started)),
+			OptEventAttempt(GetAttempt(req.Context())),
 		)
 
 		logger.MaybeTriggerContext(req.Context(), log, event)
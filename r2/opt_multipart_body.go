@@ -0,0 +1,148 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// MultipartPart is a part of a multipart body.
+type MultipartPart struct {
+	// Write writes the part.
+	Write func(*multipart.Writer) error
+	// Rewind, if set, is called before the part is written again when the body is sent more than once.
+	// It returns an error if the part cannot be written again.
+	Rewind func() error
+}
+
+// MultipartField returns a multipart form field part.
+func MultipartField(fieldName, value string) MultipartPart {
+	return MultipartPart{
+		Write: func(w *multipart.Writer) error {
+			return w.WriteField(fieldName, value)
+		},
+	}
+}
+
+// MultipartFile returns a multipart file part from a file path.
+//
+// The file is opened when the part is written, and the content type is detected from its extension.
+func MultipartFile(fieldName, path string) MultipartPart {
+	return MultipartPart{
+		Write: func(w *multipart.Writer) error {
+			f, err := os.Open(path)
+			if err != nil {
+				return ex.New(err)
+			}
+			defer f.Close()
+			return writeMultipartFile(w, fieldName, filepath.Base(path), f)
+		},
+	}
+}
+
+// MultipartFileReader returns a multipart file part from a reader.
+//
+// The reader is read when the part is written; if the body is sent more than once, for example
+// if the request is retried, the reader is seeked back to the start. If the reader is not an
+// `io.Seeker` the body cannot be sent again, and `GetBody` returns an `ErrMultipartBodyNotRewindable` error.
+func MultipartFileReader(fieldName, fileName string, contents io.Reader) MultipartPart {
+	return MultipartPart{
+		Write: func(w *multipart.Writer) error {
+			return writeMultipartFile(w, fieldName, fileName, contents)
+		},
+		Rewind: func() error {
+			seeker, ok := contents.(io.Seeker)
+			if !ok {
+				return ex.New(ErrMultipartBodyNotRewindable, ex.OptMessagef("file: %s", fileName))
+			}
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return ex.New(err)
+			}
+			return nil
+		},
+	}
+}
+
+// OptMultipartBody sets the body of the request to a multipart form built from the given parts.
+//
+// The body is streamed as the request is sent, so files are never held in memory in full.
+// It also sets the `Content-Type` header with the multipart boundary.
+func OptMultipartBody(parts ...MultipartPart) Option {
+	return func(r *Request) error {
+		if r.Request == nil {
+			return ex.New(ErrRequestUnset)
+		}
+		boundary := multipart.NewWriter(nil).Boundary()
+		var sent bool
+		getBody := func() (io.ReadCloser, error) {
+			if sent {
+				for _, part := range parts {
+					if part.Rewind == nil {
+						continue
+					}
+					if err := part.Rewind(); err != nil {
+						return nil, err
+					}
+				}
+			}
+			sent = true
+			return newPipeBody(func(wr io.Writer) error {
+				w := multipart.NewWriter(wr)
+				if err := w.SetBoundary(boundary); err != nil {
+					return ex.New(err)
+				}
+				for _, part := range parts {
+					if err := part.Write(w); err != nil {
+						return err
+					}
+				}
+				return w.Close()
+			}, nil), nil
+		}
+		r.Request.Body, _ = getBody()
+		r.Request.GetBody = getBody
+		r.Request.ContentLength = 0
+		if r.Request.Header == nil {
+			r.Request.Header = make(http.Header)
+		}
+		r.Request.Header.Set(webutil.HeaderContentType, "multipart/form-data; boundary="+boundary)
+		return nil
+	}
+}
+
+var multipartQuoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func writeMultipartFile(w *multipart.Writer, fieldName, fileName string, contents io.Reader) error {
+	contentType := mime.TypeByExtension(filepath.Ext(fileName))
+	if contentType == "" {
+		contentType = webutil.ContentTypeApplicationOctetStream
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		multipartQuoteEscaper.Replace(fieldName), multipartQuoteEscaper.Replace(fileName)))
+	header.Set(webutil.HeaderContentType, contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return ex.New(err)
+	}
+	if _, err = io.Copy(part, contents); err != nil {
+		return ex.New(err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestOptMultipartBody(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "data.json")
+	assert.Nil(ioutil.WriteFile(path, []byte(`{"is":"a file"}`), 0644))
+
+	type part struct {
+		FormName, FileName, ContentType, Contents string
+	}
+	var parts []part
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reader, err := req.MultipartReader()
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for {
+			p, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			contents, _ := ioutil.ReadAll(p)
+			parts = append(parts, part{p.FormName(), p.FileName(), p.Header.Get(webutil.HeaderContentType), string(contents)})
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL,
+		OptPost(),
+		OptMultipartBody(
+			MultipartField("name", "value"),
+			MultipartFile("file", path),
+			MultipartFileReader("reader", "notes.txt", strings.NewReader("some notes")),
+		),
+	).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Len(parts, 3)
+	assert.Equal(part{FormName: "name", Contents: "value"}, parts[0])
+	assert.Equal("file", parts[1].FormName)
+	assert.Equal("data.json", parts[1].FileName)
+	assert.True(strings.HasPrefix(parts[1].ContentType, "application/json"))
+	assert.Equal(`{"is":"a file"}`, parts[1].Contents)
+	assert.Equal("notes.txt", parts[2].FileName)
+	assert.True(strings.HasPrefix(parts[2].ContentType, "text/plain"))
+	assert.Equal("some notes", parts[2].Contents)
+}
+
+func TestOptMultipartBodyGetBody(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(TestURL, OptMultipartBody(MultipartFileReader("file", "file.bin", bytes.NewReader([]byte("contents")))))
+	assert.Nil(r.Err)
+	mediaType, params, err := mime.ParseMediaType(r.Request.Header.Get(webutil.HeaderContentType))
+	assert.Nil(err)
+	assert.Equal("multipart/form-data", mediaType)
+
+	first, err := ioutil.ReadAll(r.Request.Body)
+	assert.Nil(err)
+	body, err := r.Request.GetBody()
+	assert.Nil(err)
+	second, err := ioutil.ReadAll(body)
+	assert.Nil(err)
+	assert.Equal(string(first), string(second))
+
+	part, err := multipart.NewReader(bytes.NewReader(second), params["boundary"]).NextPart()
+	assert.Nil(err)
+	assert.Equal(webutil.ContentTypeApplicationOctetStream, part.Header.Get(webutil.HeaderContentType))
+	contents, err := ioutil.ReadAll(part)
+	assert.Nil(err)
+	assert.Equal("contents", string(contents))
+}
+
+func TestOptMultipartBodyGetBodyNotRewindable(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := struct{ io.Reader }{strings.NewReader("contents")}
+	r := New(TestURL, OptMultipartBody(
+		MultipartField("name", "value"),
+		MultipartFileReader("file", "file.bin", contents),
+	))
+	assert.Nil(r.Err)
+	_, err := ioutil.ReadAll(r.Request.Body)
+	assert.Nil(err)
+
+	_, err = r.Request.GetBody()
+	assert.True(ex.Is(err, ErrMultipartBodyNotRewindable))
+}
+
+func TestOptMultipartBodyMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(TestURL, OptMultipartBody(MultipartFile("file", filepath.Join(t.TempDir(), "missing"))))
+	_, err := ioutil.ReadAll(r.Request.Body)
+	assert.NotNil(err)
+	assert.True(os.IsNotExist(err) || strings.Contains(err.Error(), "no such file"))
+}
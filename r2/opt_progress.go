@@ -0,0 +1,67 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// ProgressListener is called as a body is transferred with the number of bytes transferred so far,
+// and the total number of bytes, which is -1 if it is unknown.
+type ProgressListener func(transferred, total int64)
+
+// OptUploadProgress adds a listener that is called as the request body is sent.
+func OptUploadProgress(listener ProgressListener) Option {
+	return OptOnRequest(func(req *http.Request) error {
+		if req.Body == nil || req.Body == http.NoBody {
+			return nil
+		}
+		total := req.ContentLength
+		if total <= 0 {
+			total = -1
+		}
+		req.Body = &progressReader{ReadCloser: req.Body, total: total, listener: listener}
+		return nil
+	})
+}
+
+// OptDownloadProgress adds a listener that is called as the response body is read.
+func OptDownloadProgress(listener ProgressListener) Option {
+	return OptOnResponse(func(_ *http.Request, res *http.Response, _ time.Time, err error) error {
+		// there is no body to track if the request failed; the error is already returned.
+		if err != nil {
+			return nil
+		}
+		if res != nil && res.Body != nil {
+			total := res.ContentLength
+			if total < 0 {
+				total = -1
+			}
+			res.Body = &progressReader{ReadCloser: res.Body, total: total, listener: listener}
+		}
+		return nil
+	})
+}
+
+type progressReader struct {
+	io.ReadCloser
+	transferred int64
+	total       int64
+	listener    ProgressListener
+}
+
+func (pr *progressReader) Read(buffer []byte) (int, error) {
+	read, err := pr.ReadCloser.Read(buffer)
+	if read > 0 {
+		pr.transferred += int64(read)
+		pr.listener(pr.transferred, pr.total)
+	}
+	return read, err
+}
@@ -0,0 +1,78 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestOptProgress(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		contents, _ := ioutil.ReadAll(req.Body)
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write(bytes.Repeat(contents, 2))
+	}))
+	defer server.Close()
+
+	var uploaded, uploadTotal, downloaded, downloadTotal int64
+	contents, _, err := New(server.URL,
+		OptPost(),
+		OptBodyBytes([]byte("hello")),
+		OptUploadProgress(func(transferred, total int64) { uploaded, uploadTotal = transferred, total }),
+		OptDownloadProgress(func(transferred, total int64) { downloaded, downloadTotal = transferred, total }),
+	).Bytes()
+	assert.Nil(err)
+	assert.Equal("hellohello", string(contents))
+	assert.Equal(5, uploaded)
+	assert.Equal(5, uploadTotal)
+	assert.Equal(10, downloaded)
+	assert.Equal(10, downloadTotal)
+}
+
+func TestOptUploadProgressUnknownLength(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = ioutil.ReadAll(req.Body)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var uploaded, uploadTotal int64
+	_, err := New(server.URL,
+		OptPost(),
+		OptBodyBytes([]byte("hello")),
+		OptGzipBody(),
+		OptUploadProgress(func(transferred, total int64) { uploaded, uploadTotal = transferred, total }),
+	).Discard()
+	assert.Nil(err)
+	assert.NotZero(uploaded)
+	assert.Equal(-1, uploadTotal)
+}
+
+func TestOptDownloadProgressError(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	server.Close()
+
+	var called bool
+	_, err := New(server.URL, OptDownloadProgress(func(_, _ int64) { called = true })).Discard()
+	assert.NotNil(err)
+	assert.Len(ex.Unwrap(err), 1, "the transport error should only be reported once")
+	assert.False(called)
+}
@@ -0,0 +1,68 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/blend/go-sdk/retry"
+)
+
+// RetryOption mutates retry options.
+type RetryOption func(*RetryOptions)
+
+// OptRetry sets the request to retry network errors and responses with a retryable status code.
+//
+// By default it will make up to `DefaultRetryMaxAttempts` attempts, retrying `DefaultRetryStatusCodes`
+// with an exponential backoff with jitter from `DefaultRetryBaseDelay`. Responses that set a `Retry-After`
+// header are retried after the delay it specifies, unless it is longer than `DefaultRetryMaxDelay`,
+// and no retry is made that would pass the request context deadline.
+//
+// Request bodies without a `GetBody` function are buffered in memory so they can be replayed.
+// Note that requests are retried regardless of their method; only set this option on requests that are safe to repeat.
+func OptRetry(options ...RetryOption) Option {
+	return func(r *Request) error {
+		r.Retry = &RetryOptions{
+			MaxAttempts:   DefaultRetryMaxAttempts,
+			DelayProvider: retry.ExponentialBackoffWithJitter(DefaultRetryBaseDelay),
+			MaxDelay:      DefaultRetryMaxDelay,
+			StatusCodes:   DefaultRetryStatusCodes,
+		}
+		for _, option := range options {
+			option(r.Retry)
+		}
+		return nil
+	}
+}
+
+// OptRetryMaxAttempts sets the maximum number of attempts, including the first.
+func OptRetryMaxAttempts(maxAttempts uint) RetryOption {
+	return func(ro *RetryOptions) { ro.MaxAttempts = maxAttempts }
+}
+
+// OptRetryDelayProvider sets the retry delay provider.
+func OptRetryDelayProvider(delayProvider retry.DelayProvider) RetryOption {
+	return func(ro *RetryOptions) { ro.DelayProvider = delayProvider }
+}
+
+// OptRetryMaxDelay sets the maximum delay a `Retry-After` header can specify; a zero value removes the limit.
+func OptRetryMaxDelay(maxDelay time.Duration) RetryOption {
+	return func(ro *RetryOptions) { ro.MaxDelay = maxDelay }
+}
+
+// OptRetryStatusCodes sets the response status codes that are retried.
+func OptRetryStatusCodes(statusCodes ...int) RetryOption {
+	return func(ro *RetryOptions) { ro.StatusCodes = statusCodes }
+}
+
+// OptRetryShouldRetryProvider sets a function that determines if an attempt should be retried,
+// overriding the check of status codes and network errors.
+func OptRetryShouldRetryProvider(provider func(*http.Response, error) bool) RetryOption {
+	return func(ro *RetryOptions) { ro.ShouldRetryProvider = provider }
+}
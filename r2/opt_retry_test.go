@@ -0,0 +1,295 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/retry"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestOptRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New(TestURL, OptRetry())
+	assert.NotNil(r.Retry)
+	assert.Equal(DefaultRetryMaxAttempts, r.Retry.MaxAttempts)
+	assert.Equal(DefaultRetryStatusCodes, r.Retry.StatusCodes)
+	assert.NotNil(r.Retry.DelayProvider)
+	assert.Equal(DefaultRetryMaxDelay, r.Retry.MaxDelay)
+
+	r = New(TestURL, OptRetry(
+		OptRetryMaxAttempts(5),
+		OptRetryStatusCodes(http.StatusInternalServerError),
+		OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond)),
+		OptRetryMaxDelay(time.Second),
+		OptRetryShouldRetryProvider(func(_ *http.Response, _ error) bool { return false }),
+	))
+	assert.Equal(5, r.Retry.MaxAttempts)
+	assert.Equal([]int{http.StatusInternalServerError}, r.Retry.StatusCodes)
+	assert.Equal(time.Millisecond, r.Retry.DelayProvider(context.Background(), 3))
+	assert.Equal(time.Second, r.Retry.MaxDelay)
+	assert.NotNil(r.Retry.ShouldRetryProvider)
+}
+
+func TestOptRetryStatusCodes(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts []uint
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte("OK!"))
+	}))
+	defer server.Close()
+
+	contents, res, err := New(server.URL,
+		OptPost(),
+		OptBody(ioutil.NopCloser(strings.NewReader("hello"))),
+		OptRetry(OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond))),
+		OptOnResponse(func(req *http.Request, _ *http.Response, _ time.Time, _ error) error {
+			attempts = append(attempts, GetAttempt(req.Context()))
+			return nil
+		}),
+	).Bytes()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("OK!", string(contents))
+	assert.Equal([]string{"hello", "hello", "hello"}, bodies)
+	assert.Equal([]uint{1, 2, 3}, attempts)
+}
+
+func TestOptRetryMaxAttempts(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL,
+		OptRetry(OptRetryMaxAttempts(2), OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond))),
+	).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusBadGateway, res.StatusCode)
+	assert.Equal(2, atomic.LoadInt32(&attempts))
+}
+
+func TestOptRetryNotRetryable(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL, OptRetry(OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond)))).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(1, atomic.LoadInt32(&attempts))
+}
+
+func TestOptRetryNetworkError(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	serverURL := server.URL
+	server.Close()
+
+	var attempts []uint
+	var errs int
+	_, err := New(serverURL,
+		OptRetry(OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond))),
+		OptOnResponse(func(req *http.Request, _ *http.Response, _ time.Time, err error) error {
+			attempts = append(attempts, GetAttempt(req.Context()))
+			if err != nil {
+				errs++
+			}
+			return err
+		}),
+	).Discard()
+	assert.NotNil(err)
+	assert.Equal([]uint{1, 2, 3}, attempts)
+	assert.Equal(3, errs)
+}
+
+func TestOptRetryRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			rw.Header().Set(webutil.HeaderRetryAfter, "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	started := time.Now()
+	res, err := New(server.URL, OptRetry(OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond)))).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(2, atomic.LoadInt32(&attempts))
+	assert.True(time.Since(started) >= time.Second)
+}
+
+func TestOptRetryContextDeadline(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		rw.Header().Set(webutil.HeaderRetryAfter, "30")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	started := time.Now()
+	res, err := New(server.URL, OptContext(ctx), OptRetry()).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(1, atomic.LoadInt32(&attempts))
+	assert.True(time.Since(started) < 5*time.Second)
+}
+
+func TestOptRetryMaxDelay(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		rw.Header().Set(webutil.HeaderRetryAfter, "3600")
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	started := time.Now()
+	res, err := New(server.URL, OptRetry()).Discard()
+	assert.Nil(err)
+	assert.Equal(http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(1, atomic.LoadInt32(&attempts))
+	assert.True(time.Since(started) < DefaultRetryMaxDelay)
+}
+
+func TestOptRetryContextCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := New(server.URL,
+		OptContext(ctx),
+		OptRetry(OptRetryDelayProvider(retry.ConstantDelay(time.Minute))),
+		OptOnResponse(func(_ *http.Request, _ *http.Response, _ time.Time, _ error) error {
+			cancel()
+			return nil
+		}),
+	).Discard()
+	assert.NotNil(err)
+	assert.True(ex.Is(err, context.Canceled))
+}
+
+func TestOptRetryListenerError(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL,
+		OptRetry(OptRetryDelayProvider(retry.ConstantDelay(time.Millisecond))),
+		OptOnResponse(func(_ *http.Request, _ *http.Response, _ time.Time, _ error) error {
+			return context.DeadlineExceeded
+		}),
+	).Discard()
+	assert.NotNil(err)
+	assert.Equal(DefaultRetryMaxAttempts, atomic.LoadInt32(&attempts))
+}
+
+func TestBufferRequestBody(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest(http.MethodPost, TestURL, nil)
+	req.Body = ioutil.NopCloser(bytes.NewBufferString("hello"))
+	assert.Nil(bufferRequestBody(req))
+	assert.Equal(5, req.ContentLength)
+	assert.NotNil(req.GetBody)
+
+	for x := 0; x < 2; x++ {
+		body, err := req.GetBody()
+		assert.Nil(err)
+		contents, _ := ioutil.ReadAll(body)
+		assert.Equal("hello", string(contents))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2021, 06, 01, 12, 00, 00, 00, time.UTC)
+
+	delay, ok := ParseRetryAfter("120", now)
+	assert.True(ok)
+	assert.Equal(2*time.Minute, delay)
+
+	delay, ok = ParseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+	assert.True(ok)
+	assert.Equal(time.Minute, delay)
+
+	delay, ok = ParseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now)
+	assert.True(ok)
+	assert.Zero(delay)
+
+	_, ok = ParseRetryAfter("", now)
+	assert.False(ok)
+	_, ok = ParseRetryAfter("-1", now)
+	assert.False(ok)
+	_, ok = ParseRetryAfter("not a date", now)
+	assert.False(ok)
+}
+
+func TestGetAttempt(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(GetAttempt(context.Background()))
+	assert.Equal(3, GetAttempt(WithAttempt(context.Background(), 3)))
+}
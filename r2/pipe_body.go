@@ -0,0 +1,72 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"io"
+	"sync"
+)
+
+var (
+	_ io.ReadCloser = (*pipeBody)(nil)
+)
+
+// newPipeBody returns a request body that streams the output of a write function.
+//
+// The write function is started in a goroutine on the first read, so bodies that
+// are never sent do not leak goroutines; if the body is closed before it is read,
+// the given source closer (if any) is closed instead.
+func newPipeBody(write func(io.Writer) error, source io.Closer) *pipeBody {
+	return &pipeBody{write: write, source: source}
+}
+
+type pipeBody struct {
+	sync.Mutex
+	write   func(io.Writer) error
+	source  io.Closer
+	reader  *io.PipeReader
+	started bool
+	closed  bool
+}
+
+// Read implements io.Reader.
+func (pb *pipeBody) Read(buffer []byte) (int, error) {
+	pb.Lock()
+	if pb.closed {
+		pb.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	if !pb.started {
+		pb.started = true
+		reader, writer := io.Pipe()
+		pb.reader = reader
+		go func() {
+			_ = writer.CloseWithError(pb.write(writer))
+		}()
+	}
+	reader := pb.reader
+	pb.Unlock()
+	return reader.Read(buffer)
+}
+
+// Close implements io.Closer.
+func (pb *pipeBody) Close() error {
+	pb.Lock()
+	defer pb.Unlock()
+	if pb.closed {
+		return nil
+	}
+	pb.closed = true
+	if pb.started {
+		return pb.reader.Close()
+	}
+	if pb.source != nil {
+		return pb.source.Close()
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Cassette is a recorded set of request and response pairs.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a recorded request and response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request" yaml:"request"`
+	Response RecordedResponse `json:"response" yaml:"response"`
+}
+
+// RecordedRequest is a recorded request.
+type RecordedRequest struct {
+	Method string      `json:"method" yaml:"method"`
+	URL    string      `json:"url" yaml:"url"`
+	Header http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body   string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode" yaml:"statusCode"`
+	Header     http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body       string      `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// ReadCassette reads a cassette from a file.
+//
+// Files with a `.json` extension are read as json, all others are read as yaml.
+func ReadCassette(path string) (*Cassette, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	var cassette Cassette
+	if isJSONPath(path) {
+		err = json.Unmarshal(contents, &cassette)
+	} else {
+		err = yaml.Unmarshal(contents, &cassette)
+	}
+	if err != nil {
+		return nil, ex.New(err, ex.OptMessagef("path: %s", path))
+	}
+	return &cassette, nil
+}
+
+// WriteCassette writes a cassette to a file, creating any parent directories.
+//
+// Files with a `.json` extension are written as json, all others are written as yaml.
+func WriteCassette(path string, cassette *Cassette) error {
+	var contents []byte
+	var err error
+	if isJSONPath(path) {
+		contents, err = json.MarshalIndent(cassette, "", "  ")
+	} else {
+		contents, err = yaml.Marshal(cassette)
+	}
+	if err != nil {
+		return ex.New(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return ex.New(err)
+	}
+	if err = ioutil.WriteFile(path, contents, 0644); err != nil {
+		return ex.New(err)
+	}
+	return nil
+}
+
+func isJSONPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
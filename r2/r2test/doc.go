@@ -31,5 +31,15 @@ During tests we can add a default option:
 	...
 
 We will now return the mocked response instead of reaching out to the remote for the call.
+
+For tests against third party apis, a `Recorder` can record real calls to a cassette file once,
+and then replay them offline:
+
+	rec, err := r2test.NewRecorder("testdata/foos.yml", r2test.OptRecorderMode(r2test.ModeRecord))
+	...
+	a := APIClient{ Remote: "https://api.example.com", Defaults: []r2.Option{r2test.OptRecorder(rec)} }
+
+Once the cassette is written, drop the `OptRecorderMode` option to replay it; requests that do not
+match a recorded interaction return an `ErrUnmatchedRequest` error.
 */
 package r2test // import "github.com/blend/go-sdk/r2/r2test"
@@ -0,0 +1,29 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2test
+
+import (
+	"net/url"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+const (
+	// ErrUnmatchedRequest is returned by a replaying recorder when a request does not match any recorded interaction.
+	ErrUnmatchedRequest ex.Class = "r2test; request does not match any recorded interaction"
+)
+
+// ErrIsUnmatchedRequest returns if an error is an unmatched request error.
+//
+// It also checks the inner error of the `*url.Error` errors returned by http clients.
+func ErrIsUnmatchedRequest(err error) bool {
+	if typed, ok := ex.ErrClass(err).(*url.Error); ok {
+		return ex.Is(typed.Err, ErrUnmatchedRequest)
+	}
+	return ex.Is(err, ErrUnmatchedRequest)
+}
@@ -0,0 +1,18 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2test
+
+import "github.com/blend/go-sdk/r2"
+
+// OptRecorder sets a request to be recorded to, or replayed from, a cassette by a recorder.
+//
+// In record mode, the recorder makes the real call with its own transport, so any transport
+// options on the request should instead be set on the recorder with `OptRecorderTransport`.
+func OptRecorder(rec *Recorder) r2.Option {
+	return r2.OptTransport(rec)
+}
@@ -0,0 +1,289 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/sanitize"
+)
+
+// Mode is a recorder mode.
+type Mode string
+
+// Recorder modes.
+const (
+	// ModeReplay serves requests from the cassette and never makes real calls.
+	ModeReplay Mode = "replay"
+	// ModeRecord makes real calls and writes each request and response pair to the cassette.
+	ModeRecord Mode = "record"
+)
+
+var (
+	_ http.RoundTripper = (*Recorder)(nil)
+)
+
+// NewRecorder returns a new recorder for a cassette file.
+//
+// In replay mode (the default) the cassette file must exist. In record mode
+// the cassette file is overwritten with the interactions as they are recorded.
+func NewRecorder(path string, options ...RecorderOption) (*Recorder, error) {
+	rec := Recorder{
+		Path: path,
+		Mode: ModeReplay,
+	}
+	for _, option := range options {
+		option(&rec)
+	}
+	if rec.Mode == ModeReplay {
+		cassette, err := ReadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		rec.Cassette = cassette
+	} else {
+		rec.Cassette = new(Cassette)
+	}
+	rec.used = make([]bool, len(rec.Cassette.Interactions))
+	return &rec, nil
+}
+
+// RecorderOption mutates a recorder.
+type RecorderOption func(*Recorder)
+
+// OptRecorderMode sets the recorder mode.
+func OptRecorderMode(mode Mode) RecorderOption {
+	return func(rec *Recorder) { rec.Mode = mode }
+}
+
+// OptRecorderTransport sets the transport used to make real calls in record mode.
+func OptRecorderTransport(transport http.RoundTripper) RecorderOption {
+	return func(rec *Recorder) { rec.Transport = transport }
+}
+
+// OptRecorderMatchBody sets if request bodies must match recorded bodies.
+func OptRecorderMatchBody(matchBody bool) RecorderOption {
+	return func(rec *Recorder) { rec.MatchBody = matchBody }
+}
+
+// OptRecorderMatchHeaders sets the request headers that must match recorded headers.
+func OptRecorderMatchHeaders(headers ...string) RecorderOption {
+	return func(rec *Recorder) { rec.MatchHeaders = headers }
+}
+
+// OptRecorderMatcher sets a custom request matcher, overriding the default matching.
+func OptRecorderMatcher(matcher Matcher) RecorderOption {
+	return func(rec *Recorder) { rec.Matcher = matcher }
+}
+
+// OptRecorderSanitize sets the sanitize options used to scrub recorded requests and responses.
+func OptRecorderSanitize(options ...sanitize.RequestOption) RecorderOption {
+	return func(rec *Recorder) { rec.SanitizeOptions = options }
+}
+
+// Matcher returns if a request matches a recorded request.
+//
+// The request is given already sanitized, with its body read out.
+type Matcher func(req *http.Request, body []byte, recorded RecordedRequest) bool
+
+// Recorder is a round tripper that records real calls to, or replays calls from, a cassette file.
+//
+// Requests are matched on method and url by default, and optionally on body and selected headers.
+// When replaying, each request is served from the first matching interaction that has not been used yet,
+// or the first matching interaction if all have been used. Requests that match no interaction fail
+// with an `ErrUnmatchedRequest` error.
+//
+// Sensitive headers and query parameters are scrubbed with the `sanitize` package before requests
+// and responses are written, and before requests are matched, so cassettes can be committed.
+type Recorder struct {
+	sync.Mutex
+
+	// Path is the cassette file path.
+	Path string
+	// Mode is the recorder mode.
+	Mode Mode
+	// Cassette holds the recorded interactions.
+	Cassette *Cassette
+	// Transport is the transport used to make real calls in record mode.
+	// If unset, `http.DefaultTransport` is used.
+	Transport http.RoundTripper
+	// MatchBody sets if request bodies must match recorded bodies.
+	MatchBody bool
+	// MatchHeaders are the request headers that must match recorded headers.
+	MatchHeaders []string
+	// Matcher is an optional custom request matcher.
+	Matcher Matcher
+	// SanitizeOptions are the sanitize options used to scrub requests and responses.
+	SanitizeOptions []sanitize.RequestOption
+
+	used []bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rec *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, ex.New(err)
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if rec.Mode == ModeRecord {
+		return rec.record(req, body)
+	}
+	return rec.replay(req, body)
+}
+
+// Save writes the cassette to the cassette file.
+func (rec *Recorder) Save() error {
+	rec.Lock()
+	defer rec.Unlock()
+	return WriteCassette(rec.Path, rec.Cassette)
+}
+
+func (rec *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := rec.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	sanitized := sanitize.Request(req, rec.SanitizeOptions...)
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: sanitized.Method,
+			URL:    sanitized.URL.String(),
+			Header: compactHeader(sanitized.Header),
+			Body:   string(body),
+		},
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Header:     compactHeader(rec.sanitizeHeader(res.Header)),
+			Body:       string(resBody),
+		},
+	}
+
+	rec.Lock()
+	defer rec.Unlock()
+	rec.Cassette.Interactions = append(rec.Cassette.Interactions, interaction)
+	rec.used = append(rec.used, true)
+	if err = WriteCassette(rec.Path, rec.Cassette); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (rec *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	sanitized := sanitize.Request(req, rec.SanitizeOptions...)
+
+	rec.Lock()
+	defer rec.Unlock()
+
+	index := -1
+	for x, interaction := range rec.Cassette.Interactions {
+		if !rec.matches(sanitized, body, interaction.Request) {
+			continue
+		}
+		if !rec.used[x] {
+			index = x
+			break
+		}
+		if index < 0 {
+			index = x
+		}
+	}
+	if index < 0 {
+		return nil, ex.New(ErrUnmatchedRequest, ex.OptMessagef("%s %s", sanitized.Method, sanitized.URL.String()))
+	}
+	rec.used[index] = true
+
+	recorded := rec.Cassette.Interactions[index].Response
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(recorded.StatusCode) + " " + http.StatusText(recorded.StatusCode),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (rec *Recorder) matches(req *http.Request, body []byte, recorded RecordedRequest) bool {
+	if rec.Matcher != nil {
+		return rec.Matcher(req, body, recorded)
+	}
+	if !strings.EqualFold(req.Method, recorded.Method) || req.URL.String() != recorded.URL {
+		return false
+	}
+	if rec.MatchBody && string(body) != recorded.Body {
+		return false
+	}
+	for _, header := range rec.MatchHeaders {
+		if strings.Join(req.Header.Values(header), ",") != strings.Join(recorded.Header.Values(header), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeHeader scrubs response headers with the same options used for requests.
+func (rec *Recorder) sanitizeHeader(header http.Header) http.Header {
+	options := sanitize.RequestOptions{
+		DisallowedHeaders: sanitize.DefaultSanitizationDisallowedHeaders,
+		ValueSanitizer:    sanitize.DefaultValueSanitizer,
+	}
+	for _, option := range rec.SanitizeOptions {
+		option(&options)
+	}
+	output := header.Clone()
+	for key, values := range output {
+		if options.IsHeaderDisallowed(key) {
+			output[key] = options.ValueSanitizer(key, values...)
+		}
+	}
+	return output
+}
+
+// compactHeader removes headers without values, e.g. those removed by sanitization.
+func compactHeader(header http.Header) http.Header {
+	output := make(http.Header)
+	for key, values := range header {
+		if len(values) > 0 {
+			output[key] = values
+		}
+	}
+	if len(output) == 0 {
+		return nil
+	}
+	return output
+}
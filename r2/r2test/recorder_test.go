@@ -0,0 +1,163 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/r2"
+)
+
+func recordFixture(assert *assert.Assertions, path string, options ...RecorderOption) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		http.SetCookie(rw, &http.Cookie{Name: "session", Value: "secret"})
+		rw.Header().Set("X-Path", req.URL.Path)
+		rw.WriteHeader(http.StatusOK)
+		fmt.Fprintf(rw, "%s %s %s", req.Method, req.URL.Path, string(body))
+	}))
+	defer server.Close()
+
+	rec, err := NewRecorder(path, append(options, OptRecorderMode(ModeRecord))...)
+	assert.Nil(err)
+
+	contents, res, err := r2.New(server.URL,
+		OptRecorder(rec),
+		r2.OptPath("/foo"),
+		r2.OptHeaderValue("Authorization", "Bearer secret"),
+	).Bytes()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("GET /foo ", string(contents))
+
+	contents, _, err = r2.New(server.URL,
+		OptRecorder(rec),
+		r2.OptPost(),
+		r2.OptPath("/bar"),
+		r2.OptBodyBytes([]byte("hello")),
+	).Bytes()
+	assert.Nil(err)
+	assert.Equal("POST /bar hello", string(contents))
+}
+
+func TestRecorderRecordReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "fixtures", "cassette.yml")
+	recordFixture(assert, path)
+
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(err)
+	assert.False(strings.Contains(string(contents), "secret"))
+
+	cassette, err := ReadCassette(path)
+	assert.Nil(err)
+	assert.Len(cassette.Interactions, 2)
+	assert.Equal(http.MethodGet, cassette.Interactions[0].Request.Method)
+	assert.Equal("/foo", cassette.Interactions[0].Response.Header.Get("X-Path"))
+	assert.Empty(cassette.Interactions[0].Response.Header.Get("Set-Cookie"))
+	assert.Equal("hello", cassette.Interactions[1].Request.Body)
+
+	// the server is closed, so these are served fully offline.
+	rec, err := NewRecorder(path)
+	assert.Nil(err)
+	serverURL := strings.TrimSuffix(cassette.Interactions[0].Request.URL, "/foo")
+
+	contents, res, err := r2.New(serverURL, OptRecorder(rec), r2.OptPath("/foo")).Bytes()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("GET /foo ", string(contents))
+	assert.Equal("/foo", res.Header.Get("X-Path"))
+
+	contents, _, err = r2.New(serverURL, OptRecorder(rec), r2.OptPost(), r2.OptPath("/bar"), r2.OptBodyBytes([]byte("hello"))).Bytes()
+	assert.Nil(err)
+	assert.Equal("POST /bar hello", string(contents))
+
+	_, _, err = r2.New(serverURL, OptRecorder(rec), r2.OptPath("/baz")).Bytes()
+	assert.NotNil(err)
+	assert.True(ErrIsUnmatchedRequest(err))
+}
+
+func TestRecorderReplayJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recordFixture(assert, path)
+
+	contents, err := ioutil.ReadFile(path)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(string(contents), "{"))
+
+	cassette, err := ReadCassette(path)
+	assert.Nil(err)
+	assert.Len(cassette.Interactions, 2)
+}
+
+func TestRecorderMatchBody(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "cassette.yml")
+	recordFixture(assert, path)
+	cassette, err := ReadCassette(path)
+	assert.Nil(err)
+	serverURL := strings.TrimSuffix(cassette.Interactions[0].Request.URL, "/foo")
+
+	rec, err := NewRecorder(path)
+	assert.Nil(err)
+	_, _, err = r2.New(serverURL, OptRecorder(rec), r2.OptPost(), r2.OptPath("/bar"), r2.OptBodyBytes([]byte("goodbye"))).Bytes()
+	assert.Nil(err)
+
+	rec, err = NewRecorder(path, OptRecorderMatchBody(true))
+	assert.Nil(err)
+	_, _, err = r2.New(serverURL, OptRecorder(rec), r2.OptPost(), r2.OptPath("/bar"), r2.OptBodyBytes([]byte("goodbye"))).Bytes()
+	assert.True(ErrIsUnmatchedRequest(err))
+}
+
+func TestRecorderMatchHeaders(t *testing.T) {
+	assert := assert.New(t)
+
+	rec := &Recorder{
+		Mode:         ModeReplay,
+		MatchHeaders: []string{"X-Version"},
+		Cassette: &Cassette{
+			Interactions: []Interaction{
+				{
+					Request:  RecordedRequest{Method: "GET", URL: "http://test.invalid/", Header: http.Header{"X-Version": {"1"}}},
+					Response: RecordedResponse{StatusCode: http.StatusOK, Body: "v1"},
+				},
+				{
+					Request:  RecordedRequest{Method: "GET", URL: "http://test.invalid/", Header: http.Header{"X-Version": {"2"}}},
+					Response: RecordedResponse{StatusCode: http.StatusOK, Body: "v2"},
+				},
+			},
+		},
+		used: make([]bool, 2),
+	}
+
+	for x := 0; x < 2; x++ {
+		contents, _, err := r2.New("http://test.invalid/", OptRecorder(rec), r2.OptHeaderValue("X-Version", "2")).Bytes()
+		assert.Nil(err)
+		assert.Equal("v2", string(contents))
+	}
+	_, _, err := r2.New("http://test.invalid/", OptRecorder(rec), r2.OptHeaderValue("X-Version", "3")).Bytes()
+	assert.True(ErrIsUnmatchedRequest(err))
+}
+
+func TestNewRecorderMissingCassette(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.NotNil(err)
+}
@@ -68,6 +68,18 @@ type Request struct {
 	OnRequest []OnRequestListener
 	// OnResponse is an array of response lifecycle hooks used for logging.
 	OnResponse []OnResponseListener
+	// Retry is an optional set of options used to retry failed attempts.
+	// If it is unset, the request is attempted once.
+	Retry *RetryOptions
+	// Breaker is an optional set of options used to send attempts through a circuit breaker.
+	Breaker *BreakerOptions
+	// Cache is an optional http cache that responses are served from and stored in.
+	Cache *HTTPCache
+	// ExpectStatus are the expected response status codes; if set, other status codes
+	// are returned as `ErrUnexpectedStatus` errors.
+	ExpectStatus []int
+	// ErrorJSON is an optional object that the bodies of unexpected responses are decoded into.
+	ErrorJSON interface{}
 }
 
 // WithContext implements the `WithContext` method for the underlying request.
@@ -99,37 +111,73 @@ func (r Request) Do() (*http.Response, error) {
 		r.Request.GetBody = func() (io.ReadCloser, error) { return ioutil.NopCloser(http.NoBody), nil }
 	}
 
+	var res *http.Response
+	var err error
+	if r.Retry != nil {
+		res, err = r.doWithRetry()
+	} else {
+		res, _, err = r.do(r.Request, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = r.checkStatus(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// do sends a single attempt of a request, calling the tracer and the request and response listeners.
+//
+// If a should retry check is given and returns true for the result of the attempt, errors returned
+// by the response listeners are ignored and the returned bool is true.
+func (r Request) do(req *http.Request, shouldRetry func(*http.Response, error) bool) (*http.Response, bool, error) {
 	started := time.Now().UTC()
 	var finisher TraceFinisher
 	if r.Tracer != nil {
-		finisher = r.Tracer.Start(r.Request)
+		finisher = r.Tracer.Start(req)
 	}
 	for _, listener := range r.OnRequest {
-		if err := listener(r.Request); err != nil {
-			return nil, err
+		if err := listener(req); err != nil {
+			return nil, false, err
 		}
 	}
 
-	var err error
-	var res *http.Response
-	if r.Client != nil {
-		res, err = r.Client.Do(r.Request)
-	} else {
-		res, err = http.DefaultClient.Do(r.Request)
-	}
+	res, err := r.send(req)
 	if finisher != nil {
-		finisher.Finish(r.Request, res, started, err)
+		finisher.Finish(req, res, started, err)
 	}
+	retry := shouldRetry != nil && shouldRetry(res, err)
 	for _, listener := range r.OnResponse {
-		if listenerErr := listener(r.Request, res, started, err); listenerErr != nil {
+		if listenerErr := listener(req, res, started, err); listenerErr != nil && !retry {
 			err = ex.Append(err, listenerErr)
-			return nil, err
+			return nil, false, err
 		}
 	}
 	if err != nil {
-		return nil, err
+		return nil, retry, err
 	}
-	return res, nil
+	return res, retry, nil
+}
+
+// send sends a request, serving it from the http cache if one is set.
+func (r Request) send(req *http.Request) (*http.Response, error) {
+	if r.Cache != nil {
+		return r.Cache.Do(req, r.sendClient)
+	}
+	return r.sendClient(req)
+}
+
+// sendClient sends a request with the client, through the circuit breaker if one is set.
+func (r Request) sendClient(req *http.Request) (*http.Response, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if r.Breaker != nil {
+		return r.Breaker.Do(client, req)
+	}
+	return client.Do(req)
 }
 
 // Close closes the request if there is a closer specified.
@@ -0,0 +1,106 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+const (
+	// ResponseErrorMaxBodySize is the maximum number of bytes of an unexpected response body that are read.
+	ResponseErrorMaxBodySize = 1 << 20
+	// ResponseErrorBodyTruncateSize is the number of bytes of an unexpected response body that are kept on the error.
+	ResponseErrorBodyTruncateSize = 1 << 10
+)
+
+var (
+	_ error = (*ResponseError)(nil)
+)
+
+// ResponseError holds the details of a response with an unexpected status code.
+//
+// It is the inner error of `ErrUnexpectedStatus` errors.
+type ResponseError struct {
+	// StatusCode is the response status code.
+	StatusCode int
+	// Header is the response header.
+	Header http.Header
+	// Body is the start of the response body, truncated to `ResponseErrorBodyTruncateSize` bytes.
+	Body []byte
+	// Payload is the decoded response body, if an error payload was set with `OptErrorJSON` and it could be decoded.
+	Payload interface{}
+}
+
+// Error implements error.
+func (re *ResponseError) Error() string {
+	return fmt.Sprintf("status: %d; body: %s", re.StatusCode, string(re.Body))
+}
+
+// ErrResponse returns the response details of an `ErrUnexpectedStatus` error, or nil if it is not one.
+func ErrResponse(err error) *ResponseError {
+	if !ex.Is(err, ErrUnexpectedStatus) {
+		return nil
+	}
+	if typed, ok := ex.ErrInner(err).(*ResponseError); ok {
+		return typed
+	}
+	return nil
+}
+
+// ErrStatusCode returns the response status code of an `ErrUnexpectedStatus` error, or 0 if it is not one.
+func ErrStatusCode(err error) int {
+	if re := ErrResponse(err); re != nil {
+		return re.StatusCode
+	}
+	return 0
+}
+
+// checkStatus returns an `ErrUnexpectedStatus` error, and closes the response,
+// if the response status code is not expected.
+//
+// If no status codes are expected and no error payload is set, all status codes are accepted.
+// If only an error payload is set, any 2xx status code is accepted.
+func (r Request) checkStatus(res *http.Response) error {
+	if len(r.ExpectStatus) == 0 && r.ErrorJSON == nil {
+		return nil
+	}
+	if len(r.ExpectStatus) == 0 && res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+	for _, statusCode := range r.ExpectStatus {
+		if res.StatusCode == statusCode {
+			return nil
+		}
+	}
+
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, ResponseErrorMaxBodySize))
+	if err != nil {
+		return ex.New(err)
+	}
+	responseErr := &ResponseError{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       body,
+	}
+	if r.ErrorJSON != nil && len(body) > 0 {
+		if json.Unmarshal(body, r.ErrorJSON) == nil {
+			responseErr.Payload = r.ErrorJSON
+		}
+	}
+	if len(responseErr.Body) > ResponseErrorBodyTruncateSize {
+		responseErr.Body = responseErr.Body[:ResponseErrorBodyTruncateSize]
+	}
+	return ex.New(ErrUnexpectedStatus, ex.OptMessagef("%s %s; status: %d", r.Request.Method, r.Request.URL.String(), res.StatusCode), ex.OptInnerClass(responseErr))
+}
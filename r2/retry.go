@@ -0,0 +1,199 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package r2
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/retry"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// RetryOptions are the options for retrying requests.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	MaxAttempts uint
+	// DelayProvider returns the delay before a given retry, starting at 0 for the first retry.
+	DelayProvider retry.DelayProvider
+	// MaxDelay, if set, is the maximum delay a `Retry-After` header can specify; if it specifies a longer delay the retry is not made.
+	MaxDelay time.Duration
+	// StatusCodes are the response status codes that are retried.
+	StatusCodes []int
+	// ShouldRetryProvider, if set, overrides the default check of status codes and network errors.
+	ShouldRetryProvider func(*http.Response, error) bool
+}
+
+// ShouldRetry returns if the result of an attempt should be retried.
+//
+// By default network errors and responses with one of the status codes are retried.
+// Errors caused by the request context being canceled, or by an open circuit breaker, are never retried.
+func (ro RetryOptions) ShouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if req != nil && req.Context().Err() != nil {
+		return false
+	}
+	if ErrIsBreakerOpen(err) {
+		return false
+	}
+	if ro.ShouldRetryProvider != nil {
+		return ro.ShouldRetryProvider(res, err)
+	}
+	if err != nil {
+		return !ErrIsTooManyRedirects(err)
+	}
+	if res == nil {
+		return false
+	}
+	for _, statusCode := range ro.StatusCodes {
+		if res.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the delay before a given retry, starting at 0 for the first retry, and if the retry should be made.
+//
+// If the response sets a `Retry-After` header, it is used instead of the delay provider;
+// the retry is not made if it exceeds the max delay.
+func (ro RetryOptions) Delay(ctx context.Context, retryAttempt uint, res *http.Response) (time.Duration, bool) {
+	if res != nil {
+		if retryAfter, ok := ParseRetryAfter(res.Header.Get(webutil.HeaderRetryAfter), time.Now().UTC()); ok {
+			if ro.MaxDelay > 0 && retryAfter > ro.MaxDelay {
+				return 0, false
+			}
+			return retryAfter, true
+		}
+	}
+	if ro.DelayProvider != nil {
+		return ro.DelayProvider(ctx, retryAttempt), true
+	}
+	return 0, true
+}
+
+// ParseRetryAfter parses a `Retry-After` header value, which can either be a number of seconds,
+// or an http date, into a delay relative to a given time.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	ts, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := ts.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+type attemptKey struct{}
+
+// WithAttempt adds the attempt number of a request to a context.
+func WithAttempt(ctx context.Context, attempt uint) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// GetAttempt gets the attempt number of a request from a context.
+//
+// Attempts start at 1; it returns 0 for requests that are not retried.
+func GetAttempt(ctx context.Context) uint {
+	if value := ctx.Value(attemptKey{}); value != nil {
+		if typed, ok := value.(uint); ok {
+			return typed
+		}
+	}
+	return 0
+}
+
+// doWithRetry sends the request, retrying attempts as dictated by the retry options.
+//
+// Each attempt is traced and passed to the request and response listeners, with the
+// attempt number set on the request context.
+func (r Request) doWithRetry() (*http.Response, error) {
+	if err := bufferRequestBody(r.Request); err != nil {
+		return nil, err
+	}
+
+	ctx := r.Request.Context()
+	for attempt := uint(1); ; attempt++ {
+		req := r.Request.WithContext(WithAttempt(ctx, attempt))
+		if attempt > 1 {
+			body, err := r.Request.GetBody()
+			if err != nil {
+				return nil, ex.New(err)
+			}
+			req.Body = body
+		}
+
+		var delay time.Duration
+		res, shouldRetry, err := r.do(req, func(res *http.Response, err error) bool {
+			if attempt >= r.Retry.MaxAttempts || !r.Retry.ShouldRetry(req, res, err) {
+				return false
+			}
+			var ok bool
+			if delay, ok = r.Retry.Delay(ctx, attempt-1, res); !ok {
+				return false
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+				return false
+			}
+			return true
+		})
+		if !shouldRetry {
+			return res, err
+		}
+		if res != nil {
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ex.New(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// bufferRequestBody reads a request body into memory so it can be replayed,
+// if the request does not already have a way to get a copy of the body.
+func bufferRequestBody(req *http.Request) error {
+	if req.GetBody != nil || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	contents, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return ex.New(err)
+	}
+	if err = req.Body.Close(); err != nil {
+		return ex.New(err)
+	}
+	req.ContentLength = int64(len(contents))
+	req.Body = ioutil.NopCloser(bytes.NewReader(contents))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(contents)), nil
+	}
+	return nil
+}
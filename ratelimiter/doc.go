@@ -6,6 +6,17 @@ Use of this source code is governed by a MIT license that can be found in the LI
 */
 
 /*
-Package ratelimiter implements two common rate limiters; queue and token/leaky bucket.
+Package ratelimiter implements common rate limiters.
+
+`LeakyBucket` and `Queue` are simple, single goroutine limiters.
+
+`TokenBucket`, `GCRA`, `SlidingWindowLog` and `SlidingWindowCounter` are safe for concurrent use and
+implement `Limiter`, which adds `Allow`, `Reserve` and `Wait` with retry after durations.
+
+Ids that have fully recovered are removed by `Sweep`, which can be run periodically with an `async.Interval`:
+
+	limiter := ratelimiter.NewTokenBucket(10, time.Second)
+	sweeper := async.NewInterval(limiter.Sweep, time.Minute)
+	go sweeper.Start()
 */
 package ratelimiter // import "github.com/blend/go-sdk/ratelimiter"
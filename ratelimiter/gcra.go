@@ -0,0 +1,131 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	_ Limiter = (*GCRA)(nil)
+)
+
+// NewGCRA returns a new concurrency safe generic cell rate algorithm limiter.
+// The rate is numActions/quantum with a burst of numActions.
+func NewGCRA(numActions int, quantum time.Duration) *GCRA {
+	return &GCRA{
+		NumActions: numActions,
+		Quantum:    quantum,
+		Burst:      numActions,
+		Now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// GCRA implements the generic cell rate algorithm.
+//
+// It tracks a single theoretical arrival time per id, which makes it
+// equivalent to a token bucket with a smaller memory footprint.
+type GCRA struct {
+	NumActions int
+	Quantum    time.Duration
+	// Burst is the number of actions allowed at once; it is at least 1.
+	Burst int
+	Now   func() time.Time
+
+	mu          sync.Mutex
+	arrivalTime map[string]time.Time
+}
+
+// Check returns true if an id has exceeded the rate limit, and false otherwise.
+func (g *GCRA) Check(id string) bool {
+	allowed, _ := g.Allow(id)
+	return !allowed
+}
+
+// Allow returns if an action for the id conforms to the rate.
+func (g *GCRA) Allow(id string) (bool, time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.Now()
+	tat, allowAt := g.nextUnsafe(id, now)
+	if now.Before(allowAt) {
+		return false, allowAt.Sub(now)
+	}
+	g.arrivalTime[id] = tat.Add(g.emissionInterval())
+	return true, 0
+}
+
+// Reserve advances the theoretical arrival time for the id and returns when the action conforms.
+func (g *GCRA) Reserve(id string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.Now()
+	tat, allowAt := g.nextUnsafe(id, now)
+	g.arrivalTime[id] = tat.Add(g.emissionInterval())
+	if now.Before(allowAt) {
+		return allowAt.Sub(now)
+	}
+	return 0
+}
+
+// Wait blocks until an action for the id conforms to the rate.
+func (g *GCRA) Wait(ctx context.Context, id string) error {
+	return wait(ctx, g.Allow, id)
+}
+
+// Sweep removes ids whose theoretical arrival time has passed.
+func (g *GCRA) Sweep(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.Now()
+	for id, tat := range g.arrivalTime {
+		if !tat.After(now) {
+			delete(g.arrivalTime, id)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of ids tracked by the limiter.
+func (g *GCRA) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.arrivalTime)
+}
+
+// nextUnsafe returns the effective theoretical arrival time for the id
+// and the earliest time the next action conforms.
+func (g *GCRA) nextUnsafe(id string, now time.Time) (tat, allowAt time.Time) {
+	if g.arrivalTime == nil {
+		g.arrivalTime = make(map[string]time.Time)
+	}
+	tat = g.arrivalTime[id]
+	if tat.Before(now) {
+		tat = now
+	}
+	burst := g.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	allowAt = tat.Add(-g.emissionInterval() * time.Duration(burst-1))
+	return
+}
+
+// emissionInterval returns the time between actions at the steady rate.
+func (g *GCRA) emissionInterval() time.Duration {
+	numActions := g.NumActions
+	if numActions < 1 {
+		numActions = 1
+	}
+	return g.Quantum / time.Duration(numActions)
+}
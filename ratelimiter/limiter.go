@@ -0,0 +1,44 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter is a concurrency safe rate limiter that reports how long a limited id must wait.
+type Limiter interface {
+	RateLimiter
+	// Allow returns if an action for the id is allowed now, consuming capacity if it is.
+	// If the action is not allowed, the retry after duration is returned and no capacity is consumed.
+	Allow(id string) (allowed bool, retryAfter time.Duration)
+	// Reserve consumes capacity for the id and returns how long the caller must wait before acting.
+	Reserve(id string) (delay time.Duration)
+	// Wait blocks until an action for the id is allowed or the context is done.
+	Wait(ctx context.Context, id string) error
+	// Sweep removes ids that have fully recovered their capacity.
+	Sweep(ctx context.Context) error
+}
+
+// wait polls a limiter's allow function until the id is allowed or the context is done.
+func wait(ctx context.Context, allow func(string) (bool, time.Duration), id string) error {
+	for {
+		allowed, retryAfter := allow(id)
+		if allowed {
+			return nil
+		}
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
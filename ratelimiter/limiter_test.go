@@ -0,0 +1,76 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestLimiterWait(t *testing.T) {
+	its := assert.New(t)
+
+	limiters := []Limiter{
+		NewTokenBucket(1, 10*time.Millisecond),
+		NewGCRA(1, 10*time.Millisecond),
+		NewSlidingWindowLog(1, 10*time.Millisecond),
+		NewSlidingWindowCounter(1, 10*time.Millisecond),
+	}
+	for _, limiter := range limiters {
+		started := time.Now()
+		for x := 0; x < 3; x++ {
+			its.Nil(limiter.Wait(context.Background(), "a"))
+		}
+		its.True(time.Since(started) >= 15*time.Millisecond, fmt.Sprintf("%T should have waited", limiter))
+	}
+}
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	its := assert.New(t)
+
+	limiter := NewTokenBucket(1, time.Hour)
+	its.Nil(limiter.Wait(context.Background(), "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	its.Equal(context.DeadlineExceeded, limiter.Wait(ctx, "a"))
+}
+
+func TestLimiterConcurrent(t *testing.T) {
+	its := assert.New(t)
+
+	limiters := []Limiter{
+		NewTokenBucket(50, time.Hour),
+		NewGCRA(50, time.Hour),
+		NewSlidingWindowLog(50, time.Hour),
+		NewSlidingWindowCounter(50, time.Hour),
+	}
+	for _, limiter := range limiters {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var allowed int
+		for x := 0; x < 100; x++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, _ := limiter.Allow("a"); ok {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		its.Equal(50, allowed, fmt.Sprintf("%T", limiter))
+	}
+}
@@ -0,0 +1,158 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	_ Limiter = (*SlidingWindowCounter)(nil)
+)
+
+// NewSlidingWindowCounter returns a new concurrency safe sliding window counter rate limiter.
+// It allows approximately numActions in any window of length quantum.
+func NewSlidingWindowCounter(numActions int, quantum time.Duration) *SlidingWindowCounter {
+	return &SlidingWindowCounter{
+		NumActions: numActions,
+		Quantum:    quantum,
+		Now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SlidingWindowCounter approximates a sliding window by weighting the previous fixed window's count
+// by how much of it still overlaps the sliding window.
+//
+// It uses constant memory per id, as opposed to SlidingWindowLog which keeps every timestamp.
+type SlidingWindowCounter struct {
+	NumActions int
+	Quantum    time.Duration
+	Now        func() time.Time
+
+	mu     sync.Mutex
+	counts map[string]map[int64]int
+}
+
+// Check returns true if an id has exceeded the rate limit, and false otherwise.
+func (swc *SlidingWindowCounter) Check(id string) bool {
+	allowed, _ := swc.Allow(id)
+	return !allowed
+}
+
+// Allow counts an action for the id if the estimated count in the window is under NumActions.
+func (swc *SlidingWindowCounter) Allow(id string) (bool, time.Duration) {
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+
+	now := swc.Now().UnixNano()
+	at, window := swc.nextUnsafe(id, now)
+	if at > now {
+		return false, time.Duration(at - now)
+	}
+	swc.counts[id][window]++
+	return true, 0
+}
+
+// Reserve counts an action for the id in the window it is next allowed in.
+func (swc *SlidingWindowCounter) Reserve(id string) time.Duration {
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+
+	now := swc.Now().UnixNano()
+	at, window := swc.nextUnsafe(id, now)
+	swc.counts[id][window]++
+	return time.Duration(at - now)
+}
+
+// Wait blocks until an action for the id is allowed.
+func (swc *SlidingWindowCounter) Wait(ctx context.Context, id string) error {
+	return wait(ctx, swc.Allow, id)
+}
+
+// Sweep removes ids with no actions in the current or previous window.
+func (swc *SlidingWindowCounter) Sweep(ctx context.Context) error {
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+
+	now := swc.Now().UnixNano()
+	for id := range swc.counts {
+		if len(swc.pruneUnsafe(id, now)) == 0 {
+			delete(swc.counts, id)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of ids tracked by the limiter.
+func (swc *SlidingWindowCounter) Len() int {
+	swc.mu.Lock()
+	defer swc.mu.Unlock()
+	return len(swc.counts)
+}
+
+// nextUnsafe returns the earliest time (in unix nanos) at or after now
+// an action for the id is allowed, and the window that time falls in.
+func (swc *SlidingWindowCounter) nextUnsafe(id string, now int64) (at, window int64) {
+	if swc.counts == nil {
+		swc.counts = make(map[string]map[int64]int)
+	}
+	counts := swc.pruneUnsafe(id, now)
+
+	limit := swc.NumActions
+	if limit < 1 {
+		limit = 1
+	}
+	width := swc.width()
+	window = now / width
+	offset := now - window*width
+	for {
+		current, previous := counts[window], counts[window-1]
+		if current < limit {
+			// the previous window's weight decays linearly as the sliding window moves past it;
+			// find the offset where previous*(1-offset/width) + current + 1 <= limit.
+			if previous > 0 {
+				remaining := 1 - float64(limit-1-current)/float64(previous)
+				if minimum := int64(math.Ceil(remaining * float64(width))); minimum > offset {
+					offset = minimum
+				}
+			}
+			if offset < width {
+				return window*width + offset, window
+			}
+		}
+		window++
+		offset = 0
+	}
+}
+
+// pruneUnsafe removes windows that no longer overlap the sliding window ending at now.
+func (swc *SlidingWindowCounter) pruneUnsafe(id string, now int64) map[int64]int {
+	counts, ok := swc.counts[id]
+	if !ok {
+		counts = make(map[int64]int)
+		swc.counts[id] = counts
+	}
+	current := now / swc.width()
+	for window := range counts {
+		if window < current-1 {
+			delete(counts, window)
+		}
+	}
+	return counts
+}
+
+// width returns the window width in nanos, which is at least one so windows can always be computed.
+func (swc *SlidingWindowCounter) width() int64 {
+	if swc.Quantum < 1 {
+		return 1
+	}
+	return int64(swc.Quantum)
+}
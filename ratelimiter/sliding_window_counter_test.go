@@ -0,0 +1,97 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestSlidingWindowCounterAllow(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowCounter(4, time.Second) // 4 actions per second
+	now := time.Unix(100, 0)
+	rl.Now = Clock(now, 0)
+
+	for x := 0; x < 4; x++ {
+		allowed, retryAfter := rl.Allow("a")
+		its.True(allowed)
+		its.Zero(retryAfter)
+	}
+
+	// the previous window must decay by one action before the next is allowed.
+	allowed, retryAfter := rl.Allow("a")
+	its.False(allowed)
+	its.Equal(1250*time.Millisecond, retryAfter)
+	its.True(rl.Check("a"))
+	its.False(rl.Check("b"), "ids should be limited independently")
+
+	rl.Now = Clock(now, 1250*time.Millisecond)
+	allowed, _ = rl.Allow("a")
+	its.True(allowed)
+
+	rl.Now = Clock(now, 1500*time.Millisecond)
+	allowed, retryAfter = rl.Allow("a")
+	its.True(allowed)
+	its.Zero(retryAfter)
+	allowed, retryAfter = rl.Allow("a")
+	its.False(allowed)
+	its.Equal(250*time.Millisecond, retryAfter)
+}
+
+func TestSlidingWindowCounterReserve(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowCounter(2, time.Second)
+	now := time.Unix(100, 0)
+	rl.Now = Clock(now, 0)
+
+	its.Zero(rl.Reserve("a"))
+	its.Zero(rl.Reserve("a"))
+	its.Equal(1500*time.Millisecond, rl.Reserve("a"))
+	its.Equal(2*time.Second, rl.Reserve("a"))
+}
+
+func TestSlidingWindowCounterSweep(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowCounter(2, time.Second)
+	now := time.Unix(100, 0)
+	rl.Now = Clock(now, 0)
+	rl.Allow("a")
+	its.Equal(1, rl.Len())
+
+	rl.Now = Clock(now, 1500*time.Millisecond)
+	its.Nil(rl.Sweep(context.Background()))
+	its.Equal(1, rl.Len())
+
+	rl.Now = Clock(now, 2*time.Second)
+	its.Nil(rl.Sweep(context.Background()))
+	its.Zero(rl.Len())
+}
+
+func TestSlidingWindowCounterNonPositiveQuantum(t *testing.T) {
+	its := assert.New(t)
+
+	for _, quantum := range []time.Duration{0, -time.Second} {
+		rl := NewSlidingWindowCounter(1, quantum)
+		rl.Now = Clock(time.Unix(100, 0), 0)
+
+		// quantums below 1ns are clamped to 1ns windows.
+		allowed, retryAfter := rl.Allow("a")
+		its.True(allowed)
+		its.Zero(retryAfter)
+		allowed, retryAfter = rl.Allow("a")
+		its.False(allowed)
+		its.True(retryAfter > 0 && retryAfter <= 2*time.Nanosecond, retryAfter)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	_ Limiter = (*SlidingWindowLog)(nil)
+)
+
+// NewSlidingWindowLog returns a new concurrency safe sliding window log rate limiter.
+// It allows numActions in any window of length quantum.
+func NewSlidingWindowLog(numActions int, quantum time.Duration) *SlidingWindowLog {
+	return &SlidingWindowLog{
+		NumActions: numActions,
+		Quantum:    quantum,
+		Now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SlidingWindowLog implements an exact sliding window by keeping a log of action timestamps per id.
+type SlidingWindowLog struct {
+	NumActions int
+	Quantum    time.Duration
+	Now        func() time.Time
+
+	mu   sync.Mutex
+	logs map[string][]time.Time
+}
+
+// Check returns true if an id has exceeded the rate limit, and false otherwise.
+func (swl *SlidingWindowLog) Check(id string) bool {
+	allowed, _ := swl.Allow(id)
+	return !allowed
+}
+
+// Allow records an action for the id if fewer than NumActions were recorded in the window.
+func (swl *SlidingWindowLog) Allow(id string) (bool, time.Duration) {
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+
+	now := swl.Now()
+	at := swl.nextUnsafe(id, now)
+	if at.After(now) {
+		return false, at.Sub(now)
+	}
+	swl.logs[id] = append(swl.logs[id], now)
+	return true, 0
+}
+
+// Reserve records an action for the id at the earliest time it fits in the window.
+func (swl *SlidingWindowLog) Reserve(id string) time.Duration {
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+
+	now := swl.Now()
+	at := swl.nextUnsafe(id, now)
+	swl.logs[id] = append(swl.logs[id], at)
+	return at.Sub(now)
+}
+
+// Wait blocks until an action for the id fits in the window.
+func (swl *SlidingWindowLog) Wait(ctx context.Context, id string) error {
+	return wait(ctx, swl.Allow, id)
+}
+
+// Sweep removes ids with no actions in the window.
+func (swl *SlidingWindowLog) Sweep(ctx context.Context) error {
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+
+	now := swl.Now()
+	for id := range swl.logs {
+		if len(swl.pruneUnsafe(id, now)) == 0 {
+			delete(swl.logs, id)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of ids tracked by the limiter.
+func (swl *SlidingWindowLog) Len() int {
+	swl.mu.Lock()
+	defer swl.mu.Unlock()
+	return len(swl.logs)
+}
+
+// nextUnsafe returns the earliest time at or after now an action for the id fits in the window.
+func (swl *SlidingWindowLog) nextUnsafe(id string, now time.Time) time.Time {
+	if swl.logs == nil {
+		swl.logs = make(map[string][]time.Time)
+	}
+	log := swl.pruneUnsafe(id, now)
+	limit := swl.NumActions
+	if limit < 1 {
+		limit = 1
+	}
+	if len(log) < limit {
+		return now
+	}
+	// the log is sorted, so the action limit back must leave the window first.
+	if at := log[len(log)-limit].Add(swl.Quantum); at.After(now) {
+		return at
+	}
+	return now
+}
+
+// pruneUnsafe removes timestamps that have left the window ending at now.
+func (swl *SlidingWindowLog) pruneUnsafe(id string, now time.Time) []time.Time {
+	log := swl.logs[id]
+	cutoff := now.Add(-swl.Quantum)
+	var index int
+	for index < len(log) && !log[index].After(cutoff) {
+		index++
+	}
+	log = log[index:]
+	swl.logs[id] = log
+	return log
+}
@@ -0,0 +1,96 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestSlidingWindowLogAllow(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowLog(3, time.Second) // 3 actions per second
+	now := time.Now()
+
+	for x := 0; x < 3; x++ {
+		rl.Now = Clock(now, time.Duration(x)*100*time.Millisecond)
+		allowed, retryAfter := rl.Allow("a")
+		its.True(allowed)
+		its.Zero(retryAfter)
+	}
+
+	rl.Now = Clock(now, 500*time.Millisecond)
+	allowed, retryAfter := rl.Allow("a")
+	its.False(allowed)
+	its.Equal(500*time.Millisecond, retryAfter)
+	its.True(rl.Check("a"))
+	its.False(rl.Check("b"), "ids should be limited independently")
+
+	rl.Now = Clock(now, 999*time.Millisecond)
+	its.True(rl.Check("a"), "the first action should still be in the window")
+
+	rl.Now = Clock(now, 1001*time.Millisecond)
+	allowed, _ = rl.Allow("a")
+	its.True(allowed)
+	allowed, retryAfter = rl.Allow("a")
+	its.False(allowed)
+	its.Equal(99*time.Millisecond, retryAfter)
+}
+
+func TestSlidingWindowLogReserve(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowLog(2, time.Second)
+	now := time.Now()
+	rl.Now = Clock(now, 0)
+
+	its.Zero(rl.Reserve("a"))
+	its.Zero(rl.Reserve("a"))
+	its.Equal(time.Second, rl.Reserve("a"))
+	its.Equal(time.Second, rl.Reserve("a"))
+	its.Equal(2*time.Second, rl.Reserve("a"))
+}
+
+func TestSlidingWindowLogSweep(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowLog(3, time.Second)
+	now := time.Now()
+	rl.Now = Clock(now, 0)
+	rl.Allow("a")
+	rl.Now = Clock(now, 500*time.Millisecond)
+	rl.Allow("b")
+	its.Equal(2, rl.Len())
+
+	rl.Now = Clock(now, time.Second)
+	its.Nil(rl.Sweep(context.Background()))
+	its.Equal(1, rl.Len())
+
+	rl.Now = Clock(now, 1500*time.Millisecond)
+	its.Nil(rl.Sweep(context.Background()))
+	its.Zero(rl.Len())
+}
+
+func TestSlidingWindowLogNonPositiveLimit(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewSlidingWindowLog(0, time.Second)
+	rl.Now = Clock(time.Unix(100, 0), 0)
+
+	// limits below 1 are clamped to a single action per quantum.
+	allowed, retryAfter := rl.Allow("a")
+	its.True(allowed)
+	its.Zero(retryAfter)
+	allowed, retryAfter = rl.Allow("a")
+	its.False(allowed)
+	its.Equal(time.Second, retryAfter)
+}
@@ -0,0 +1,140 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	_ Limiter = (*TokenBucket)(nil)
+)
+
+// NewTokenBucket returns a new concurrency safe token bucket rate limiter.
+// Tokens are refilled at numActions/quantum, and the bucket holds at most numActions tokens.
+func NewTokenBucket(numActions int, quantum time.Duration) *TokenBucket {
+	return &TokenBucket{
+		NumActions: numActions,
+		Quantum:    quantum,
+		Burst:      numActions,
+		Now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// TokenBucket implements the token bucket rate limiting algorithm.
+type TokenBucket struct {
+	NumActions int
+	Quantum    time.Duration
+	// Burst is the maximum number of tokens a bucket can hold; it is at least 1.
+	Burst int
+	Now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*Token
+}
+
+// Check returns true if an id has exceeded the rate limit, and false otherwise.
+func (tb *TokenBucket) Check(id string) bool {
+	allowed, _ := tb.Allow(id)
+	return !allowed
+}
+
+// Allow takes a token for the id if one is available.
+func (tb *TokenBucket) Allow(id string) (bool, time.Duration) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	token := tb.refillUnsafe(id, tb.Now())
+	if token.Count >= 1 {
+		token.Count--
+		return true, 0
+	}
+	return false, tb.durationFor(1 - token.Count)
+}
+
+// Reserve takes a token for the id, borrowing against future refills if the bucket is empty.
+func (tb *TokenBucket) Reserve(id string) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	token := tb.refillUnsafe(id, tb.Now())
+	token.Count--
+	if token.Count >= 0 {
+		return 0
+	}
+	return tb.durationFor(-token.Count)
+}
+
+// Wait blocks until a token is available for the id.
+func (tb *TokenBucket) Wait(ctx context.Context, id string) error {
+	return wait(ctx, tb.Allow, id)
+}
+
+// Sweep removes ids whose buckets have refilled.
+func (tb *TokenBucket) Sweep(ctx context.Context) error {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := tb.Now()
+	for id := range tb.buckets {
+		if token := tb.refillUnsafe(id, now); token.Count >= tb.burst() {
+			delete(tb.buckets, id)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of ids tracked by the limiter.
+func (tb *TokenBucket) Len() int {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return len(tb.buckets)
+}
+
+// refillUnsafe returns the token for an id, refilled as of now.
+func (tb *TokenBucket) refillUnsafe(id string, now time.Time) *Token {
+	if tb.buckets == nil {
+		tb.buckets = make(map[string]*Token)
+	}
+	token, ok := tb.buckets[id]
+	if !ok {
+		token = &Token{Count: tb.burst(), Last: now}
+		tb.buckets[id] = token
+		return token
+	}
+	if elapsed := now.Sub(token.Last); elapsed > 0 {
+		token.Count = math.Min(tb.burst(), token.Count+float64(elapsed)*tb.rate())
+		token.Last = now
+	}
+	return token
+}
+
+// burst returns the maximum number of tokens, which is at least one so a token can always be taken.
+func (tb *TokenBucket) burst() float64 {
+	if tb.Burst < 1 {
+		return 1
+	}
+	return float64(tb.Burst)
+}
+
+// rate returns the tokens added per nanosecond.
+func (tb *TokenBucket) rate() float64 {
+	numActions := tb.NumActions
+	if numActions < 1 {
+		numActions = 1
+	}
+	return float64(numActions) / float64(tb.Quantum)
+}
+
+// durationFor returns how long it takes to refill a given number of tokens.
+func (tb *TokenBucket) durationFor(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / tb.rate()))
+}
@@ -0,0 +1,103 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package ratelimiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestTokenBucketAllow(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewTokenBucket(5, time.Second) // 5 actions per second
+	now := time.Now()
+	rl.Now = Clock(now, 0)
+
+	for x := 0; x < 5; x++ {
+		allowed, retryAfter := rl.Allow("a")
+		its.True(allowed)
+		its.Zero(retryAfter)
+	}
+	allowed, retryAfter := rl.Allow("a")
+	its.False(allowed)
+	its.Equal(200*time.Millisecond, retryAfter)
+	its.True(rl.Check("a"))
+	its.False(rl.Check("b"), "ids should be limited independently")
+
+	rl.Now = Clock(now, 100*time.Millisecond)
+	allowed, retryAfter = rl.Allow("a")
+	its.False(allowed)
+	its.Equal(100*time.Millisecond, retryAfter)
+
+	rl.Now = Clock(now, 200*time.Millisecond)
+	allowed, _ = rl.Allow("a")
+	its.True(allowed)
+}
+
+func TestTokenBucketReserve(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewTokenBucket(5, time.Second)
+	rl.Burst = 1
+	now := time.Now()
+	rl.Now = Clock(now, 0)
+
+	its.Zero(rl.Reserve("a"))
+	its.Equal(200*time.Millisecond, rl.Reserve("a"))
+	its.Equal(400*time.Millisecond, rl.Reserve("a"))
+
+	allowed, retryAfter := rl.Allow("a")
+	its.False(allowed)
+	its.Equal(600*time.Millisecond, retryAfter)
+}
+
+func TestTokenBucketSweep(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewTokenBucket(5, time.Second)
+	now := time.Now()
+	rl.Now = Clock(now, 0)
+
+	rl.Allow("a")
+	rl.Allow("b")
+	rl.Allow("b")
+	its.Equal(2, rl.Len())
+
+	rl.Now = Clock(now, 200*time.Millisecond)
+	its.Nil(rl.Sweep(context.Background()))
+	its.Equal(1, rl.Len())
+
+	rl.Now = Clock(now, 400*time.Millisecond)
+	its.Nil(rl.Sweep(context.Background()))
+	its.Zero(rl.Len())
+}
+
+func TestTokenBucketNonPositiveLimit(t *testing.T) {
+	its := assert.New(t)
+
+	rl := NewTokenBucket(0, time.Second)
+	rl.Now = Clock(time.Unix(100, 0), 0)
+
+	// limits below 1 are clamped to a single action per quantum.
+	allowed, retryAfter := rl.Allow("a")
+	its.True(allowed)
+	its.Zero(retryAfter)
+	allowed, retryAfter = rl.Allow("a")
+	its.False(allowed)
+	its.Equal(time.Second, retryAfter)
+
+	rl = NewTokenBucket(0, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	its.Nil(rl.Wait(ctx, "a"))
+	its.Nil(rl.Wait(ctx, "a"))
+}
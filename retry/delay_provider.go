@@ -9,6 +9,7 @@ package retry
 
 import (
 	"context"
+	"math/rand"
 	"time"
 )
 
@@ -28,3 +29,18 @@ func ExponentialBackoff(d time.Duration) DelayProvider {
 		return d * (1 << attempt)
 	}
 }
+
+// ExponentialBackoffWithJitter is a backoff provider that doubles the base delay each attempt,
+// and returns a random delay between half and all of that value.
+//
+// The jitter spreads out retries from many callers that failed at the same time.
+func ExponentialBackoffWithJitter(d time.Duration) DelayProvider {
+	return func(_ context.Context, attempt uint) time.Duration {
+		backoff := d * (1 << attempt)
+		if backoff <= 1 {
+			return backoff
+		}
+		half := backoff / 2
+		return half + time.Duration(rand.Int63n(int64(backoff-half)))
+	}
+}
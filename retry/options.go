@@ -48,6 +48,11 @@ func OptExponentialBackoff(d time.Duration) Option {
 	return func(o *Options) { o.DelayProvider = ExponentialBackoff(d) }
 }
 
+// OptExponentialBackoffWithJitter sets the retry delay provider.
+func OptExponentialBackoffWithJitter(d time.Duration) Option {
+	return func(o *Options) { o.DelayProvider = ExponentialBackoffWithJitter(d) }
+}
+
 // OptShouldRetryProvider sets the should retry provider.
 func OptShouldRetryProvider(provider ShouldRetryProvider) Option {
 	return func(o *Options) { o.ShouldRetryProvider = provider }
@@ -81,3 +81,18 @@ func TestRetry_ShouldRetryProvider(t *testing.T) {
 	assert.Equal(fmt.Errorf("attempt 2"), <-results)
 	assert.Equal(fmt.Errorf("attempt 3"), <-results)
 }
+
+func TestExponentialBackoffWithJitter(t *testing.T) {
+	assert := assert.New(t)
+
+	provider := ExponentialBackoffWithJitter(100 * time.Millisecond)
+	for attempt := uint(0); attempt < 4; attempt++ {
+		backoff := (100 * time.Millisecond) * (1 << attempt)
+		for x := 0; x < 16; x++ {
+			delay := provider(context.Background(), attempt)
+			assert.True(delay >= backoff/2, delay.String())
+			assert.True(delay < backoff, delay.String())
+		}
+	}
+	assert.Zero(ExponentialBackoffWithJitter(0)(context.Background(), 3))
+}
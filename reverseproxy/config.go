@@ -0,0 +1,187 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Config is the routing config for a proxy.
+type Config struct {
+	Routes []RouteConfig `json:"routes,omitempty" yaml:"routes,omitempty"`
+}
+
+// RouteConfig is the config for a route.
+type RouteConfig struct {
+	Name          string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Host          string            `json:"host,omitempty" yaml:"host,omitempty"`
+	PathPrefix    string            `json:"pathPrefix,omitempty" yaml:"pathPrefix,omitempty"`
+	StripPrefix   bool              `json:"stripPrefix,omitempty" yaml:"stripPrefix,omitempty"`
+	RewritePrefix string            `json:"rewritePrefix,omitempty" yaml:"rewritePrefix,omitempty"`
+	Resolver      string            `json:"resolver,omitempty" yaml:"resolver,omitempty"`
+	HashHeader    string            `json:"hashHeader,omitempty" yaml:"hashHeader,omitempty"`
+	HashCookie    string            `json:"hashCookie,omitempty" yaml:"hashCookie,omitempty"`
+	AddHeaders    map[string]string `json:"addHeaders,omitempty" yaml:"addHeaders,omitempty"`
+	SetHeaders    map[string]string `json:"setHeaders,omitempty" yaml:"setHeaders,omitempty"`
+	DeleteHeaders []string          `json:"deleteHeaders,omitempty" yaml:"deleteHeaders,omitempty"`
+	MaxAttempts   int               `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	RetryMethods  []string          `json:"retryMethods,omitempty" yaml:"retryMethods,omitempty"`
+	Mirror        *MirrorConfig     `json:"mirror,omitempty" yaml:"mirror,omitempty"`
+	Upstreams     []UpstreamConfig  `json:"upstreams,omitempty" yaml:"upstreams,omitempty"`
+}
+
+// UpstreamConfig is the config for a route upstream.
+type UpstreamConfig struct {
+	Name                  string        `json:"name,omitempty" yaml:"name,omitempty"`
+	URL                   string        `json:"url,omitempty" yaml:"url,omitempty"`
+	Weight                int           `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Timeout               time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	ResponseHeaderTimeout time.Duration `json:"responseHeaderTimeout,omitempty" yaml:"responseHeaderTimeout,omitempty"`
+}
+
+// Upstream returns a new upstream for the config.
+func (uc UpstreamConfig) Upstream(options ...UpstreamOption) (*Upstream, error) {
+	target, err := url.Parse(uc.URL)
+	if err != nil {
+		return nil, ex.New(err, ex.OptMessagef("upstream: %s", uc.URL))
+	}
+	upstreamOptions := []UpstreamOption{
+		OptUpstreamName(uc.Name),
+		OptUpstreamWeight(uc.Weight),
+		OptUpstreamTimeout(uc.Timeout),
+	}
+	if uc.ResponseHeaderTimeout > 0 {
+		upstreamOptions = append(upstreamOptions, OptUpstreamResponseHeaderTimeout(uc.ResponseHeaderTimeout))
+	}
+	return NewUpstream(target, append(upstreamOptions, options...)...), nil
+}
+
+// MirrorConfig is the config for a route mirror.
+type MirrorConfig struct {
+	Percent  float64        `json:"percent,omitempty" yaml:"percent,omitempty"`
+	Upstream UpstreamConfig `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+}
+
+// Route returns a new route for the config.
+//
+// The upstream options are applied to each of the route's upstreams.
+func (rc RouteConfig) Route(options ...UpstreamOption) (*Route, error) {
+	if len(rc.Upstreams) == 0 {
+		return nil, ex.New(ErrInvalidRoute, ex.OptMessagef("route: %s, no upstreams", rc.Name))
+	}
+	resolver, err := ParseResolver(rc.Resolver, rc.HashKeyProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	routeOptions := []RouteOption{
+		OptRouteName(rc.Name),
+		OptRouteHost(rc.Host),
+		OptRoutePathPrefix(rc.PathPrefix),
+		OptRouteRewritePrefix(rc.RewritePrefix),
+		OptRouteResolver(resolver),
+	}
+	if rc.StripPrefix {
+		routeOptions = append(routeOptions, OptRouteStripPrefix())
+	}
+	for key, value := range rc.AddHeaders {
+		routeOptions = append(routeOptions, OptRouteAddHeaderValue(key, value))
+	}
+	for key, value := range rc.SetHeaders {
+		routeOptions = append(routeOptions, OptRouteSetHeaderValue(key, value))
+	}
+	for _, key := range rc.DeleteHeaders {
+		routeOptions = append(routeOptions, OptRouteDeleteHeader(key))
+	}
+	if rc.MaxAttempts > 1 {
+		routeOptions = append(routeOptions, OptRouteRetry(rc.MaxAttempts, rc.RetryMethods...))
+	}
+	for _, uc := range rc.Upstreams {
+		upstream, err := uc.Upstream(options...)
+		if err != nil {
+			return nil, ex.New(ErrInvalidRoute, ex.OptMessagef("route: %s", rc.Name), ex.OptInner(err))
+		}
+		routeOptions = append(routeOptions, OptRouteUpstream(upstream))
+	}
+	if rc.Mirror != nil {
+		upstream, err := rc.Mirror.Upstream.Upstream(options...)
+		if err != nil {
+			return nil, ex.New(ErrInvalidRoute, ex.OptMessagef("route: %s, mirror", rc.Name), ex.OptInner(err))
+		}
+		routeOptions = append(routeOptions, OptRouteMirror(upstream, rc.Mirror.Percent))
+	}
+	return NewRoute(routeOptions...), nil
+}
+
+// HashKeyProvider returns the hash key provider for the consistent hash resolver.
+//
+// It uses the hash header if set, then the hash cookie if set, and otherwise the request path.
+func (rc RouteConfig) HashKeyProvider() HashKeyProvider {
+	if rc.HashHeader != "" {
+		return HashKeyHeader(rc.HashHeader)
+	}
+	if rc.HashCookie != "" {
+		return HashKeyCookie(rc.HashCookie)
+	}
+	return HashKeyPath()
+}
+
+// ParseResolver returns a new resolver by name.
+//
+// It returns a nil resolver for round robin (or an empty name), so the proxy or route
+// uses its default round robin resolver. The hash key provider is only used by the
+// consistent hash resolver.
+func ParseResolver(name string, hashKeyProvider HashKeyProvider) (Resolver, error) {
+	switch name {
+	case "", ResolverRoundRobin:
+		return nil, nil
+	case ResolverWeightedRoundRobin:
+		return WeightedRoundRobinResolver(), nil
+	case ResolverLeastConnections:
+		return LeastConnectionsResolver(), nil
+	case ResolverRandomTwoChoices:
+		return RandomTwoChoicesResolver(), nil
+	case ResolverConsistentHash:
+		if hashKeyProvider == nil {
+			hashKeyProvider = HashKeyPath()
+		}
+		return ConsistentHashResolver(hashKeyProvider), nil
+	default:
+		return nil, ex.New(ErrInvalidResolver, ex.OptMessagef("resolver: %s", name))
+	}
+}
+
+// ParseRouteConfig parses a route from a string of the form `host/path=upstream[,upstream...]`.
+//
+// The host is optional, e.g. `/api=http://localhost:8080` matches any host.
+func ParseRouteConfig(value string) (RouteConfig, error) {
+	pieces := strings.SplitN(value, "=", 2)
+	if len(pieces) < 2 || pieces[1] == "" {
+		return RouteConfig{}, ex.New(ErrInvalidRoute, ex.OptMessagef("route: %s; must be in the form host/path=upstream[,upstream...]", value))
+	}
+
+	rc := RouteConfig{
+		Name: pieces[0],
+	}
+	if index := strings.Index(pieces[0], "/"); index >= 0 {
+		rc.Host = pieces[0][:index]
+		rc.PathPrefix = pieces[0][index:]
+	} else {
+		rc.Host = pieces[0]
+	}
+	for _, upstream := range strings.Split(pieces[1], ",") {
+		if upstream = strings.TrimSpace(upstream); upstream != "" {
+			rc.Upstreams = append(rc.Upstreams, UpstreamConfig{URL: upstream})
+		}
+	}
+	return rc, nil
+}
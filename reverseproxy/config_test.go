@@ -0,0 +1,134 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestConfigRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	contents := `
+routes:
+- name: api
+  host: "*.example.com"
+  pathPrefix: /api
+  stripPrefix: true
+  rewritePrefix: /v1
+  resolver: weighted-round-robin
+  setHeaders:
+    X-Route: api
+  deleteHeaders:
+  - Cookie
+  maxAttempts: 3
+  mirror:
+    percent: 10
+    upstream:
+      url: http://localhost:9090
+  upstreams:
+  - name: api-1
+    url: http://localhost:8080
+    weight: 3
+    timeout: 5s
+  - url: http://localhost:8081
+`
+	var cfg Config
+	assert.Nil(yaml.Unmarshal([]byte(contents), &cfg))
+	assert.Len(cfg.Routes, 1)
+
+	route, err := cfg.Routes[0].Route(OptUpstreamPassiveHealthCheck(3, 0))
+	assert.Nil(err)
+	assert.Equal("api", route.Name)
+	assert.Equal("*.example.com", route.Host)
+	assert.Equal("/api", route.PathPrefix)
+	assert.True(route.StripPrefix)
+	assert.Equal("/v1", route.RewritePrefix)
+	assert.NotNil(route.Resolver)
+	assert.Equal("api", route.SetHeaders.Get("X-Route"))
+	assert.Equal([]string{"Cookie"}, route.DeleteHeaders)
+	assert.Len(route.Upstreams, 2)
+	assert.Equal("api-1", route.Upstreams[0].Name)
+	assert.Equal(3, route.Upstreams[0].Weight)
+	assert.Equal("http://localhost:8081", route.Upstreams[1].URL.String())
+	assert.NotNil(route.Upstreams[1].PassiveHealthCheck)
+	assert.Equal(5*time.Second, route.Upstreams[0].Timeout)
+	assert.NotNil(route.Retry)
+	assert.Equal(3, route.Retry.MaxAttempts)
+	assert.NotNil(route.Mirror)
+	assert.Equal(10, route.Mirror.Percent)
+	assert.Equal("http://localhost:9090", route.Mirror.Upstream.URL.String())
+}
+
+func TestConfigRouteInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := RouteConfig{Name: "empty"}.Route()
+	assert.True(ex.Is(err, ErrInvalidRoute))
+
+	_, err = RouteConfig{Name: "bad-resolver", Resolver: "nope", Upstreams: []UpstreamConfig{{URL: "http://localhost"}}}.Route()
+	assert.True(ex.Is(err, ErrInvalidResolver))
+
+	_, err = RouteConfig{Name: "bad-url", Upstreams: []UpstreamConfig{{URL: "://"}}}.Route()
+	assert.True(ex.Is(err, ErrInvalidRoute))
+}
+
+func TestParseResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{ResolverWeightedRoundRobin, ResolverLeastConnections, ResolverRandomTwoChoices, ResolverConsistentHash} {
+		resolver, err := ParseResolver(name, nil)
+		assert.Nil(err)
+		assert.NotNil(resolver)
+	}
+	resolver, err := ParseResolver(ResolverRoundRobin, nil)
+	assert.Nil(err)
+	assert.Nil(resolver)
+}
+
+func TestRouteConfigHashKeyProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/foo", nil)
+	req.Header.Set("X-Session", "header")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "cookie"})
+
+	assert.Equal("header", RouteConfig{HashHeader: "X-Session", HashCookie: "session"}.HashKeyProvider()(req))
+	assert.Equal("cookie", RouteConfig{HashCookie: "session"}.HashKeyProvider()(req))
+	assert.Equal("/foo", RouteConfig{}.HashKeyProvider()(req))
+}
+
+func TestParseRouteConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	rc, err := ParseRouteConfig("api.local/api=http://localhost:8080, http://localhost:8081")
+	assert.Nil(err)
+	assert.Equal("api.local", rc.Host)
+	assert.Equal("/api", rc.PathPrefix)
+	assert.Equal([]UpstreamConfig{{URL: "http://localhost:8080"}, {URL: "http://localhost:8081"}}, rc.Upstreams)
+
+	rc, err = ParseRouteConfig("/static=http://localhost:9090")
+	assert.Nil(err)
+	assert.Empty(rc.Host)
+	assert.Equal("/static", rc.PathPrefix)
+
+	rc, err = ParseRouteConfig("web.local=http://localhost:9090")
+	assert.Nil(err)
+	assert.Equal("web.local", rc.Host)
+	assert.Empty(rc.PathPrefix)
+
+	_, err = ParseRouteConfig("/api")
+	assert.True(ex.Is(err, ErrInvalidRoute))
+}
@@ -7,9 +7,59 @@ Use of this source code is governed by a MIT license that can be found in the LI
 
 package reverseproxy
 
+import (
+	"net/http"
+	"time"
+)
+
 const (
 	// DefaultAddr is the default reverse proxy address.
 	DefaultAddr = ":443"
 	// DefaultUpgradeAddr is the default upgrade address.
 	DefaultUpgradeAddr = ":80"
 )
+
+const (
+	// DefaultHealthCheckPath is the default active health check probe path.
+	DefaultHealthCheckPath = "/"
+	// DefaultHealthCheckInterval is the default interval between active health checks.
+	DefaultHealthCheckInterval = 10 * time.Second
+	// DefaultHealthCheckTimeout is the default timeout for an active health check probe.
+	DefaultHealthCheckTimeout = 5 * time.Second
+	// DefaultHealthCheckHealthyThreshold is the default number of consecutive passed probes that mark an upstream healthy.
+	DefaultHealthCheckHealthyThreshold = 2
+	// DefaultHealthCheckUnhealthyThreshold is the default number of consecutive failed probes that mark an upstream unhealthy.
+	DefaultHealthCheckUnhealthyThreshold = 3
+)
+
+const (
+	// DefaultPassiveHealthCheckMaxFailures is the default number of consecutive failed requests that eject an upstream.
+	DefaultPassiveHealthCheckMaxFailures = 5
+	// DefaultPassiveHealthCheckEjectionDuration is the default duration an upstream is ejected for.
+	DefaultPassiveHealthCheckEjectionDuration = 30 * time.Second
+)
+
+// Resolver names used in configs.
+const (
+	ResolverRoundRobin         = "round-robin"
+	ResolverWeightedRoundRobin = "weighted-round-robin"
+	ResolverLeastConnections   = "least-connections"
+	ResolverRandomTwoChoices   = "random-two-choices"
+	ResolverConsistentHash     = "consistent-hash"
+)
+
+const (
+	// DefaultMaxBufferedBodyBytes is the default maximum size of request bodies that are
+	// buffered in memory so they can be retried or mirrored.
+	DefaultMaxBufferedBodyBytes int64 = 1 << 20
+)
+
+// DefaultRetryMethods are the idempotent methods that are retried by default.
+var DefaultRetryMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodTrace,
+	http.MethodPut,
+	http.MethodDelete,
+}
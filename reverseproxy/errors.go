@@ -0,0 +1,21 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"github.com/blend/go-sdk/ex"
+)
+
+const (
+	// ErrHealthCheckStatus is returned when an active health check probe returns a non-success status.
+	ErrHealthCheckStatus ex.Class = "reverseproxy; health check returned a non-success status"
+	// ErrInvalidResolver is returned when a resolver name is not recognized.
+	ErrInvalidResolver ex.Class = "reverseproxy; invalid resolver"
+	// ErrInvalidRoute is returned when a route config is invalid.
+	ErrInvalidRoute ex.Class = "reverseproxy; invalid route"
+)
@@ -0,0 +1,198 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/async"
+	"github.com/blend/go-sdk/ex"
+)
+
+// NewHealthCheck returns a new active health check for a given set of upstreams.
+func NewHealthCheck(upstreams []*Upstream, options ...HealthCheckOption) *HealthCheck {
+	hc := HealthCheck{
+		Upstreams:          upstreams,
+		Path:               DefaultHealthCheckPath,
+		Interval:           DefaultHealthCheckInterval,
+		Timeout:            DefaultHealthCheckTimeout,
+		HealthyThreshold:   DefaultHealthCheckHealthyThreshold,
+		UnhealthyThreshold: DefaultHealthCheckUnhealthyThreshold,
+		counts:             make(map[*Upstream]*healthCheckCounts),
+	}
+	for _, option := range options {
+		option(&hc)
+	}
+	hc.worker = async.NewInterval(hc.Check, hc.Interval)
+	return &hc
+}
+
+// HealthCheckOption is an option for health checks.
+type HealthCheckOption func(*HealthCheck)
+
+// OptHealthCheckPath sets the path that is probed on each upstream.
+func OptHealthCheckPath(path string) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Path = path }
+}
+
+// OptHealthCheckInterval sets the interval between probes.
+func OptHealthCheckInterval(interval time.Duration) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Interval = interval }
+}
+
+// OptHealthCheckTimeout sets the timeout for each probe.
+func OptHealthCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Timeout = timeout }
+}
+
+// OptHealthCheckThresholds sets the number of consecutive passed and failed
+// probes that mark an upstream healthy and unhealthy respectively.
+func OptHealthCheckThresholds(healthy, unhealthy int) HealthCheckOption {
+	return func(hc *HealthCheck) {
+		hc.HealthyThreshold = healthy
+		hc.UnhealthyThreshold = unhealthy
+	}
+}
+
+// OptHealthCheckClient sets the client used to send probes.
+func OptHealthCheckClient(client *http.Client) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Client = client }
+}
+
+// HealthCheck actively probes upstreams on an interval, marking them unhealthy
+// after a number of consecutive failed probes and healthy again after a number
+// of consecutive passed probes.
+//
+// A probe passes if the upstream responds to a `GET` of the path with a 2xx or 3xx status.
+type HealthCheck struct {
+	// Upstreams are the upstreams to probe.
+	Upstreams []*Upstream
+	// Path is the path that is probed on each upstream.
+	Path string
+	// Interval is the interval between probes.
+	Interval time.Duration
+	// Timeout is the timeout for each probe.
+	Timeout time.Duration
+	// HealthyThreshold is the number of consecutive passed probes that mark an upstream healthy.
+	HealthyThreshold int
+	// UnhealthyThreshold is the number of consecutive failed probes that mark an upstream unhealthy.
+	UnhealthyThreshold int
+	// Client is the client used to send probes; if unset the upstream's transport is used.
+	Client *http.Client
+
+	mu     sync.Mutex
+	counts map[*Upstream]*healthCheckCounts
+	worker *async.Interval
+}
+
+type healthCheckCounts struct {
+	unhealthy bool
+	successes int
+	failures  int
+}
+
+// Start probes the upstreams immediately and then on the interval.
+//
+// This call will block.
+func (hc *HealthCheck) Start() error {
+	_ = hc.Check(context.Background())
+	return hc.worker.Start()
+}
+
+// Stop stops the health check.
+func (hc *HealthCheck) Stop() error {
+	return hc.worker.Stop()
+}
+
+// NotifyStarted returns a channel that is closed when the health check has started.
+func (hc *HealthCheck) NotifyStarted() <-chan struct{} {
+	return hc.worker.NotifyStarted()
+}
+
+// Check probes each of the upstreams once, in parallel, and updates their health.
+func (hc *HealthCheck) Check(ctx context.Context) error {
+	wg := sync.WaitGroup{}
+	wg.Add(len(hc.Upstreams))
+	for _, upstream := range hc.Upstreams {
+		go func(u *Upstream) {
+			defer wg.Done()
+			hc.record(u, hc.Probe(ctx, u))
+		}(upstream)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Probe sends a single probe to an upstream, returning an error if it fails.
+func (hc *HealthCheck) Probe(ctx context.Context, u *Upstream) error {
+	if hc.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hc.Timeout)
+		defer cancel()
+	}
+
+	target := *u.URL
+	target.Path = hc.Path
+	target.RawPath = ""
+	target.RawQuery = ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return ex.New(err)
+	}
+
+	client := hc.Client
+	if client == nil {
+		client = &http.Client{Transport: u.ReverseProxy.Transport}
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return ex.New(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return ex.New(ErrHealthCheckStatus, ex.OptMessagef("upstream: %s, status code: %d", u, res.StatusCode))
+	}
+	return nil
+}
+
+// record updates the consecutive counts for an upstream with the result of a probe.
+func (hc *HealthCheck) record(u *Upstream, err error) {
+	u.recordCheck(time.Now().UTC(), err)
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	counts, ok := hc.counts[u]
+	if !ok {
+		counts = new(healthCheckCounts)
+		hc.counts[u] = counts
+	}
+
+	if err != nil {
+		counts.successes = 0
+		counts.failures++
+		if !counts.unhealthy && counts.failures >= hc.UnhealthyThreshold {
+			counts.unhealthy = true
+			u.SetHealthy(false, fmt.Sprintf("%d consecutive failed health checks", counts.failures))
+		}
+		return
+	}
+	counts.failures = 0
+	counts.successes++
+	if counts.unhealthy && counts.successes >= hc.HealthyThreshold {
+		counts.unhealthy = false
+		u.SetHealthy(true, fmt.Sprintf("%d consecutive passed health checks", counts.successes))
+	}
+}
@@ -0,0 +1,96 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestHealthCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	var healthy int32 = 1
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/healthz" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&probes, 1)
+		if atomic.LoadInt32(&healthy) == 1 {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	u := NewUpstream(MustParseURL(srv.URL + "/api"))
+	hc := NewHealthCheck([]*Upstream{u}, OptHealthCheckPath("/healthz"), OptHealthCheckThresholds(2, 2))
+	assert.Equal("/healthz", hc.Path)
+
+	assert.Nil(hc.Check(context.Background()))
+	assert.Equal(UpstreamStateHealthy, u.State())
+	assert.NotNil(u.Status().LastCheck)
+
+	atomic.StoreInt32(&healthy, 0)
+	assert.Nil(hc.Check(context.Background()))
+	assert.Equal(UpstreamStateHealthy, u.State())
+	assert.Nil(hc.Check(context.Background()))
+	assert.Equal(UpstreamStateUnhealthy, u.State())
+	assert.NotEmpty(u.Status().LastCheckError)
+
+	atomic.StoreInt32(&healthy, 1)
+	assert.Nil(hc.Check(context.Background()))
+	assert.Equal(UpstreamStateUnhealthy, u.State())
+	assert.Nil(hc.Check(context.Background()))
+	assert.Equal(UpstreamStateHealthy, u.State())
+	assert.Empty(u.Status().LastCheckError)
+	assert.Equal(5, atomic.LoadInt32(&probes))
+}
+
+func TestHealthCheckProbe(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	u := NewUpstream(MustParseURL(srv.URL))
+	hc := NewHealthCheck([]*Upstream{u})
+
+	err := hc.Probe(context.Background(), u)
+	assert.True(ex.Is(err, ErrHealthCheckStatus))
+
+	srv.Close()
+	assert.NotNil(hc.Probe(context.Background(), u))
+}
+
+func TestHealthCheckStartStop(t *testing.T) {
+	assert := assert.New(t)
+
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	hc := NewHealthCheck([]*Upstream{NewUpstream(MustParseURL(srv.URL))}, OptHealthCheckInterval(time.Millisecond))
+	go func() { _ = hc.Start() }()
+	<-hc.NotifyStarted()
+	assert.Nil(hc.Stop())
+	assert.True(atomic.LoadInt32(&probes) >= 1)
+}
@@ -0,0 +1,90 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+
+	"github.com/blend/go-sdk/webutil"
+)
+
+// Mirror copies a percentage of requests to a secondary upstream, e.g. to test a new
+// version of a service with production traffic. Responses from the mirror are discarded.
+//
+// Mirrored requests are sent in the background and are not canceled when the original
+// request finishes; set a `Timeout` on the mirror upstream to bound them.
+type Mirror struct {
+	// Upstream is the upstream requests are copied to.
+	Upstream *Upstream
+	// Percent is the percentage of requests, from 0 to 100, that are copied.
+	Percent float64
+	// MaxBodyBytes is the maximum size of a request body that is copied; requests
+	// with larger bodies are not mirrored. If unset `DefaultMaxBufferedBodyBytes` is used.
+	MaxBodyBytes int64
+}
+
+// ShouldMirror returns if a request should be mirrored based on the percentage.
+func (m Mirror) ShouldMirror() bool {
+	if m.Upstream == nil || m.Percent <= 0 {
+		return false
+	}
+	return m.Percent >= 100 || rand.Float64()*100 < m.Percent
+}
+
+func (m Mirror) maxBodyBytes() int64 {
+	if m.MaxBodyBytes > 0 {
+		return m.MaxBodyBytes
+	}
+	return DefaultMaxBufferedBodyBytes
+}
+
+// mirror sends a copy of a request to the mirror upstream in the background.
+//
+// The copy is traced in its own span, tagged as mirrored.
+func (p *Proxy) mirror(req *http.Request, m *Mirror) {
+	body, ok := bufferRequestBody(req, m.maxBodyBytes())
+	if !ok {
+		return
+	}
+	if body != nil {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	mirrorReq := req.Clone(webutil.WithProxyMirrored(detachedContext{parent: req.Context()}))
+	if body != nil {
+		mirrorReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	go func() {
+		var tf webutil.HTTPTraceFinisher
+		if p.Tracer != nil {
+			tf, mirrorReq = p.Tracer.Start(mirrorReq)
+		}
+		rw := newDiscardResponseWriter()
+		m.Upstream.ServeHTTP(rw, mirrorReq)
+		if tf != nil {
+			tf.Finish(nil)
+		}
+	}()
+}
+
+// discardResponseWriter is a response writer that discards the response.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func newDiscardResponseWriter() *discardResponseWriter {
+	return &discardResponseWriter{header: http.Header{}}
+}
+
+func (drw *discardResponseWriter) Header() http.Header                { return drw.header }
+func (drw *discardResponseWriter) Write(contents []byte) (int, error) { return len(contents), nil }
+func (drw *discardResponseWriter) WriteHeader(int)                    {}
@@ -0,0 +1,80 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestMirrorShouldMirror(t *testing.T) {
+	assert := assert.New(t)
+
+	u := NewUpstream(MustParseURL("http://mirror.invalid"))
+	assert.False(Mirror{Upstream: u}.ShouldMirror())
+	assert.False(Mirror{Percent: 100}.ShouldMirror())
+	assert.True(Mirror{Upstream: u, Percent: 100}.ShouldMirror())
+}
+
+func TestProxyMirror(t *testing.T) {
+	assert := assert.New(t)
+
+	mirrored := make(chan string, 1)
+	mirror := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		mirrored <- req.URL.Path + " " + string(body)
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mirror.Close()
+
+	primary := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write(body)
+	}))
+	defer primary.Close()
+
+	tracer := new(recordingHTTPTracer)
+	proxy, err := NewProxy(
+		OptProxyTracer(tracer),
+		OptProxyRoute(NewRoute(
+			OptRoutePathPrefix("/api"),
+			OptRouteStripPrefix(),
+			OptRouteUpstream(NewUpstream(MustParseURL(primary.URL))),
+			OptRouteMirror(NewUpstream(MustParseURL(mirror.URL)), 100),
+		)),
+	)
+	assert.Nil(err)
+
+	res := httptest.NewRecorder()
+	proxy.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader("hello")))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal("hello", res.Body.String())
+
+	select {
+	case got := <-mirrored:
+		assert.Equal("/users hello", got)
+	case <-time.After(5 * time.Second):
+		assert.FailNow("mirrored request was not sent")
+	}
+
+	for tracer.Len() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	tracer.Lock()
+	defer tracer.Unlock()
+	assert.False(webutil.IsProxyMirrored(tracer.Requests[0].Context()))
+	assert.True(webutil.IsProxyMirrored(tracer.Requests[1].Context()))
+}
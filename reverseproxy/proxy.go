@@ -43,6 +43,7 @@ type Proxy struct {
 	Log              logger.Log
 	Upstreams        []*Upstream
 	Resolver         Resolver
+	Routes           []*Route
 	Tracer           webutil.HTTPTracer
 	TransformRequest TransformRequest
 	Timeout          time.Duration
@@ -89,7 +90,17 @@ func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		p.Resolver = RoundRobinResolver(p.Upstreams)
 	}
 
-	upstream, err := p.Resolver(req, p.Upstreams)
+	var upstream *Upstream
+	route := p.Route(req)
+	if route != nil {
+		req = req.WithContext(WithRoute(req.Context(), route))
+		upstream, err = route.Resolve(req)
+	} else if len(p.Routes) > 0 && len(p.Upstreams) == 0 {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	} else {
+		upstream, err = p.Resolver(req, p.Upstreams)
+	}
 
 	if err != nil {
 		logger.MaybeError(p.Log, err)
@@ -118,9 +129,49 @@ func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			req.Header.Add(key, value)
 		}
 	}
+	if route != nil {
+		route.Transform(req)
+	}
 	if p.TransformRequest != nil {
 		p.TransformRequest(req)
 	}
+	if route != nil && route.Mirror != nil && route.Mirror.ShouldMirror() {
+		p.mirror(req, route.Mirror)
+	}
+	if route != nil && route.Retry != nil && route.Retry.IsRetryable(req) {
+		p.serveWithRetry(rw, req, route, upstream)
+		return
+	}
 
 	upstream.ServeHTTP(rw, req)
 }
+
+// Route returns the most specific route that matches a request, or nil if no routes match.
+//
+// Routes with an exact host are more specific than routes with a wildcard host, which are
+// more specific than routes that match any host; ties are broken by the longest path prefix,
+// and then by the order the routes were added.
+func (p *Proxy) Route(req *http.Request) *Route {
+	var selected *Route
+	var selectedHost, selectedPath int
+	for _, route := range p.Routes {
+		if !route.Matches(req) {
+			continue
+		}
+		host, path := route.specificity()
+		if selected == nil || host > selectedHost || (host == selectedHost && path > selectedPath) {
+			selected = route
+			selectedHost, selectedPath = host, path
+		}
+	}
+	return selected
+}
+
+// AllUpstreams returns the proxy's upstreams followed by the upstreams of each route.
+func (p *Proxy) AllUpstreams() []*Upstream {
+	upstreams := append([]*Upstream(nil), p.Upstreams...)
+	for _, route := range p.Routes {
+		upstreams = append(upstreams, route.Upstreams...)
+	}
+	return upstreams
+}
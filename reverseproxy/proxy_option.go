@@ -22,7 +22,7 @@ type ProxyOption func(*Proxy) error
 func OptProxyLog(log logger.Log) ProxyOption {
 	return func(p *Proxy) error {
 		p.Log = log
-		for _, us := range p.Upstreams {
+		for _, us := range p.AllUpstreams() {
 			us.Log = log
 		}
 		return nil
@@ -37,6 +37,25 @@ func OptProxyUpstream(upstream *Upstream) ProxyOption {
 	}
 }
 
+// OptProxyRoute adds a proxy route.
+//
+// Requests that do not match any route are sent to the proxy's upstreams, or
+// rejected with a 404 if the proxy has no upstreams of its own.
+func OptProxyRoute(route *Route) ProxyOption {
+	return func(p *Proxy) error {
+		p.Routes = append(p.Routes, route)
+		return nil
+	}
+}
+
+// OptProxyResolver sets the resolver used to pick an upstream for each request.
+func OptProxyResolver(resolver Resolver) ProxyOption {
+	return func(p *Proxy) error {
+		p.Resolver = resolver
+		return nil
+	}
+}
+
 // OptProxyAddHeaderValue adds a proxy upstream.
 func OptProxyAddHeaderValue(key, value string) ProxyOption {
 	return func(p *Proxy) error {
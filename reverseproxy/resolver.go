@@ -15,7 +15,20 @@ import (
 // Resolver is a function that takes a request and produces a destination `url.URL`.
 type Resolver func(*http.Request, []*Upstream) (*Upstream, error)
 
+// HealthyUpstreams returns the upstreams that can receive requests.
+func HealthyUpstreams(upstreams []*Upstream) []*Upstream {
+	healthy := make([]*Upstream, 0, len(upstreams))
+	for _, upstream := range upstreams {
+		if upstream.IsHealthy() {
+			healthy = append(healthy, upstream)
+		}
+	}
+	return healthy
+}
+
 // RoundRobinResolver returns a closure based resolver that rotates through upstreams uniformly.
+//
+// Upstreams that are not healthy are skipped.
 func RoundRobinResolver(upstreams []*Upstream) Resolver {
 	if len(upstreams) == 0 {
 		return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
@@ -25,6 +38,9 @@ func RoundRobinResolver(upstreams []*Upstream) Resolver {
 
 	if len(upstreams) == 1 {
 		return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
+			if !upstreams[0].IsHealthy() {
+				return nil, nil
+			}
 			return upstreams[0], nil
 		}
 	}
@@ -39,9 +55,14 @@ func manyRoundRobinResolver(upstreams []*Upstream) Resolver {
 
 	return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
 		l.Lock()
-		upstream := upstreams[index]
-		index = (index + 1) % total
-		l.Unlock()
-		return upstream, nil
+		defer l.Unlock()
+		for x := 0; x < total; x++ {
+			upstream := upstreams[index]
+			index = (index + 1) % total
+			if upstream.IsHealthy() {
+				return upstream, nil
+			}
+		}
+		return nil, nil
 	}
 }
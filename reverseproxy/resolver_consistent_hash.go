@@ -0,0 +1,82 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"hash/fnv"
+	"net/http"
+
+	"github.com/blend/go-sdk/webutil"
+)
+
+// HashKeyProvider returns the key used to pin a request to an upstream.
+type HashKeyProvider func(*http.Request) string
+
+// HashKeyHeader returns a hash key provider that uses the value of a request header.
+func HashKeyHeader(header string) HashKeyProvider {
+	return func(req *http.Request) string {
+		return req.Header.Get(header)
+	}
+}
+
+// HashKeyCookie returns a hash key provider that uses the value of a request cookie.
+func HashKeyCookie(name string) HashKeyProvider {
+	return func(req *http.Request) string {
+		if cookie, err := req.Cookie(name); err == nil {
+			return cookie.Value
+		}
+		return ""
+	}
+}
+
+// HashKeyPath returns a hash key provider that uses the request path.
+func HashKeyPath() HashKeyProvider {
+	return func(req *http.Request) string {
+		return req.URL.Path
+	}
+}
+
+// ConsistentHashResolver returns a resolver that pins requests with the same key
+// to the same healthy upstream, e.g. for sticky sessions.
+//
+// It uses rendezvous hashing, so when an upstream becomes unhealthy only the keys
+// pinned to it move to other upstreams. Requests without a key are hashed by remote address.
+func ConsistentHashResolver(keyProvider HashKeyProvider) Resolver {
+	return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
+		key := keyProvider(req)
+		if key == "" {
+			key = webutil.GetRemoteAddr(req)
+		}
+
+		var selected *Upstream
+		var selectedScore uint64
+		for _, upstream := range HealthyUpstreams(upstreams) {
+			if score := rendezvousScore(key, upstream.String()); selected == nil || score > selectedScore {
+				selected = upstream
+				selectedScore = score
+			}
+		}
+		return selected, nil
+	}
+}
+
+func rendezvousScore(key, upstream string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(upstream))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+
+	// mix the bits so similar upstream names don't produce correlated scores.
+	score := h.Sum64()
+	score ^= score >> 33
+	score *= 0xff51afd7ed558ccd
+	score ^= score >> 33
+	score *= 0xc4ceb9fe1a85ec53
+	score ^= score >> 33
+	return score
+}
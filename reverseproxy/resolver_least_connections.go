@@ -0,0 +1,28 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"net/http"
+)
+
+// LeastConnectionsResolver returns a resolver that picks the healthy upstream
+// with the fewest active requests, breaking ties by order.
+func LeastConnectionsResolver() Resolver {
+	return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
+		var selected *Upstream
+		var selectedActive int
+		for _, upstream := range HealthyUpstreams(upstreams) {
+			if active := upstream.ActiveRequests(); selected == nil || active < selectedActive {
+				selected = upstream
+				selectedActive = active
+			}
+		}
+		return selected, nil
+	}
+}
@@ -0,0 +1,40 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+// RandomTwoChoicesResolver returns a resolver that picks two healthy upstreams
+// at random and selects the one with fewer active requests.
+//
+// It spreads load almost as evenly as least connections while avoiding
+// every proxy picking the same upstream at once.
+func RandomTwoChoicesResolver() Resolver {
+	return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
+		healthy := HealthyUpstreams(upstreams)
+		switch len(healthy) {
+		case 0:
+			return nil, nil
+		case 1:
+			return healthy[0], nil
+		}
+
+		first := rand.Intn(len(healthy))
+		second := rand.Intn(len(healthy) - 1)
+		if second >= first {
+			second++
+		}
+		if healthy[second].ActiveRequests() < healthy[first].ActiveRequests() {
+			return healthy[second], nil
+		}
+		return healthy[first], nil
+	}
+}
@@ -0,0 +1,168 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func testUpstreams(count int) []*Upstream {
+	var upstreams []*Upstream
+	for x := 0; x < count; x++ {
+		upstreams = append(upstreams, NewUpstream(MustParseURL(fmt.Sprintf("http://upstream-%d.invalid", x))))
+	}
+	return upstreams
+}
+
+func TestRoundRobinResolverSkipsUnhealthy(t *testing.T) {
+	assert := assert.New(t)
+
+	upstreams := testUpstreams(3)
+	upstreams[1].SetHealthy(false, "test")
+	resolver := RoundRobinResolver(upstreams)
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	var resolved []*Upstream
+	for x := 0; x < 4; x++ {
+		upstream, err := resolver(req, upstreams)
+		assert.Nil(err)
+		resolved = append(resolved, upstream)
+	}
+	assert.Equal([]*Upstream{upstreams[0], upstreams[2], upstreams[0], upstreams[2]}, resolved)
+
+	upstreams[0].SetHealthy(false, "test")
+	upstreams[2].SetHealthy(false, "test")
+	upstream, err := resolver(req, upstreams)
+	assert.Nil(err)
+	assert.Nil(upstream)
+}
+
+func TestLeastConnectionsResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	upstreams := testUpstreams(3)
+	atomic.StoreInt32(&upstreams[0].active, 3)
+	atomic.StoreInt32(&upstreams[1].active, 1)
+	atomic.StoreInt32(&upstreams[2].active, 2)
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	upstream, err := LeastConnectionsResolver()(req, upstreams)
+	assert.Nil(err)
+	assert.Equal(upstreams[1], upstream)
+
+	upstreams[1].Eject(time.Now().UTC().Add(time.Minute), "test")
+	upstream, err = LeastConnectionsResolver()(req, upstreams)
+	assert.Nil(err)
+	assert.Equal(upstreams[2], upstream)
+}
+
+func TestWeightedRoundRobinResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	upstreams := testUpstreams(3)
+	upstreams[0].Weight = 5
+	upstreams[1].Weight = 1
+	upstreams[2].Weight = 1
+
+	resolver := WeightedRoundRobinResolver()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	counts := make(map[*Upstream]int)
+	var sequence []*Upstream
+	for x := 0; x < 7; x++ {
+		upstream, err := resolver(req, upstreams)
+		assert.Nil(err)
+		counts[upstream]++
+		sequence = append(sequence, upstream)
+	}
+	assert.Equal(5, counts[upstreams[0]])
+	assert.Equal(1, counts[upstreams[1]])
+	assert.Equal(1, counts[upstreams[2]])
+	// the heaviest upstream is interleaved rather than picked 5 times in a row.
+	assert.Equal([]*Upstream{upstreams[0], upstreams[0], upstreams[1], upstreams[0], upstreams[2], upstreams[0], upstreams[0]}, sequence)
+}
+
+func TestConsistentHashResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	upstreams := testUpstreams(5)
+	resolver := ConsistentHashResolver(HashKeyHeader("X-Session"))
+
+	pinned := make(map[string]*Upstream)
+	for x := 0; x < 50; x++ {
+		session := fmt.Sprintf("session-%d", x)
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Session", session)
+		upstream, err := resolver(req, upstreams)
+		assert.Nil(err)
+		assert.NotNil(upstream)
+
+		again, err := resolver(req, upstreams)
+		assert.Nil(err)
+		assert.Equal(upstream, again)
+		pinned[session] = upstream
+	}
+
+	// only the sessions pinned to an unhealthy upstream should move.
+	upstreams[2].SetHealthy(false, "test")
+	for session, previous := range pinned {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Session", session)
+		upstream, err := resolver(req, upstreams)
+		assert.Nil(err)
+		if previous == upstreams[2] {
+			assert.NotEqual(upstreams[2], upstream)
+		} else {
+			assert.Equal(previous, upstream)
+		}
+	}
+}
+
+func TestHashKeyProviders(t *testing.T) {
+	assert := assert.New(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://localhost/foo/bar", nil)
+	req.Header.Set("X-Session", "header-value")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "cookie-value"})
+
+	assert.Equal("header-value", HashKeyHeader("X-Session")(req))
+	assert.Equal("cookie-value", HashKeyCookie("session")(req))
+	assert.Empty(HashKeyCookie("missing")(req))
+	assert.Equal("/foo/bar", HashKeyPath()(req))
+}
+
+func TestRandomTwoChoicesResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	upstreams := testUpstreams(2)
+	atomic.StoreInt32(&upstreams[0].active, 10)
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	resolver := RandomTwoChoicesResolver()
+	for x := 0; x < 10; x++ {
+		upstream, err := resolver(req, upstreams)
+		assert.Nil(err)
+		assert.Equal(upstreams[1], upstream)
+	}
+
+	upstreams[1].SetHealthy(false, "test")
+	upstream, err := resolver(req, upstreams)
+	assert.Nil(err)
+	assert.Equal(upstreams[0], upstream)
+
+	upstreams[0].SetHealthy(false, "test")
+	upstream, err = resolver(req, upstreams)
+	assert.Nil(err)
+	assert.Nil(upstream)
+}
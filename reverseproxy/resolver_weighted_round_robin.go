@@ -0,0 +1,45 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// WeightedRoundRobinResolver returns a resolver that rotates through healthy upstreams
+// in proportion to their `Weight`, interleaving them smoothly rather than in bursts.
+//
+// Upstreams with a weight less than 1 are treated as having a weight of 1.
+func WeightedRoundRobinResolver() Resolver {
+	l := sync.Mutex{}
+	current := make(map[*Upstream]int)
+
+	return func(req *http.Request, upstreams []*Upstream) (*Upstream, error) {
+		l.Lock()
+		defer l.Unlock()
+
+		var selected *Upstream
+		var total int
+		for _, upstream := range HealthyUpstreams(upstreams) {
+			weight := upstream.Weight
+			if weight < 1 {
+				weight = 1
+			}
+			total += weight
+			current[upstream] += weight
+			if selected == nil || current[upstream] > current[selected] {
+				selected = upstream
+			}
+		}
+		if selected != nil {
+			current[selected] -= total
+		}
+		return selected, nil
+	}
+}
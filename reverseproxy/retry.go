@@ -0,0 +1,154 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/blend/go-sdk/webutil"
+)
+
+// RetryPolicy retries requests that fail to connect to, or time out waiting for,
+// an upstream against a different upstream of the same route.
+//
+// Requests are only retried if the upstream did not return a response, so only
+// idempotent methods should be retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	MaxAttempts int
+	// Methods are the methods that are retried; if unset `DefaultRetryMethods` are used.
+	Methods []string
+	// MaxBodyBytes is the maximum size of a request body that is buffered so it can be retried;
+	// if unset `DefaultMaxBufferedBodyBytes` is used.
+	MaxBodyBytes int64
+}
+
+// IsRetryable returns if a request can be retried.
+func (rp RetryPolicy) IsRetryable(req *http.Request) bool {
+	if rp.MaxAttempts < 2 {
+		return false
+	}
+	methods := rp.Methods
+	if len(methods) == 0 {
+		methods = DefaultRetryMethods
+	}
+	for _, method := range methods {
+		if req.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (rp RetryPolicy) maxBodyBytes() int64 {
+	if rp.MaxBodyBytes > 0 {
+		return rp.MaxBodyBytes
+	}
+	return DefaultMaxBufferedBodyBytes
+}
+
+// serveWithRetry serves a request, retrying it against other upstreams of the route if
+// an attempt fails without a response. Each attempt, including the first, is traced in its own span.
+func (p *Proxy) serveWithRetry(rw http.ResponseWriter, req *http.Request, route *Route, upstream *Upstream) {
+	body, ok := bufferRequestBody(req, route.Retry.maxBodyBytes())
+	if !ok {
+		upstream.ServeHTTP(rw, req)
+		return
+	}
+
+	tried := []*Upstream{upstream}
+	for attempt := 1; ; attempt++ {
+		state := &proxyAttempt{
+			retryable: attempt < route.Retry.MaxAttempts && untriedUpstream(route.Upstreams, tried) != nil,
+		}
+		attemptReq := req.WithContext(withProxyAttempt(webutil.WithProxyAttempt(req.Context(), uint(attempt)), state))
+		if body != nil {
+			attemptReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		var tf webutil.HTTPTraceFinisher
+		if p.Tracer != nil {
+			tf, attemptReq = p.Tracer.Start(attemptReq)
+		}
+		upstream.ServeHTTP(rw, attemptReq)
+		if tf != nil {
+			tf.Finish(state.err)
+		}
+		if state.err == nil {
+			return
+		}
+
+		if req.Context().Err() == nil {
+			upstream = p.retryUpstream(req, route, tried)
+		} else {
+			upstream = nil
+		}
+		if upstream == nil {
+			if isTimeout(state.err) {
+				rw.WriteHeader(http.StatusGatewayTimeout)
+			} else {
+				rw.WriteHeader(http.StatusBadGateway)
+			}
+			return
+		}
+		tried = append(tried, upstream)
+	}
+}
+
+// retryUpstream picks an upstream that has not been tried yet, preferring the route's resolver.
+func (p *Proxy) retryUpstream(req *http.Request, route *Route, tried []*Upstream) *Upstream {
+	if upstream, err := route.Resolve(req); err == nil && upstream != nil && !containsUpstream(tried, upstream) {
+		return upstream
+	}
+	return untriedUpstream(route.Upstreams, tried)
+}
+
+func untriedUpstream(upstreams, tried []*Upstream) *Upstream {
+	for _, upstream := range upstreams {
+		if !containsUpstream(tried, upstream) && upstream.IsHealthy() {
+			return upstream
+		}
+	}
+	return nil
+}
+
+func containsUpstream(upstreams []*Upstream, upstream *Upstream) bool {
+	for _, u := range upstreams {
+		if u == upstream {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyAttempt is the state of an attempt that can be retried.
+//
+// If the attempt fails without a response, the upstream records the error
+// instead of writing a response.
+type proxyAttempt struct {
+	retryable bool
+	err       error
+}
+
+type proxyAttemptKey struct{}
+
+func withProxyAttempt(ctx context.Context, attempt *proxyAttempt) context.Context {
+	return context.WithValue(ctx, proxyAttemptKey{}, attempt)
+}
+
+func getProxyAttempt(ctx context.Context) *proxyAttempt {
+	if value := ctx.Value(proxyAttemptKey{}); value != nil {
+		if typed, ok := value.(*proxyAttempt); ok {
+			return typed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/webutil"
+)
+
+var (
+	_ webutil.HTTPTracer = (*recordingHTTPTracer)(nil)
+)
+
+// recordingHTTPTracer records every request it starts a span for.
+type recordingHTTPTracer struct {
+	sync.Mutex
+	Requests []*http.Request
+}
+
+func (rht *recordingHTTPTracer) Start(req *http.Request) (webutil.HTTPTraceFinisher, *http.Request) {
+	rht.Lock()
+	defer rht.Unlock()
+	rht.Requests = append(rht.Requests, req)
+	return &mockHTTPTraceFinisher{}, req
+}
+
+func (rht *recordingHTTPTracer) Len() int {
+	rht.Lock()
+	defer rht.Unlock()
+	return len(rht.Requests)
+}
+
+func TestRetryPolicyIsRetryable(t *testing.T) {
+	assert := assert.New(t)
+
+	get := httptest.NewRequest(http.MethodGet, "/", nil)
+	post := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	assert.True(RetryPolicy{MaxAttempts: 2}.IsRetryable(get))
+	assert.False(RetryPolicy{MaxAttempts: 2}.IsRetryable(post))
+	assert.False(RetryPolicy{MaxAttempts: 1}.IsRetryable(get))
+	assert.True(RetryPolicy{MaxAttempts: 2, Methods: []string{http.MethodPost}}.IsRetryable(post))
+}
+
+func TestProxyRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	down.Close()
+
+	var bodies []string
+	up := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+
+	downUpstream := NewUpstream(MustParseURL(down.URL), OptUpstreamPassiveHealthCheck(1, time.Minute))
+	tracer := new(recordingHTTPTracer)
+	proxy, err := NewProxy(
+		OptProxyTracer(tracer),
+		OptProxyRoute(NewRoute(
+			OptRouteUpstream(downUpstream),
+			OptRouteUpstream(NewUpstream(MustParseURL(up.URL))),
+			OptRouteRetry(3),
+		)),
+	)
+	assert.Nil(err)
+
+	res := httptest.NewRecorder()
+	proxy.ServeHTTP(res, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("hello")))
+	assert.Equal(http.StatusOK, res.Code)
+	assert.Equal([]string{"hello"}, bodies)
+
+	// the failed attempt counts towards passive health checks.
+	assert.Equal(UpstreamStateEjected, downUpstream.State())
+
+	// the proxy span and a span for each attempt.
+	assert.Equal(3, tracer.Len())
+	assert.Zero(webutil.GetProxyAttempt(tracer.Requests[0].Context()))
+	assert.Equal(1, webutil.GetProxyAttempt(tracer.Requests[1].Context()))
+	assert.Equal(2, webutil.GetProxyAttempt(tracer.Requests[2].Context()))
+}
+
+func TestProxyRetryExhausted(t *testing.T) {
+	assert := assert.New(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	down.Close()
+
+	proxy, err := NewProxy(
+		OptProxyRoute(NewRoute(
+			OptRouteUpstream(NewUpstream(MustParseURL(down.URL))),
+			OptRouteUpstream(NewUpstream(MustParseURL(down.URL))),
+			OptRouteRetry(5),
+		)),
+	)
+	assert.Nil(err)
+
+	res := httptest.NewRecorder()
+	proxy.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusBadGateway, res.Code)
+}
+
+func TestProxyRetryNotIdempotent(t *testing.T) {
+	assert := assert.New(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	down.Close()
+
+	var calls int
+	up := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+
+	proxy, err := NewProxy(
+		OptProxyRoute(NewRoute(
+			OptRouteUpstream(NewUpstream(MustParseURL(down.URL))),
+			OptRouteUpstream(NewUpstream(MustParseURL(up.URL))),
+			OptRouteRetry(3),
+		)),
+	)
+	assert.Nil(err)
+
+	res := httptest.NewRecorder()
+	proxy.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+	assert.Equal(http.StatusBadGateway, res.Code)
+	assert.Zero(calls)
+}
+
+func TestUpstreamTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+
+	u := NewUpstream(MustParseURL(slow.URL), OptUpstreamTimeout(10*time.Millisecond))
+	res := httptest.NewRecorder()
+	u.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusGatewayTimeout, res.Code)
+
+	u = NewUpstream(MustParseURL(slow.URL), OptUpstreamResponseHeaderTimeout(10*time.Millisecond))
+	res = httptest.NewRecorder()
+	u.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusGatewayTimeout, res.Code)
+}
+
+func TestBufferRequestBody(t *testing.T) {
+	assert := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+	body, ok := bufferRequestBody(req, 5)
+	assert.True(ok)
+	assert.Equal("hello", string(body))
+	contents, _ := ioutil.ReadAll(req.Body)
+	assert.Equal("hello", string(contents))
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world"))
+	req.ContentLength = -1
+	body, ok = bufferRequestBody(req, 5)
+	assert.False(ok)
+	assert.Nil(body)
+	contents, _ = ioutil.ReadAll(req.Body)
+	assert.Equal("hello world", string(contents))
+
+	body, ok = bufferRequestBody(httptest.NewRequest(http.MethodGet, "/", nil), 5)
+	assert.True(ok)
+	assert.Nil(body)
+}
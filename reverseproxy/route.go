@@ -0,0 +1,249 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// NewRoute returns a new route.
+func NewRoute(options ...RouteOption) *Route {
+	r := Route{
+		AddHeaders: http.Header{},
+		SetHeaders: http.Header{},
+	}
+	for _, option := range options {
+		option(&r)
+	}
+	return &r
+}
+
+// RouteOption is an option for routes.
+type RouteOption func(*Route)
+
+// OptRouteName sets the route name.
+func OptRouteName(name string) RouteOption {
+	return func(r *Route) { r.Name = name }
+}
+
+// OptRouteHost sets the host pattern the route matches, e.g. `api.example.com` or `*.example.com`.
+func OptRouteHost(host string) RouteOption {
+	return func(r *Route) { r.Host = host }
+}
+
+// OptRoutePathPrefix sets the path prefix the route matches, e.g. `/api`.
+func OptRoutePathPrefix(pathPrefix string) RouteOption {
+	return func(r *Route) { r.PathPrefix = pathPrefix }
+}
+
+// OptRouteStripPrefix strips the path prefix from requests before they're proxied.
+func OptRouteStripPrefix() RouteOption {
+	return func(r *Route) { r.StripPrefix = true }
+}
+
+// OptRouteRewritePrefix replaces the path prefix of requests with a given prefix before they're proxied.
+func OptRouteRewritePrefix(prefix string) RouteOption {
+	return func(r *Route) { r.RewritePrefix = prefix }
+}
+
+// OptRouteUpstream adds a route upstream.
+func OptRouteUpstream(upstream *Upstream) RouteOption {
+	return func(r *Route) { r.Upstreams = append(r.Upstreams, upstream) }
+}
+
+// OptRouteResolver sets the resolver used to pick one of the route's upstreams.
+func OptRouteResolver(resolver Resolver) RouteOption {
+	return func(r *Route) { r.Resolver = resolver }
+}
+
+// OptRouteAddHeaderValue adds a header value to requests that match the route.
+func OptRouteAddHeaderValue(key, value string) RouteOption {
+	return func(r *Route) {
+		if r.AddHeaders == nil {
+			r.AddHeaders = http.Header{}
+		}
+		r.AddHeaders.Add(key, value)
+	}
+}
+
+// OptRouteSetHeaderValue sets a header value on requests that match the route, replacing any existing values.
+func OptRouteSetHeaderValue(key, value string) RouteOption {
+	return func(r *Route) {
+		if r.SetHeaders == nil {
+			r.SetHeaders = http.Header{}
+		}
+		r.SetHeaders.Set(key, value)
+	}
+}
+
+// OptRouteDeleteHeader deletes a header from requests that match the route.
+func OptRouteDeleteHeader(key string) RouteOption {
+	return func(r *Route) { r.DeleteHeaders = append(r.DeleteHeaders, key) }
+}
+
+// OptRouteRetry retries requests that fail without a response against other upstreams of
+// the route, up to a maximum number of attempts.
+//
+// If no methods are given `DefaultRetryMethods` are retried.
+func OptRouteRetry(maxAttempts int, methods ...string) RouteOption {
+	return func(r *Route) {
+		r.Retry = &RetryPolicy{
+			MaxAttempts: maxAttempts,
+			Methods:     methods,
+		}
+	}
+}
+
+// OptRouteMirror copies a percentage (from 0 to 100) of requests to a secondary upstream.
+func OptRouteMirror(upstream *Upstream, percent float64) RouteOption {
+	return func(r *Route) {
+		r.Mirror = &Mirror{
+			Upstream: upstream,
+			Percent:  percent,
+		}
+	}
+}
+
+// Route maps requests with a given host and path prefix to a pool of upstreams.
+type Route struct {
+	// Name is the name of the route.
+	Name string
+	// Host is the host pattern the route matches; it can be an exact host, a wildcard
+	// like `*.example.com`, or empty (or `*`) to match any host.
+	Host string
+	// PathPrefix is the path prefix the route matches on path segment boundaries;
+	// if empty it matches any path.
+	PathPrefix string
+	// StripPrefix strips the path prefix from requests before they're proxied.
+	StripPrefix bool
+	// RewritePrefix, if set, replaces the path prefix of requests before they're proxied.
+	RewritePrefix string
+	// Upstreams are the upstreams requests are proxied to.
+	Upstreams []*Upstream
+	// Resolver picks one of the upstreams; if unset it defaults to round robin.
+	Resolver Resolver
+	// AddHeaders are header values added to requests.
+	AddHeaders http.Header
+	// SetHeaders are header values set on requests, replacing existing values.
+	SetHeaders http.Header
+	// DeleteHeaders are headers deleted from requests.
+	DeleteHeaders []string
+	// Retry, if set, retries failed requests against other upstreams.
+	Retry *RetryPolicy
+	// Mirror, if set, copies a percentage of requests to a secondary upstream.
+	Mirror *Mirror
+
+	resolverOnce sync.Once
+	resolver     Resolver
+}
+
+// Matches returns if the route matches a request.
+func (r *Route) Matches(req *http.Request) bool {
+	return r.MatchesHost(req.Host) && r.MatchesPath(req.URL.Path)
+}
+
+// MatchesHost returns if the route matches a host, ignoring any port.
+func (r *Route) MatchesHost(host string) bool {
+	if r.Host == "" || r.Host == "*" {
+		return true
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	if strings.HasPrefix(r.Host, "*.") {
+		return len(host) > len(r.Host)-1 && strings.EqualFold(host[len(host)-(len(r.Host)-1):], r.Host[1:])
+	}
+	return strings.EqualFold(host, r.Host)
+}
+
+// MatchesPath returns if the route matches a path.
+func (r *Route) MatchesPath(path string) bool {
+	prefix := strings.TrimSuffix(r.PathPrefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// RewritePath returns the path a request is proxied with, after the prefix is stripped or rewritten.
+func (r *Route) RewritePath(path string) string {
+	if !r.StripPrefix && r.RewritePrefix == "" {
+		return path
+	}
+	rest := strings.TrimPrefix(path, strings.TrimSuffix(r.PathPrefix, "/"))
+	rewritten := strings.TrimSuffix(r.RewritePrefix, "/") + rest
+	if !strings.HasPrefix(rewritten, "/") {
+		rewritten = "/" + rewritten
+	}
+	return rewritten
+}
+
+// Transform rewrites the path and headers of a request that matches the route.
+func (r *Route) Transform(req *http.Request) {
+	req.URL.Path = r.RewritePath(req.URL.Path)
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = r.RewritePath(req.URL.RawPath)
+	}
+	for key, values := range r.AddHeaders {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	for key, values := range r.SetHeaders {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	for _, key := range r.DeleteHeaders {
+		req.Header.Del(key)
+	}
+}
+
+// Resolve picks one of the route's upstreams for a request.
+func (r *Route) Resolve(req *http.Request) (*Upstream, error) {
+	r.resolverOnce.Do(func() {
+		r.resolver = r.Resolver
+		if r.resolver == nil {
+			r.resolver = RoundRobinResolver(r.Upstreams)
+		}
+	})
+	return r.resolver(req, r.Upstreams)
+}
+
+// specificity returns how specific the route's host and path prefix are, used to
+// pick the best match when multiple routes match a request.
+func (r *Route) specificity() (host, path int) {
+	switch {
+	case r.Host == "" || r.Host == "*":
+		host = 0
+	case strings.HasPrefix(r.Host, "*."):
+		host = len(r.Host)
+	default:
+		host = 1 << 16
+	}
+	return host, len(strings.TrimSuffix(r.PathPrefix, "/"))
+}
+
+type routeKey struct{}
+
+// WithRoute adds the route that matched a request to a context.
+func WithRoute(ctx context.Context, route *Route) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// GetRoute gets the route that matched a request from a context.
+func GetRoute(ctx context.Context) *Route {
+	if value := ctx.Value(routeKey{}); value != nil {
+		if typed, ok := value.(*Route); ok {
+			return typed
+		}
+	}
+	return nil
+}
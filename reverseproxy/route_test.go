@@ -0,0 +1,198 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestRouteMatchesHost(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(NewRoute().MatchesHost("foo.example.com"))
+	assert.True(NewRoute(OptRouteHost("*")).MatchesHost("foo.example.com"))
+
+	exact := NewRoute(OptRouteHost("api.example.com"))
+	assert.True(exact.MatchesHost("api.example.com"))
+	assert.True(exact.MatchesHost("API.example.com:8443"))
+	assert.False(exact.MatchesHost("www.example.com"))
+
+	wildcard := NewRoute(OptRouteHost("*.example.com"))
+	assert.True(wildcard.MatchesHost("api.example.com"))
+	assert.True(wildcard.MatchesHost("a.b.example.com:80"))
+	assert.False(wildcard.MatchesHost("example.com"))
+	assert.False(wildcard.MatchesHost("badexample.com"))
+}
+
+func TestRouteMatchesPath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(NewRoute().MatchesPath("/anything"))
+
+	route := NewRoute(OptRoutePathPrefix("/api"))
+	assert.True(route.MatchesPath("/api"))
+	assert.True(route.MatchesPath("/api/users"))
+	assert.False(route.MatchesPath("/apiary"))
+	assert.False(route.MatchesPath("/"))
+
+	route = NewRoute(OptRoutePathPrefix("/api/"))
+	assert.True(route.MatchesPath("/api"))
+	assert.True(route.MatchesPath("/api/users"))
+}
+
+func TestRouteRewritePath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/api/users", NewRoute(OptRoutePathPrefix("/api")).RewritePath("/api/users"))
+
+	strip := NewRoute(OptRoutePathPrefix("/api"), OptRouteStripPrefix())
+	assert.Equal("/users", strip.RewritePath("/api/users"))
+	assert.Equal("/", strip.RewritePath("/api"))
+
+	rewrite := NewRoute(OptRoutePathPrefix("/api/"), OptRouteRewritePrefix("/v1/"))
+	assert.Equal("/v1/users", rewrite.RewritePath("/api/users"))
+	assert.Equal("/v1", rewrite.RewritePath("/api"))
+}
+
+func TestRouteTransform(t *testing.T) {
+	assert := assert.New(t)
+
+	route := NewRoute(
+		OptRoutePathPrefix("/api"),
+		OptRouteStripPrefix(),
+		OptRouteAddHeaderValue("X-Added", "added"),
+		OptRouteSetHeaderValue("X-Set", "set"),
+		OptRouteDeleteHeader("X-Deleted"),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.Header.Add("X-Added", "existing")
+	req.Header.Add("X-Set", "existing")
+	req.Header.Set("X-Deleted", "existing")
+	route.Transform(req)
+
+	assert.Equal("/users", req.URL.Path)
+	assert.Equal([]string{"existing", "added"}, req.Header["X-Added"])
+	assert.Equal([]string{"set"}, req.Header["X-Set"])
+	assert.Empty(req.Header.Get("X-Deleted"))
+}
+
+func TestProxyRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	anyHost := NewRoute(OptRouteName("any"))
+	api := NewRoute(OptRouteName("api"), OptRoutePathPrefix("/api"))
+	apiUsers := NewRoute(OptRouteName("api-users"), OptRoutePathPrefix("/api/users"))
+	wildcard := NewRoute(OptRouteName("wildcard"), OptRouteHost("*.example.com"))
+	exact := NewRoute(OptRouteName("exact"), OptRouteHost("admin.example.com"))
+
+	proxy, err := NewProxy(
+		OptProxyRoute(anyHost),
+		OptProxyRoute(api),
+		OptProxyRoute(apiUsers),
+		OptProxyRoute(wildcard),
+		OptProxyRoute(exact),
+	)
+	assert.Nil(err)
+
+	route := func(host, path string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		if matched := proxy.Route(req); matched != nil {
+			return matched.Name
+		}
+		return ""
+	}
+	assert.Equal("any", route("localhost", "/"))
+	assert.Equal("api", route("localhost", "/api/projects"))
+	assert.Equal("api-users", route("localhost", "/api/users/1"))
+	assert.Equal("wildcard", route("www.example.com", "/api/users/1"))
+	assert.Equal("exact", route("admin.example.com", "/api/users/1"))
+}
+
+func TestProxyRoutes(t *testing.T) {
+	assert := assert.New(t)
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+			fmt.Fprintf(rw, "%s %s %s", name, req.URL.Path, req.Header.Get("X-Route"))
+		}))
+	}
+	apiServer := newServer("api")
+	defer apiServer.Close()
+	webServer := newServer("web")
+	defer webServer.Close()
+
+	var routed *Route
+	proxy, err := NewProxy(
+		OptProxyRoute(NewRoute(
+			OptRouteName("api"),
+			OptRoutePathPrefix("/api"),
+			OptRouteStripPrefix(),
+			OptRouteSetHeaderValue("X-Route", "api"),
+			OptRouteUpstream(NewUpstream(MustParseURL(apiServer.URL))),
+		)),
+		OptProxyRoute(NewRoute(
+			OptRouteName("web"),
+			OptRouteHost("web.local"),
+			OptRouteUpstream(NewUpstream(MustParseURL(webServer.URL))),
+		)),
+		OptProxyTransformRequest(func(req *http.Request) {
+			routed = GetRoute(req.Context())
+		}),
+	)
+	assert.Nil(err)
+	assert.Len(proxy.AllUpstreams(), 2)
+
+	mockedProxy := httptest.NewServer(proxy)
+	defer mockedProxy.Close()
+
+	get := func(host, path string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, mockedProxy.URL+path, nil)
+		req.Host = host
+		res, err := http.DefaultClient.Do(req)
+		assert.Nil(err)
+		defer res.Body.Close()
+		contents, _ := ioutil.ReadAll(res.Body)
+		return res.StatusCode, string(contents)
+	}
+
+	statusCode, contents := get("localhost", "/api/users")
+	assert.Equal(http.StatusOK, statusCode)
+	assert.Equal("api /users api", contents)
+	assert.Equal("api", routed.Name)
+
+	statusCode, contents = get("web.local", "/index.html")
+	assert.Equal(http.StatusOK, statusCode)
+	assert.Equal("web /index.html ", contents)
+	assert.Equal("web", routed.Name)
+
+	statusCode, _ = get("localhost", "/index.html")
+	assert.Equal(http.StatusNotFound, statusCode)
+
+	status := proxy.Status()
+	assert.Len(status, 2)
+	assert.Equal("api", status[0].Route)
+	assert.Equal("web", status[1].Route)
+}
+
+func TestGetRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(GetRoute(context.Background()))
+	route := NewRoute()
+	assert.Equal(route, GetRoute(WithRoute(context.Background(), route)))
+}
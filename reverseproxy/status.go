@@ -0,0 +1,46 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/webutil"
+)
+
+// Status returns a snapshot of the health of each of the proxy's upstreams,
+// including the upstreams of each route.
+func (p *Proxy) Status() []UpstreamStatus {
+	status := make([]UpstreamStatus, 0, len(p.Upstreams))
+	for _, upstream := range p.Upstreams {
+		status = append(status, upstream.Status())
+	}
+	for _, route := range p.Routes {
+		for _, upstream := range route.Upstreams {
+			upstreamStatus := upstream.Status()
+			upstreamStatus.Route = route.Name
+			status = append(status, upstreamStatus)
+		}
+	}
+	return status
+}
+
+// StatusHandler writes the health of each of the proxy's upstreams as json.
+//
+// It responds with a 200 if any upstream is healthy, and a 503 otherwise.
+func (p *Proxy) StatusHandler(rw http.ResponseWriter, req *http.Request) {
+	status := p.Status()
+	statusCode := http.StatusServiceUnavailable
+	for _, upstream := range status {
+		if upstream.State == UpstreamStateHealthy {
+			statusCode = http.StatusOK
+			break
+		}
+	}
+	_ = webutil.WriteJSON(rw, statusCode, status)
+}
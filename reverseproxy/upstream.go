@@ -8,9 +8,12 @@ Use of this source code is governed by a MIT license that can be found in the LI
 package reverseproxy
 
 import (
+	"context"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -29,6 +32,9 @@ func NewUpstream(target *url.URL, opts ...UpstreamOption) *Upstream {
 	}
 	// NOTE: This creates a reference cycle `u -> rp -> u`.
 	rp.ErrorHandler = u.errorHandler
+	for _, opt := range opts {
+		opt(u)
+	}
 	return u
 }
 
@@ -42,6 +48,16 @@ type Upstream struct {
 	URL *url.URL
 	// ReverseProxy is what actually forwards requests.
 	ReverseProxy *httputil.ReverseProxy
+	// Weight is the relative weight of the upstream used by weighted resolvers.
+	Weight int
+	// PassiveHealthCheck, if set, ejects the upstream after consecutive failed requests.
+	PassiveHealthCheck *PassiveHealthCheck
+	// Timeout, if set, is the overall timeout for requests to the upstream.
+	Timeout time.Duration
+
+	active int32
+	health upstreamHealth
+	mu     sync.Mutex
 }
 
 // UseHTTP2 sets the upstream to use http2.
@@ -57,15 +73,37 @@ func (u *Upstream) UseHTTP2() error {
 	return nil
 }
 
+// ActiveRequests returns the number of requests currently being served by the upstream.
+func (u *Upstream) ActiveRequests() int {
+	return int(atomic.LoadInt32(&u.active))
+}
+
 // ServeHTTP
 func (u *Upstream) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	clientCtx := req.Context()
+	if u.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), u.Timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
 	w := webutil.NewStatusResponseWriter(rw)
+	attempt := getProxyAttempt(req.Context())
+	statusCode := func() int {
+		if attempt != nil && attempt.err != nil {
+			return http.StatusBadGateway
+		}
+		return w.StatusCode()
+	}
+
+	atomic.AddInt32(&u.active, 1)
+	defer atomic.AddInt32(&u.active, -1)
 
 	if u.Log != nil {
 		start := time.Now()
 		defer func() {
 			wre := webutil.NewHTTPRequestEvent(req,
-				webutil.OptHTTPRequestStatusCode(w.StatusCode()),
+				webutil.OptHTTPRequestStatusCode(statusCode()),
 				webutil.OptHTTPRequestContentLength(w.ContentLength()),
 				webutil.OptHTTPRequestElapsed(time.Since(start)),
 			)
@@ -80,12 +118,28 @@ func (u *Upstream) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}()
 	}
 	u.ReverseProxy.ServeHTTP(w, req)
+	// a client that hangs up mid request says nothing about the health of the upstream.
+	if clientCtx.Err() != nil {
+		return
+	}
+	u.reportResult(statusCode())
 }
 
 // errorHandler is intended to be used as an `(net/http/httputil).ReverseProxy.ErrorHandler`
 // This implementation is based on:
 // https://github.com/golang/go/blob/go1.13.6/src/net/http/httputil/reverseproxy.go#L151-L154
+//
+// If the request is an attempt that can be retried against another upstream, the error is
+// recorded on the attempt and no response is written. Timeouts return a 504 (Gateway Timeout).
 func (u *Upstream) errorHandler(rw http.ResponseWriter, req *http.Request, err error) {
 	logger.MaybeErrorfContext(req.Context(), u.Log, "http: proxy error: %v", err)
+	if attempt := getProxyAttempt(req.Context()); attempt != nil && attempt.retryable {
+		attempt.err = err
+		return
+	}
+	if isTimeout(err) {
+		rw.WriteHeader(http.StatusGatewayTimeout)
+		return
+	}
 	rw.WriteHeader(http.StatusBadGateway)
 }
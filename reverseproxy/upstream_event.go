@@ -0,0 +1,79 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/blend/go-sdk/logger"
+)
+
+const (
+	// FlagUpstreamState is a logger flag for upstream health state changes.
+	FlagUpstreamState = "proxy.upstream.state"
+)
+
+// NewUpstreamStateEvent returns a new upstream state event.
+func NewUpstreamStateEvent(upstream string, from, to UpstreamState, reason string) UpstreamStateEvent {
+	return UpstreamStateEvent{
+		Upstream: upstream,
+		From:     from,
+		To:       to,
+		Reason:   reason,
+	}
+}
+
+// NewUpstreamStateEventListener returns a new upstream state event listener.
+func NewUpstreamStateEventListener(listener func(context.Context, UpstreamStateEvent)) logger.Listener {
+	return func(ctx context.Context, e logger.Event) {
+		if typed, isTyped := e.(UpstreamStateEvent); isTyped {
+			listener(ctx, typed)
+		}
+	}
+}
+
+var (
+	_ logger.Event        = (*UpstreamStateEvent)(nil)
+	_ logger.TextWritable = (*UpstreamStateEvent)(nil)
+	_ logger.JSONWritable = (*UpstreamStateEvent)(nil)
+)
+
+// UpstreamStateEvent is an upstream health state change.
+type UpstreamStateEvent struct {
+	// Upstream is the name or url of the upstream.
+	Upstream string
+	// From is the previous state.
+	From UpstreamState
+	// To is the new state.
+	To UpstreamState
+	// Reason is why the state changed.
+	Reason string
+}
+
+// GetFlag implements logger.Event.
+func (e UpstreamStateEvent) GetFlag() string { return FlagUpstreamState }
+
+// WriteText writes the event to a text writer.
+func (e UpstreamStateEvent) WriteText(tf logger.TextFormatter, wr io.Writer) {
+	fmt.Fprintf(wr, "%s %s -> %s", e.Upstream, e.From, e.To)
+	if e.Reason != "" {
+		fmt.Fprintf(wr, " (%s)", e.Reason)
+	}
+}
+
+// Decompose implements logger.JSONWritable.
+func (e UpstreamStateEvent) Decompose() map[string]interface{} {
+	return map[string]interface{}{
+		"upstream": e.Upstream,
+		"from":     string(e.From),
+		"to":       string(e.To),
+		"reason":   e.Reason,
+	}
+}
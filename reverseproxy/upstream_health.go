@@ -0,0 +1,183 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/blend/go-sdk/logger"
+)
+
+// UpstreamState is the health state of an upstream.
+type UpstreamState string
+
+// UpstreamState values.
+const (
+	// UpstreamStateHealthy means the upstream can receive requests.
+	UpstreamStateHealthy UpstreamState = "healthy"
+	// UpstreamStateUnhealthy means the upstream is failing active health checks.
+	UpstreamStateUnhealthy UpstreamState = "unhealthy"
+	// UpstreamStateEjected means the upstream was ejected after failed requests.
+	UpstreamStateEjected UpstreamState = "ejected"
+)
+
+// PassiveHealthCheck ejects an upstream for a period of time after a number of
+// consecutive requests fail with a 5xx status or a connection error.
+type PassiveHealthCheck struct {
+	// MaxFailures is the number of consecutive failures that eject the upstream.
+	MaxFailures int
+	// EjectionDuration is how long the upstream is ejected for.
+	EjectionDuration time.Duration
+}
+
+// UpstreamStatus is a snapshot of the health of an upstream.
+type UpstreamStatus struct {
+	Name                string        `json:"name,omitempty"`
+	Route               string        `json:"route,omitempty"`
+	URL                 string        `json:"url"`
+	State               UpstreamState `json:"state"`
+	Weight              int           `json:"weight,omitempty"`
+	ActiveRequests      int           `json:"activeRequests"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	EjectedUntil        *time.Time    `json:"ejectedUntil,omitempty"`
+	LastCheck           *time.Time    `json:"lastCheck,omitempty"`
+	LastCheckError      string        `json:"lastCheckError,omitempty"`
+}
+
+// upstreamHealth is the mutable health state of an upstream.
+type upstreamHealth struct {
+	state               UpstreamState
+	unhealthy           bool
+	ejectedUntil        time.Time
+	consecutiveFailures int
+	lastCheck           time.Time
+	lastCheckError      string
+}
+
+// State returns the current health state of the upstream.
+func (u *Upstream) State() UpstreamState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.updateStateUnsafe("ejection expired")
+}
+
+// IsHealthy returns if the upstream can receive requests.
+func (u *Upstream) IsHealthy() bool {
+	return u.State() == UpstreamStateHealthy
+}
+
+// SetHealthy marks the upstream as healthy or unhealthy, e.g. from the result of active health checks.
+func (u *Upstream) SetHealthy(healthy bool, reason string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.health.unhealthy = !healthy
+	u.updateStateUnsafe(reason)
+}
+
+// Eject ejects the upstream until a given time.
+func (u *Upstream) Eject(until time.Time, reason string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.health.ejectedUntil = until
+	u.updateStateUnsafe(reason)
+}
+
+// Status returns a snapshot of the health of the upstream.
+func (u *Upstream) Status() UpstreamStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	status := UpstreamStatus{
+		Name:                u.Name,
+		State:               u.updateStateUnsafe("ejection expired"),
+		Weight:              u.Weight,
+		ActiveRequests:      u.ActiveRequests(),
+		ConsecutiveFailures: u.health.consecutiveFailures,
+		LastCheckError:      u.health.lastCheckError,
+	}
+	if u.URL != nil {
+		status.URL = u.URL.String()
+	}
+	if status.State == UpstreamStateEjected {
+		ejectedUntil := u.health.ejectedUntil
+		status.EjectedUntil = &ejectedUntil
+	}
+	if !u.health.lastCheck.IsZero() {
+		lastCheck := u.health.lastCheck
+		status.LastCheck = &lastCheck
+	}
+	return status
+}
+
+// String returns the name of the upstream, or its url if the name is unset.
+func (u *Upstream) String() string {
+	if u.Name != "" {
+		return u.Name
+	}
+	if u.URL != nil {
+		return u.URL.String()
+	}
+	return ""
+}
+
+// recordCheck records the result of an active health check.
+func (u *Upstream) recordCheck(ts time.Time, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.health.lastCheck = ts
+	if err != nil {
+		u.health.lastCheckError = err.Error()
+	} else {
+		u.health.lastCheckError = ""
+	}
+}
+
+// reportResult records the status code of a proxied request for passive health checking.
+func (u *Upstream) reportResult(statusCode int) {
+	if u.PassiveHealthCheck == nil || u.PassiveHealthCheck.MaxFailures <= 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if statusCode < http.StatusInternalServerError {
+		u.health.consecutiveFailures = 0
+		return
+	}
+	u.health.consecutiveFailures++
+	if u.health.consecutiveFailures >= u.PassiveHealthCheck.MaxFailures {
+		u.health.consecutiveFailures = 0
+		u.health.ejectedUntil = time.Now().UTC().Add(u.PassiveHealthCheck.EjectionDuration)
+		u.updateStateUnsafe(fmt.Sprintf("%d consecutive failed requests", u.PassiveHealthCheck.MaxFailures))
+	}
+}
+
+// updateStateUnsafe computes the current state, triggering an event if it changed.
+//
+// It must be called while holding the upstream lock.
+func (u *Upstream) updateStateUnsafe(reason string) UpstreamState {
+	state := UpstreamStateHealthy
+	if u.health.unhealthy {
+		state = UpstreamStateUnhealthy
+	} else if time.Now().UTC().Before(u.health.ejectedUntil) {
+		state = UpstreamStateEjected
+	}
+
+	previous := u.health.state
+	if previous == "" {
+		previous = UpstreamStateHealthy
+	}
+	u.health.state = state
+	if previous != state {
+		logger.MaybeTrigger(context.Background(), u.Log, NewUpstreamStateEvent(u.String(), previous, state, reason))
+	}
+	return state
+}
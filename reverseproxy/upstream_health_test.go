@@ -0,0 +1,171 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package reverseproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/logger"
+)
+
+func TestUpstreamPassiveHealthCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	log := logger.MustNew(logger.OptOutput(new(bytes.Buffer)), logger.OptAll())
+	defer log.Close()
+
+	var eventsMu sync.Mutex
+	var events []UpstreamStateEvent
+	done := make(chan struct{}, 2)
+	log.Listen(FlagUpstreamState, "test", NewUpstreamStateEventListener(func(_ context.Context, e UpstreamStateEvent) {
+		eventsMu.Lock()
+		events = append(events, e)
+		eventsMu.Unlock()
+		done <- struct{}{}
+	}))
+
+	u := NewUpstream(MustParseURL(srv.URL),
+		OptUpstreamName("test-upstream"),
+		OptUpstreamLog(log),
+		OptUpstreamPassiveHealthCheck(2, 50*time.Millisecond),
+	)
+	assert.Equal("test-upstream", u.Name)
+
+	serve := func(path string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		u.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve("/fail")
+	serve("/")
+	serve("/fail")
+	assert.Equal(UpstreamStateHealthy, u.State())
+	assert.Equal(1, u.Status().ConsecutiveFailures)
+
+	serve("/fail")
+	assert.Equal(UpstreamStateEjected, u.State())
+	assert.NotNil(u.Status().EjectedUntil)
+	<-done
+
+	time.Sleep(75 * time.Millisecond)
+	assert.Equal(UpstreamStateHealthy, u.State())
+	<-done
+
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	assert.Len(events, 2)
+	assert.Equal("test-upstream", events[0].Upstream)
+	assert.Equal(UpstreamStateHealthy, events[0].From)
+	assert.Equal(UpstreamStateEjected, events[0].To)
+	assert.Equal(UpstreamStateEjected, events[1].From)
+	assert.Equal(UpstreamStateHealthy, events[1].To)
+}
+
+func TestUpstreamPassiveHealthCheckConnectionError(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	srv.Close()
+
+	u := NewUpstream(MustParseURL(srv.URL), OptUpstreamPassiveHealthCheck(1, time.Minute))
+	res := httptest.NewRecorder()
+	u.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(http.StatusBadGateway, res.Code)
+	assert.Equal(UpstreamStateEjected, u.State())
+	assert.Zero(u.ActiveRequests())
+}
+
+func TestUpstreamPassiveHealthCheckClientCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	u := NewUpstream(MustParseURL(srv.URL), OptUpstreamPassiveHealthCheck(1, time.Minute))
+	for x := 0; x < 3; x++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+		u.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+		cancel()
+	}
+	assert.Equal(UpstreamStateHealthy, u.State())
+	assert.Zero(u.Status().ConsecutiveFailures)
+	assert.Zero(u.ActiveRequests())
+}
+
+func TestUpstreamStateEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	e := NewUpstreamStateEvent("test-upstream", UpstreamStateHealthy, UpstreamStateUnhealthy, "3 consecutive failed health checks")
+	assert.Equal(FlagUpstreamState, e.GetFlag())
+
+	buffer := new(bytes.Buffer)
+	e.WriteText(logger.NewTextOutputFormatter(logger.OptTextNoColor()), buffer)
+	assert.Equal("test-upstream healthy -> unhealthy (3 consecutive failed health checks)", buffer.String())
+
+	decomposed := e.Decompose()
+	assert.Equal("test-upstream", decomposed["upstream"])
+	assert.Equal("healthy", decomposed["from"])
+	assert.Equal("unhealthy", decomposed["to"])
+}
+
+func TestProxyStatusHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	upstreams := testUpstreams(2)
+	upstreams[0].Weight = 3
+	upstreams[1].SetHealthy(false, "test")
+	proxy, err := NewProxy(OptProxyUpstream(upstreams[0]), OptProxyUpstream(upstreams[1]))
+	assert.Nil(err)
+
+	res := httptest.NewRecorder()
+	proxy.StatusHandler(res, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(http.StatusOK, res.Code)
+
+	var status []UpstreamStatus
+	assert.Nil(json.Unmarshal(res.Body.Bytes(), &status))
+	assert.Len(status, 2)
+	assert.Equal(upstreams[0].URL.String(), status[0].URL)
+	assert.Equal(UpstreamStateHealthy, status[0].State)
+	assert.Equal(3, status[0].Weight)
+	assert.Equal(UpstreamStateUnhealthy, status[1].State)
+
+	upstreams[0].SetHealthy(false, "test")
+	res = httptest.NewRecorder()
+	proxy.StatusHandler(res, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(http.StatusServiceUnavailable, res.Code)
+}
@@ -10,7 +10,9 @@ package reverseproxy
 import (
 	"net"
 	"net/http"
+	"time"
 
+	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/webutil"
 )
 
@@ -32,3 +34,55 @@ func OptUpstreamDial(opts ...webutil.DialOption) UpstreamOption {
 		}
 	}
 }
+
+// OptUpstreamName sets the upstream name.
+func OptUpstreamName(name string) UpstreamOption {
+	return func(u *Upstream) {
+		u.Name = name
+	}
+}
+
+// OptUpstreamLog sets the upstream logger.
+func OptUpstreamLog(log logger.Log) UpstreamOption {
+	return func(u *Upstream) {
+		u.Log = log
+	}
+}
+
+// OptUpstreamWeight sets the upstream weight used by weighted resolvers.
+func OptUpstreamWeight(weight int) UpstreamOption {
+	return func(u *Upstream) {
+		u.Weight = weight
+	}
+}
+
+// OptUpstreamPassiveHealthCheck ejects the upstream for a given duration after
+// a number of consecutive requests fail with a 5xx status or a connection error.
+func OptUpstreamPassiveHealthCheck(maxFailures int, ejectionDuration time.Duration) UpstreamOption {
+	return func(u *Upstream) {
+		u.PassiveHealthCheck = &PassiveHealthCheck{
+			MaxFailures:      maxFailures,
+			EjectionDuration: ejectionDuration,
+		}
+	}
+}
+
+// OptUpstreamTimeout sets the overall timeout for requests to the upstream.
+func OptUpstreamTimeout(timeout time.Duration) UpstreamOption {
+	return func(u *Upstream) {
+		u.Timeout = timeout
+	}
+}
+
+// OptUpstreamResponseHeaderTimeout sets the time to wait for the upstream's response headers
+// after the request is written.
+func OptUpstreamResponseHeaderTimeout(timeout time.Duration) UpstreamOption {
+	return func(u *Upstream) {
+		if u.ReverseProxy.Transport == nil {
+			u.ReverseProxy.Transport = new(http.Transport)
+		}
+		if typed, ok := u.ReverseProxy.Transport.(*http.Transport); ok {
+			typed.ResponseHeaderTimeout = timeout
+		}
+	}
+}
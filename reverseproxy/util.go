@@ -8,9 +8,16 @@ Use of this source code is governed by a MIT license that can be found in the LI
 package reverseproxy
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"golang.org/x/net/http/httpguts"
 )
@@ -42,3 +49,51 @@ func UpgradeType(h http.Header) string {
 	}
 	return strings.ToLower(h.Get("Upgrade"))
 }
+
+// isTimeout returns if an error is a timeout.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// bufferRequestBody reads a request body of up to a given size into memory so
+// it can be replayed, returning false if the body is larger than the max size.
+//
+// If the body is too large, the request body is restored so it can still be read once.
+func bufferRequestBody(req *http.Request, maxBytes int64) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	if req.ContentLength > maxBytes {
+		return nil, false
+	}
+	body := req.Body
+	contents, err := ioutil.ReadAll(io.LimitReader(body, maxBytes+1))
+	if err != nil || int64(len(contents)) > maxBytes {
+		req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(contents), body), Closer: body}
+		return nil, false
+	}
+	_ = body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(contents))
+	return contents, true
+}
+
+// readCloser combines a reader and a closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// detachedContext is a context that keeps the values of its parent, but not its
+// cancellation or deadline, so work can outlive the request it was started for.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)          { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}                { return nil }
+func (detachedContext) Err() error                           { return nil }
+func (dc detachedContext) Value(key interface{}) interface{} { return dc.parent.Value(key) }
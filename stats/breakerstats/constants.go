@@ -0,0 +1,26 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package breakerstats
+
+// Breaker stats constants
+const (
+	MetricNameBreaker                string = "breaker"
+	MetricNameBreakerStateChange     string = MetricNameBreaker + ".state_change"
+	MetricNameBreakerState           string = MetricNameBreaker + ".state"
+	MetricNameBreakerRequests        string = MetricNameBreaker + ".requests"
+	MetricNameBreakerFailures        string = MetricNameBreaker + ".failures"
+	MetricNameBreakerWindowCalls     string = MetricNameBreaker + ".window.calls"
+	MetricNameBreakerWindowFailures  string = MetricNameBreaker + ".window.failures"
+	MetricNameBreakerWindowSlowCalls string = MetricNameBreaker + ".window.slow_calls"
+	MetricNameBreakerFailureRate     string = MetricNameBreaker + ".window.failure_rate"
+	MetricNameBreakerSlowCallRate    string = MetricNameBreaker + ".window.slow_call_rate"
+
+	TagBreaker string = "breaker"
+	TagFrom    string = "from"
+	TagTo      string = "to"
+)
@@ -0,0 +1,55 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package breakerstats
+
+import (
+	"context"
+
+	"github.com/blend/go-sdk/breaker"
+	"github.com/blend/go-sdk/stats"
+)
+
+// AddListeners adds a state change handler to a breaker that emits a state change count
+// and a state gauge, calling any existing state change handler first.
+func AddListeners(b *breaker.Breaker, collector stats.Collector, name string) {
+	if b == nil || collector == nil {
+		return
+	}
+
+	previous := b.OnStateChange
+	b.OnStateChange = func(ctx context.Context, from, to breaker.State, generation int64) {
+		if previous != nil {
+			previous(ctx, from, to, generation)
+		}
+		tag := stats.Tag(TagBreaker, name)
+		_ = collector.Increment(MetricNameBreakerStateChange, tag, stats.Tag(TagFrom, from.String()), stats.Tag(TagTo, to.String()))
+		_ = collector.Gauge(MetricNameBreakerState, float64(to), tag)
+	}
+}
+
+// Report emits gauges for a point in time snapshot of a breaker, including the counts
+// of its sliding window, e.g. from an `async.Interval`.
+func Report(ctx context.Context, b *breaker.Breaker, collector stats.Collector, name string) {
+	if b == nil || collector == nil {
+		return
+	}
+
+	snapshot := b.Snapshot(ctx)
+	tag := stats.Tag(TagBreaker, name)
+	_ = collector.Gauge(MetricNameBreakerState, float64(snapshot.State), tag)
+	_ = collector.Gauge(MetricNameBreakerRequests, float64(snapshot.Counts.Requests), tag)
+	_ = collector.Gauge(MetricNameBreakerFailures, float64(snapshot.Counts.TotalFailures), tag)
+	if b.Window == nil {
+		return
+	}
+	_ = collector.Gauge(MetricNameBreakerWindowCalls, float64(snapshot.Window.Calls), tag)
+	_ = collector.Gauge(MetricNameBreakerWindowFailures, float64(snapshot.Window.Failures), tag)
+	_ = collector.Gauge(MetricNameBreakerWindowSlowCalls, float64(snapshot.Window.SlowCalls), tag)
+	_ = collector.Gauge(MetricNameBreakerFailureRate, snapshot.Window.FailureRate(), tag)
+	_ = collector.Gauge(MetricNameBreakerSlowCallRate, snapshot.Window.SlowCallRate(), tag)
+}
@@ -0,0 +1,74 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package breakerstats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/breaker"
+	"github.com/blend/go-sdk/stats"
+)
+
+func TestAddListeners(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var previousCalled bool
+	b := breaker.MustNew(
+		breaker.OptOnStateChange(func(_ context.Context, _, _ breaker.State, _ int64) { previousCalled = true }),
+		breaker.OptWindow(breaker.NewCountWindow(2)),
+		breaker.OptMinimumCalls(2),
+		breaker.OptFailureRateThreshold(1),
+	)
+	AddListeners(nil, nil, "test")
+	collector := stats.NewMockCollector(32)
+	AddListeners(b, collector, "test")
+
+	for x := 0; x < 2; x++ {
+		_, _ = b.Do(ctx, func(_ context.Context) (interface{}, error) { return nil, fmt.Errorf("fail") })
+	}
+	assert.Equal(breaker.StateOpen, b.EvaluateState(ctx))
+	assert.True(previousCalled)
+
+	metrics := collector.AllMetrics()
+	assert.Len(metrics, 2)
+	assert.Equal(MetricNameBreakerStateChange, metrics[0].Name)
+	assert.Equal([]string{"breaker:test", "from:closed", "to:open"}, metrics[0].Tags)
+	assert.Equal(MetricNameBreakerState, metrics[1].Name)
+	assert.Equal(float64(breaker.StateOpen), metrics[1].Gauge)
+}
+
+func TestReport(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	collector := stats.NewMockCollector(32)
+	b := breaker.MustNew()
+	Report(ctx, b, collector, "test")
+	assert.Len(collector.AllMetrics(), 3)
+
+	b = breaker.MustNew(breaker.OptWindow(breaker.NewCountWindow(4)))
+	_, _ = b.Do(ctx, func(_ context.Context) (interface{}, error) { return nil, nil })
+	_, _ = b.Do(ctx, func(_ context.Context) (interface{}, error) { return nil, fmt.Errorf("fail") })
+	Report(ctx, b, collector, "test")
+
+	gauges := map[string]float64{}
+	for _, metric := range collector.AllMetrics() {
+		assert.Equal([]string{"breaker:test"}, metric.Tags)
+		gauges[metric.Name] = metric.Gauge
+	}
+	assert.Equal(float64(breaker.StateClosed), gauges[MetricNameBreakerState])
+	assert.Equal(2, gauges[MetricNameBreakerRequests])
+	assert.Equal(1, gauges[MetricNameBreakerFailures])
+	assert.Equal(2, gauges[MetricNameBreakerWindowCalls])
+	assert.Equal(0.5, gauges[MetricNameBreakerFailureRate])
+	assert.Equal(0, gauges[MetricNameBreakerSlowCallRate])
+}
@@ -13,8 +13,12 @@ import "github.com/blend/go-sdk/r2"
 const (
 	MetricNameHTTPClientRequest        string = string(r2.Flag)
 	MetricNameHTTPClientRequestElapsed string = MetricNameHTTPClientRequest + ".elapsed"
+	MetricNameHTTPClientBreaker        string = string(r2.FlagBreaker)
+	MetricNameHTTPClientBreakerState   string = MetricNameHTTPClientBreaker + ".state"
 
 	TagHostname string = "url_hostname"
 	TagMethod   string = "method"
 	TagStatus   string = "status"
+	TagFrom     string = "from"
+	TagTo       string = "to"
 )
@@ -68,4 +68,12 @@ func AddListeners(log logger.FilterListenable, collector stats.Collector, opts .
 			_ = collector.Distribution(MetricNameHTTPClientRequestElapsed, timeutil.Milliseconds(r2e.Elapsed), tags...)
 		}),
 	)
+
+	log.Listen(r2.FlagBreaker, stats.ListenerNameStats,
+		r2.NewBreakerEventListener(func(_ context.Context, be r2.BreakerEvent) {
+			hostname := stats.Tag(TagHostname, be.Host)
+			_ = collector.Increment(MetricNameHTTPClientBreaker, hostname, stats.Tag(TagFrom, be.From.String()), stats.Tag(TagTo, be.To.String()))
+			_ = collector.Gauge(MetricNameHTTPClientBreakerState, float64(be.To), hostname)
+		}),
+	)
 }
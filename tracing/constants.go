@@ -35,6 +35,10 @@ const (
 	TagKeyHTTPCode = "http.status_code"
 	// TagKeyHTTPURL is the url of the request (typically the raw path).
 	TagKeyHTTPURL = "http.url"
+	// TagKeyHTTPAttempt is the attempt number of a retried request.
+	TagKeyHTTPAttempt = "http.attempt"
+	// TagKeyHTTPMirrored is set on mirrored (shadow) copies of proxied requests.
+	TagKeyHTTPMirrored = "http.mirrored"
 	// TagKeyDBApplication is the application that uses a database.
 	TagKeyDBApplication = "db.application"
 	// TagKeyDBName is the database name.
@@ -47,6 +47,12 @@ func StartHTTPSpan(ctx context.Context, tracer opentracing.Tracer, req *http.Req
 		tracing.TagMeasured(),
 		opentracing.StartTime(startTime),
 	}
+	if attempt := webutil.GetProxyAttempt(req.Context()); attempt > 0 {
+		startOptions = append(startOptions, opentracing.Tag{Key: tracing.TagKeyHTTPAttempt, Value: attempt})
+	}
+	if webutil.IsProxyMirrored(req.Context()) {
+		startOptions = append(startOptions, opentracing.Tag{Key: tracing.TagKeyHTTPMirrored, Value: true})
+	}
 	startOptions = append(startOptions, extra...)
 
 	// try to extract an incoming span context
@@ -86,6 +86,20 @@ func TestStart(t *testing.T) {
 	assert.True(mockSpan.FinishTime.IsZero())
 }
 
+func TestStartProxyRetriedAndMirrored(t *testing.T) {
+	assert := assert.New(t)
+	mockTracer := mocktracer.New()
+	httpTracer := httptrace.Tracer(mockTracer)
+
+	req := webutil.NewMockRequest("GET", "/test-resource")
+	req = req.WithContext(webutil.WithProxyMirrored(webutil.WithProxyAttempt(req.Context(), 2)))
+	_, req = httpTracer.Start(req)
+
+	mockSpan := opentracing.SpanFromContext(req.Context()).(*mocktracer.MockSpan)
+	assert.Equal(uint(2), mockSpan.Tags()[tracing.TagKeyHTTPAttempt])
+	assert.Equal(true, mockSpan.Tags()[tracing.TagKeyHTTPMirrored])
+}
+
 func applyIncomingSpan(req *http.Request, t opentracing.Tracer, s opentracing.Span) {
 	_ = t.Inject(
 		s.Context(),
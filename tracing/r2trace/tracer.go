@@ -42,6 +42,9 @@ func (rt r2Tracer) Start(req *http.Request) r2.TraceFinisher {
 		tracing.TagMeasured(),
 		opentracing.StartTime(time.Now().UTC()),
 	}
+	if attempt := r2.GetAttempt(req.Context()); attempt > 0 {
+		startOptions = append(startOptions, opentracing.Tag{Key: tracing.TagKeyHTTPAttempt, Value: attempt})
+	}
 	span, _ := tracing.StartSpanFromContext(req.Context(), rt.tracer, tracing.OperationHTTPRequest, startOptions...)
 
 	if req.Header == nil {
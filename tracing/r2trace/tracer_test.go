@@ -66,6 +66,19 @@ func TestStartNoHeader(t *testing.T) {
 	assert.True(mockSpan.FinishTime.IsZero())
 }
 
+func TestStartAttempt(t *testing.T) {
+	assert := assert.New(t)
+	mockTracer := mocktracer.New()
+	reqTracer := Tracer(mockTracer)
+
+	req := r2.New("https://foo.com/bar", r2.OptContext(r2.WithAttempt(context.Background(), 2)))
+	rtf := reqTracer.Start(req.Request)
+
+	mockSpan := rtf.(r2TraceFinisher).span.(*mocktracer.MockSpan)
+	assert.Len(mockSpan.Tags(), 6)
+	assert.Equal(uint(2), mockSpan.Tags()[tracing.TagKeyHTTPAttempt])
+}
+
 func TestStartWithParentSpan(t *testing.T) {
 	assert := assert.New(t)
 	mockTracer := mocktracer.New()
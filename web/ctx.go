@@ -21,6 +21,7 @@ import (
 	"github.com/blend/go-sdk/ex"
 	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/reflectutil"
+	"github.com/blend/go-sdk/webutil"
 )
 
 var (
@@ -298,6 +299,36 @@ func (rc *Ctx) PostBodyAsForm(response interface{}) error {
 	}, response)
 }
 
+// Negotiate returns a result for a given status code and response value whose
+// type (json, xml, text or an html view) is chosen from the `Accept` header of the request.
+//
+// If no type is acceptable, a 406 (Not Acceptable) result is returned.
+func (rc *Ctx) Negotiate(statusCode int, response interface{}) Result {
+	if rc.Response != nil {
+		webutil.HeaderAddUnique(rc.Response.Header(), webutil.HeaderVary, webutil.HeaderAccept)
+	}
+	return NewNegotiateResultProvider(rc).Negotiate(statusCode, response)
+}
+
+// MultipartReader returns a reader that streams the parts of a multipart request body.
+//
+// Unlike `PostedFiles`, it does not buffer the contents of the parts in memory, and
+// should be used for large uploads. Errors returned by the reader, and by this method
+// if the request is not multipart, can be turned into results with `ResultForMultipartError`.
+func (rc *Ctx) MultipartReader(options ...MultipartOption) (*MultipartReader, error) {
+	reader, err := rc.Request.MultipartReader()
+	if err != nil {
+		return nil, ex.New(ErrNotMultipart, ex.OptInner(err))
+	}
+	mr := &MultipartReader{
+		reader: reader,
+	}
+	for _, option := range options {
+		option(mr)
+	}
+	return mr, nil
+}
+
 // Cookie returns a named cookie from the request.
 func (rc *Ctx) Cookie(name string) *http.Cookie {
 	cookie, err := rc.Request.Cookie(name)
@@ -0,0 +1,17 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbsession
+
+import "time"
+
+const (
+	// DefaultTableName is the default session table name.
+	DefaultTableName = "web_session"
+	// DefaultSweepInterval is the default interval expired sessions are removed on.
+	DefaultSweepInterval = 5 * time.Minute
+)
@@ -0,0 +1,26 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+/*
+Package dbsession provides a postgres backed session store for `web.AuthManager`.
+
+Sessions are persisted to a table that is created with the store's migrations:
+
+	store := dbsession.New(conn)
+	if err := store.Migrations().Apply(ctx, conn); err != nil {
+		return err
+	}
+	authManager, err := web.NewAuthManager()
+	if err != nil {
+		return err
+	}
+	store.Apply(&authManager)
+	go store.Start() // sweeps expired sessions in the background
+
+Unlike `web.LocalSessionCache`, sessions survive restarts and are shared between replicas.
+*/
+package dbsession // import "github.com/blend/go-sdk/web/dbsession"
@@ -0,0 +1,56 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbsession
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/stringutil"
+)
+
+func TestMain(m *testing.M) {
+	conn, err := db.New(
+		db.OptConfigFromEnv(),
+		db.OptSSLMode(db.SSLModeDisable),
+	)
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	if err = conn.Open(); err != nil {
+		logger.FatalExit(err)
+	}
+	defaultConnection = conn
+	defer conn.Close()
+	os.Exit(m.Run())
+}
+
+var (
+	defaultConnection *db.Connection
+)
+
+func defaultDB() *db.Connection {
+	return defaultConnection
+}
+
+// createTestStore creates a store with a random table name, and
+// returns a function that drops the table.
+func createTestStore(options ...Option) (*Store, func(), error) {
+	tableName := fmt.Sprintf("test_web_session_%s", stringutil.Random(stringutil.LowerLetters, 10))
+	store := New(defaultDB(), append([]Option{OptTableName(tableName)}, options...)...)
+	if err := store.Migrations().Apply(context.Background(), defaultDB()); err != nil {
+		return nil, nil, err
+	}
+	return store, func() {
+		_ = db.IgnoreExecResult(defaultDB().Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)))
+	}, nil
+}
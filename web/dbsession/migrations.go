@@ -0,0 +1,79 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbsession
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/blend/go-sdk/db/migration"
+)
+
+// Migrations returns the migration suite that creates the session table and its indexes.
+//
+// Each step is guarded, so the suite is safe to apply on every startup.
+func (s *Store) Migrations(options ...migration.SuiteOption) *migration.Suite {
+	_, tableName := s.schemaAndTable()
+	userIDIndex := fmt.Sprintf("ix_%s_user_id", tableName)
+	expiresUTCIndex := fmt.Sprintf("ix_%s_expires_utc", tableName)
+	table := s.tableIdentifier()
+	return migration.New(
+		append([]migration.SuiteOption{
+			migration.OptGroups(
+				migration.NewGroup(
+					migration.OptGroupActions(
+						migration.NewStep(
+							s.tableNotExists(),
+							migration.Statements(
+								fmt.Sprintf(`CREATE TABLE %s (
+	session_id varchar(255) NOT NULL PRIMARY KEY,
+	user_id varchar(255) NOT NULL,
+	base_url text NOT NULL DEFAULT '',
+	created_utc timestamp NOT NULL,
+	expires_utc timestamp,
+	user_agent text NOT NULL DEFAULT '',
+	remote_addr text NOT NULL DEFAULT '',
+	state jsonb
+)`, table),
+							),
+						),
+						migration.NewStep(
+							s.indexNotExists(userIDIndex),
+							migration.Statements(
+								fmt.Sprintf(`CREATE INDEX %s ON %s (user_id)`, pgx.Identifier{userIDIndex}.Sanitize(), table),
+							),
+						),
+						migration.NewStep(
+							s.indexNotExists(expiresUTCIndex),
+							migration.Statements(
+								fmt.Sprintf(`CREATE INDEX %s ON %s (expires_utc)`, pgx.Identifier{expiresUTCIndex}.Sanitize(), table),
+							),
+						),
+					),
+				),
+			),
+		}, options...)...,
+	)
+}
+
+// tableNotExists returns a guard that the session table does not exist, in its schema if it is schema qualified.
+func (s *Store) tableNotExists() migration.GuardFunc {
+	if schemaName, tableName := s.schemaAndTable(); schemaName != "" {
+		return migration.TableNotExistsInSchema(schemaName, tableName)
+	}
+	return migration.TableNotExists(s.TableName)
+}
+
+// indexNotExists returns a guard that an index does not exist on the session table.
+func (s *Store) indexNotExists(indexName string) migration.GuardFunc {
+	if schemaName, tableName := s.schemaAndTable(); schemaName != "" {
+		return migration.IndexNotExistsInSchema(schemaName, tableName, indexName)
+	}
+	return migration.IndexNotExists(s.TableName, indexName)
+}
@@ -0,0 +1,218 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbsession
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/blend/go-sdk/async"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/web"
+)
+
+// New returns a new session store for a given connection.
+func New(conn *db.Connection, options ...Option) *Store {
+	s := Store{
+		Conn:      conn,
+		TableName: DefaultTableName,
+	}
+	s.Sweeper = async.NewInterval(s.Sweep, DefaultSweepInterval)
+	for _, option := range options {
+		option(&s)
+	}
+	return &s
+}
+
+// Option is an option for session stores.
+type Option func(*Store)
+
+// OptTableName sets the session table name.
+func OptTableName(tableName string) Option {
+	return func(s *Store) { s.TableName = tableName }
+}
+
+// OptSweepInterval sets the interval expired sessions are removed on.
+func OptSweepInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.Sweeper = async.NewInterval(s.Sweep, d)
+	}
+}
+
+// OptLog sets the logger.
+func OptLog(log logger.Log) Option {
+	return func(s *Store) { s.Log = log }
+}
+
+// Store persists `web.Session` values to a postgres table.
+//
+// Session `State` is stored as json, so values are read back as their json types;
+// numbers are returned as `float64`, and structs as `map[string]interface{}`.
+type Store struct {
+	Conn      *db.Connection
+	TableName string
+	Log       logger.Log
+	Sweeper   *async.Interval
+}
+
+// tableIdentifier returns the quoted table name, which can be schema qualified.
+func (s *Store) tableIdentifier() string {
+	return pgx.Identifier(strings.Split(s.TableName, ".")).Sanitize()
+}
+
+// schemaAndTable splits the table name into its schema, which is empty if it is not schema qualified, and table.
+func (s *Store) schemaAndTable() (schemaName, tableName string) {
+	if index := strings.LastIndex(s.TableName, "."); index >= 0 {
+		return s.TableName[:index], s.TableName[index+1:]
+	}
+	return "", s.TableName
+}
+
+// Apply sets the fetch, persist and remove handlers on a given auth manager.
+func (s *Store) Apply(am *web.AuthManager) {
+	am.FetchHandler = s.FetchHandler
+	am.PersistHandler = s.PersistHandler
+	am.RemoveHandler = s.RemoveHandler
+}
+
+// Start starts the expired session sweeper.
+func (s *Store) Start() error {
+	return s.Sweeper.Start()
+}
+
+// NotifyStarted returns the underlying started signal.
+func (s *Store) NotifyStarted() <-chan struct{} {
+	return s.Sweeper.NotifyStarted()
+}
+
+// Stop stops the expired session sweeper.
+func (s *Store) Stop() error {
+	return s.Sweeper.Stop()
+}
+
+// NotifyStopped returns the underlying stopped signal.
+func (s *Store) NotifyStopped() <-chan struct{} {
+	return s.Sweeper.NotifyStopped()
+}
+
+// FetchHandler returns a session by id.
+//
+// It returns nil if the session does not exist or has expired.
+func (s *Store) FetchHandler(ctx context.Context, sessionID string) (*web.Session, error) {
+	var session web.Session
+	var expiresUTC sql.NullTime
+	var state []byte
+	found, err := s.Conn.Invoke(db.OptContext(ctx)).Query(
+		fmt.Sprintf(`SELECT session_id, user_id, base_url, created_utc, expires_utc, user_agent, remote_addr, state FROM %s WHERE session_id = $1 AND (expires_utc IS NULL OR expires_utc > $2)`, s.tableIdentifier()),
+		sessionID, time.Now().UTC(),
+	).Scan(
+		&session.SessionID,
+		&session.UserID,
+		&session.BaseURL,
+		&session.CreatedUTC,
+		&expiresUTC,
+		&session.UserAgent,
+		&session.RemoteAddr,
+		&state,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	if expiresUTC.Valid {
+		session.ExpiresUTC = expiresUTC.Time.UTC()
+	}
+	session.CreatedUTC = session.CreatedUTC.UTC()
+	if len(state) > 0 {
+		if err := json.Unmarshal(state, &session.State); err != nil {
+			return nil, ex.New(err)
+		}
+	}
+	return &session, nil
+}
+
+// PersistHandler inserts or updates a session.
+func (s *Store) PersistHandler(ctx context.Context, session *web.Session) error {
+	if session == nil {
+		return nil
+	}
+	var state []byte
+	if len(session.State) > 0 {
+		var err error
+		if state, err = json.Marshal(session.State); err != nil {
+			return ex.New(err)
+		}
+	}
+	var expiresUTC sql.NullTime
+	if !session.ExpiresUTC.IsZero() {
+		expiresUTC = sql.NullTime{Time: session.ExpiresUTC.UTC(), Valid: true}
+	}
+	return db.IgnoreExecResult(s.Conn.Invoke(db.OptContext(ctx)).Exec(
+		fmt.Sprintf(`INSERT INTO %s (session_id, user_id, base_url, created_utc, expires_utc, user_agent, remote_addr, state)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (session_id) DO UPDATE SET
+	user_id = EXCLUDED.user_id,
+	base_url = EXCLUDED.base_url,
+	expires_utc = EXCLUDED.expires_utc,
+	user_agent = EXCLUDED.user_agent,
+	remote_addr = EXCLUDED.remote_addr,
+	state = EXCLUDED.state`, s.tableIdentifier()),
+		session.SessionID,
+		session.UserID,
+		session.BaseURL,
+		session.CreatedUTC.UTC(),
+		expiresUTC,
+		session.UserAgent,
+		session.RemoteAddr,
+		state,
+	))
+}
+
+// RemoveHandler removes a session by id.
+func (s *Store) RemoveHandler(ctx context.Context, sessionID string) error {
+	return db.IgnoreExecResult(s.Conn.Invoke(db.OptContext(ctx)).Exec(
+		fmt.Sprintf(`DELETE FROM %s WHERE session_id = $1`, s.tableIdentifier()),
+		sessionID,
+	))
+}
+
+// RemoveAllForUser removes all the sessions for a given user, logging them out everywhere.
+//
+// It returns the number of sessions removed.
+func (s *Store) RemoveAllForUser(ctx context.Context, userID string) (int64, error) {
+	return db.ExecRowsAffected(s.Conn.Invoke(db.OptContext(ctx)).Exec(
+		fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, s.tableIdentifier()),
+		userID,
+	))
+}
+
+// Sweep removes expired sessions.
+func (s *Store) Sweep(ctx context.Context) error {
+	removed, err := db.ExecRowsAffected(s.Conn.Invoke(db.OptContext(ctx)).Exec(
+		fmt.Sprintf(`DELETE FROM %s WHERE expires_utc IS NOT NULL AND expires_utc < $1`, s.tableIdentifier()),
+		time.Now().UTC(),
+	))
+	if err != nil {
+		logger.MaybeErrorContext(ctx, s.Log, err)
+		return err
+	}
+	if removed > 0 {
+		logger.MaybeDebugfContext(ctx, s.Log, "dbsession; swept %d expired sessions", removed)
+	}
+	return nil
+}
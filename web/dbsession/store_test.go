@@ -0,0 +1,167 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package dbsession
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/stringutil"
+	"github.com/blend/go-sdk/uuid"
+	"github.com/blend/go-sdk/web"
+)
+
+func TestStorePersistFetchRemove(t *testing.T) {
+	assert := assert.New(t)
+
+	store, cleanup, err := createTestStore()
+	assert.Nil(err)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := web.NewSession("example-string", uuid.V4().String())
+	session.ExpiresUTC = time.Now().UTC().Add(time.Hour)
+	session.UserAgent = "test-user-agent"
+	session.RemoteAddr = "127.0.0.1"
+	session.State["foo"] = "bar"
+	assert.Nil(store.PersistHandler(ctx, session))
+
+	fetched, err := store.FetchHandler(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.NotNil(fetched)
+	assert.Equal(session.UserID, fetched.UserID)
+	assert.Equal(session.UserAgent, fetched.UserAgent)
+	assert.Equal(session.RemoteAddr, fetched.RemoteAddr)
+	assert.Equal("bar", fetched.State["foo"])
+	assert.InTimeDelta(session.ExpiresUTC, fetched.ExpiresUTC, time.Millisecond)
+
+	session.State["foo"] = "baz"
+	assert.Nil(store.PersistHandler(ctx, session))
+	fetched, err = store.FetchHandler(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.Equal("baz", fetched.State["foo"])
+
+	assert.Nil(store.RemoveHandler(ctx, session.SessionID))
+	fetched, err = store.FetchHandler(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.Nil(fetched)
+}
+
+func TestStoreFetchExpired(t *testing.T) {
+	assert := assert.New(t)
+
+	store, cleanup, err := createTestStore()
+	assert.Nil(err)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := web.NewSession("example-string", uuid.V4().String())
+	session.ExpiresUTC = time.Now().UTC().Add(-time.Hour)
+	assert.Nil(store.PersistHandler(ctx, session))
+
+	fetched, err := store.FetchHandler(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.Nil(fetched)
+
+	assert.Nil(store.Sweep(ctx))
+	var count int
+	_, err = defaultDB().Query("SELECT count(*) FROM " + store.TableName).Scan(&count)
+	assert.Nil(err)
+	assert.Zero(count)
+}
+
+func TestStoreRemoveAllForUser(t *testing.T) {
+	assert := assert.New(t)
+
+	store, cleanup, err := createTestStore()
+	assert.Nil(err)
+	defer cleanup()
+
+	ctx := context.Background()
+	assert.Nil(store.PersistHandler(ctx, web.NewSession("user-one", uuid.V4().String())))
+	assert.Nil(store.PersistHandler(ctx, web.NewSession("user-one", uuid.V4().String())))
+	other := web.NewSession("user-two", uuid.V4().String())
+	assert.Nil(store.PersistHandler(ctx, other))
+
+	removed, err := store.RemoveAllForUser(ctx, "user-one")
+	assert.Nil(err)
+	assert.Equal(2, removed)
+
+	fetched, err := store.FetchHandler(ctx, other.SessionID)
+	assert.Nil(err)
+	assert.NotNil(fetched)
+}
+
+func TestStoreMigrationsRerunnable(t *testing.T) {
+	assert := assert.New(t)
+
+	store, cleanup, err := createTestStore()
+	assert.Nil(err)
+	defer cleanup()
+
+	suite := store.Migrations()
+	assert.Nil(suite.Apply(context.Background(), defaultDB()))
+	assert.Zero(suite.Applied)
+	assert.Equal(3, suite.Skipped)
+}
+
+func TestStoreMigrationsRerunnableInSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	schemaName := fmt.Sprintf("test_web_session_schema_%s", stringutil.Random(stringutil.LowerLetters, 10))
+	assert.Nil(db.IgnoreExecResult(defaultDB().Exec(fmt.Sprintf("CREATE SCHEMA %s", schemaName))))
+	defer func() {
+		_ = db.IgnoreExecResult(defaultDB().Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", schemaName)))
+	}()
+
+	store := New(defaultDB(), OptTableName(schemaName+".sessions"))
+	suite := store.Migrations()
+	assert.Nil(suite.Apply(context.Background(), defaultDB()))
+	assert.Equal(3, suite.Applied)
+
+	suite = store.Migrations()
+	assert.Nil(suite.Apply(context.Background(), defaultDB()))
+	assert.Zero(suite.Applied)
+	assert.Equal(3, suite.Skipped)
+}
+
+func TestStoreApply(t *testing.T) {
+	assert := assert.New(t)
+
+	store := New(defaultDB())
+	var am web.AuthManager
+	store.Apply(&am)
+	assert.NotNil(am.FetchHandler)
+	assert.NotNil(am.PersistHandler)
+	assert.NotNil(am.RemoveHandler)
+	assert.Equal(DefaultTableName, store.TableName)
+}
+
+func TestStoreTableIdentifier(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`"sessions"`, New(nil, OptTableName("sessions")).tableIdentifier())
+	assert.Equal(`"auth"."sessions"`, New(nil, OptTableName("auth.sessions")).tableIdentifier())
+	assert.Equal(`"sessions; DROP TABLE users"`, New(nil, OptTableName("sessions; DROP TABLE users")).tableIdentifier())
+}
+
+func TestStoreSchemaAndTable(t *testing.T) {
+	assert := assert.New(t)
+
+	schemaName, tableName := New(nil, OptTableName("sessions")).schemaAndTable()
+	assert.Empty(schemaName)
+	assert.Equal("sessions", tableName)
+
+	schemaName, tableName = New(nil, OptTableName("auth.sessions")).schemaAndTable()
+	assert.Equal("auth", schemaName)
+	assert.Equal("sessions", tableName)
+}
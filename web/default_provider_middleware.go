@@ -7,6 +7,10 @@ Use of this source code is governed by a MIT license that can be found in the LI
 
 package web
 
+import (
+	"github.com/blend/go-sdk/webutil"
+)
+
 // ViewProviderAsDefault sets the context.DefaultResultProvider() equal to context.View().
 func ViewProviderAsDefault(action Action) Action {
 	return func(ctx *Ctx) Result {
@@ -38,3 +42,15 @@ func TextProviderAsDefault(action Action) Action {
 		return action(ctx)
 	}
 }
+
+// NegotiateProviderAsDefault sets the context.DefaultResultProvider() equal to a
+// result provider that negotiates the result type from the `Accept` header.
+func NegotiateProviderAsDefault(action Action) Action {
+	return func(ctx *Ctx) Result {
+		ctx.DefaultProvider = NewNegotiateResultProvider(ctx)
+		if ctx.Response != nil {
+			webutil.HeaderAddUnique(ctx.Response.Header(), webutil.HeaderVary, webutil.HeaderAccept)
+		}
+		return action(ctx)
+	}
+}
@@ -33,6 +33,18 @@ func TestDefaultProviderMiddlewares(t *testing.T) {
 	r = applyMiddleware(TextProviderAsDefault)
 	_, ok = r.DefaultProvider.(TextResultProvider)
 	assert.True(ok)
+
+	r = applyMiddleware(NegotiateProviderAsDefault)
+	_, ok = r.DefaultProvider.(*NegotiateResultProvider)
+	assert.True(ok)
+	assert.Equal(webutil.HeaderAccept, r.Response.Header().Get(webutil.HeaderVary))
+
+	// negotiating within the middleware should not repeat the vary header
+	r.Negotiate(200, "foo")
+	assert.Equal([]string{webutil.HeaderAccept}, r.Response.Header().Values(webutil.HeaderVary))
+
+	// a nil response should not panic
+	NegotiateProviderAsDefault(func(ctx *Ctx) Result { return NoContent })(&Ctx{})
 }
 
 func applyMiddleware(middleware Middleware) (output *Ctx) {
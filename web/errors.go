@@ -23,6 +23,14 @@ const (
 	ErrParameterMissing ex.Class = "parameter is missing"
 	// ErrParameterInvalid is an error on request validation.
 	ErrParameterInvalid ex.Class = "parameter is invalid"
+	// ErrNotMultipart is an error returned when reading a multipart body from a request that is not multipart.
+	ErrNotMultipart ex.Class = "request content type is not multipart"
+	// ErrMultipartFileTooLarge is an error returned when a multipart part exceeds the max file size.
+	ErrMultipartFileTooLarge ex.Class = "multipart file too large"
+	// ErrMultipartTooLarge is an error returned when multipart parts exceed the max total size.
+	ErrMultipartTooLarge ex.Class = "multipart body too large"
+	// ErrMultipartContentTypeNotAllowed is an error returned when a multipart file has a disallowed content type.
+	ErrMultipartContentTypeNotAllowed ex.Class = "multipart file content type not allowed"
 )
 
 // NewParameterMissingError returns a new parameter missing error.
@@ -68,3 +76,19 @@ func IsErrParameterInvalid(err error) bool {
 	}
 	return ex.Is(err, ErrParameterMissing)
 }
+
+// IsErrMultipartTooLarge returns if an error is an ErrMultipartFileTooLarge or an ErrMultipartTooLarge.
+func IsErrMultipartTooLarge(err error) bool {
+	if err == nil {
+		return false
+	}
+	return ex.Is(err, ErrMultipartFileTooLarge) || ex.Is(err, ErrMultipartTooLarge)
+}
+
+// IsErrMultipartContentType returns if an error is an ErrNotMultipart or an ErrMultipartContentTypeNotAllowed.
+func IsErrMultipartContentType(err error) bool {
+	if err == nil {
+		return false
+	}
+	return ex.Is(err, ErrNotMultipart) || ex.Is(err, ErrMultipartContentTypeNotAllowed)
+}
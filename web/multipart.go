@@ -0,0 +1,228 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package web
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/fileutil"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// MultipartOption is an option for multipart readers.
+type MultipartOption func(*MultipartReader)
+
+// OptMultipartMaxFileSize sets the maximum size in bytes of any single part.
+func OptMultipartMaxFileSize(maxFileSize int64) MultipartOption {
+	return func(mr *MultipartReader) { mr.MaxFileSize = maxFileSize }
+}
+
+// OptMultipartMaxTotalSize sets the maximum size in bytes of all the parts combined.
+func OptMultipartMaxTotalSize(maxTotalSize int64) MultipartOption {
+	return func(mr *MultipartReader) { mr.MaxTotalSize = maxTotalSize }
+}
+
+// OptMultipartAllowedContentTypes sets the content types allowed for file parts.
+//
+// Content types can include wildcards, e.g. `image/*`.
+func OptMultipartAllowedContentTypes(contentTypes ...string) MultipartOption {
+	return func(mr *MultipartReader) { mr.AllowedContentTypes = contentTypes }
+}
+
+// MultipartReader reads the parts of a multipart request body as a stream
+// without buffering the contents in memory.
+type MultipartReader struct {
+	// MaxFileSize is the maximum size in bytes of any single part; if unset it is not enforced.
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size in bytes of all parts combined; if unset it is not enforced.
+	MaxTotalSize int64
+	// AllowedContentTypes are the allowed content types for file parts; if unset all are allowed.
+	AllowedContentTypes []string
+
+	reader    *multipart.Reader
+	current   *MultipartPart
+	totalRead int64
+}
+
+// NextPart returns the next part of the request body.
+//
+// It returns `io.EOF` when there are no more parts. The previous part's contents
+// are discarded when it is called, and they still count towards the total size limit.
+func (mr *MultipartReader) NextPart() (*MultipartPart, error) {
+	if mr.current != nil {
+		if err := mr.current.Close(); err != nil {
+			return nil, err
+		}
+		mr.current = nil
+	}
+	part, err := mr.reader.NextPart()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ex.New(err)
+	}
+
+	output := &MultipartPart{
+		FormName: part.FormName(),
+		FileName: part.FileName(),
+		Header:   part.Header,
+		part:     part,
+		parent:   mr,
+	}
+	mr.current = output
+	if output.IsFile() {
+		output.ContentType = part.Header.Get(webutil.HeaderContentType)
+		if output.ContentType == "" {
+			output.ContentType = webutil.ContentTypeApplicationOctetStream
+		}
+		if !mr.isAllowedContentType(output.ContentType) {
+			return nil, ex.New(ErrMultipartContentTypeNotAllowed, ex.OptMessagef("form name: %s, content type: %s", output.FormName, output.ContentType))
+		}
+	}
+	return output, nil
+}
+
+// Each calls a handler for each part of the request body.
+//
+// It stops at, and returns, the first error returned by the handler.
+func (mr *MultipartReader) Each(handler func(*MultipartPart) error) error {
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = handler(part)
+		if errClose := part.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// TotalRead returns the total number of bytes read across all parts.
+func (mr *MultipartReader) TotalRead() int64 {
+	return mr.totalRead
+}
+
+func (mr *MultipartReader) isAllowedContentType(contentType string) bool {
+	if len(mr.AllowedContentTypes) == 0 {
+		return true
+	}
+	_, ok := webutil.NegotiateContentType(strings.Join(mr.AllowedContentTypes, ","), contentType)
+	return ok
+}
+
+var (
+	_ io.ReadCloser = (*MultipartPart)(nil)
+)
+
+// MultipartPart is a single part of a multipart request body.
+//
+// It is an `io.Reader` that enforces the size limits of the reader that returned it.
+type MultipartPart struct {
+	// FormName is the form field name of the part.
+	FormName string
+	// FileName is the file name of the part, it is empty for non-file fields.
+	FileName string
+	// ContentType is the declared content type of a file part.
+	ContentType string
+	// Header is the full set of part headers.
+	Header textproto.MIMEHeader
+
+	part   *multipart.Part
+	parent *MultipartReader
+	read   int64
+	closed bool
+}
+
+// IsFile returns if the part is a file.
+func (mp *MultipartPart) IsFile() bool {
+	return mp.FileName != ""
+}
+
+// Read implements io.Reader.
+//
+// It returns an `ErrMultipartFileTooLarge` or `ErrMultipartTooLarge` error if
+// reading would exceed the reader's limits.
+func (mp *MultipartPart) Read(buffer []byte) (int, error) {
+	read, err := mp.part.Read(buffer)
+	mp.read += int64(read)
+	mp.parent.totalRead += int64(read)
+	if mp.parent.MaxFileSize > 0 && mp.read > mp.parent.MaxFileSize {
+		return read, ex.New(ErrMultipartFileTooLarge, ex.OptMessagef("form name: %s, max size: %s", mp.FormName, fileutil.FormatFileSize(mp.parent.MaxFileSize)))
+	}
+	if mp.parent.MaxTotalSize > 0 && mp.parent.totalRead > mp.parent.MaxTotalSize {
+		return read, ex.New(ErrMultipartTooLarge, ex.OptMessagef("max size: %s", fileutil.FormatFileSize(mp.parent.MaxTotalSize)))
+	}
+	return read, err
+}
+
+// Spill copies the remaining contents of the part to a temp file.
+//
+// The caller is responsible for closing the temp file, which will also delete it.
+func (mp *MultipartPart) Spill() (*fileutil.Temp, error) {
+	return fileutil.NewTempFromReader(mp)
+}
+
+// Close discards the remaining contents of the part and closes it.
+//
+// The discarded contents count towards the reader's total size limit,
+// and an `ErrMultipartTooLarge` error is returned if they exceed it.
+func (mp *MultipartPart) Close() error {
+	if mp.closed {
+		return nil
+	}
+	mp.closed = true
+	buffer := make([]byte, 32<<10)
+	for {
+		read, err := mp.part.Read(buffer)
+		mp.parent.totalRead += int64(read)
+		if mp.parent.MaxTotalSize > 0 && mp.parent.totalRead > mp.parent.MaxTotalSize {
+			return ex.New(ErrMultipartTooLarge, ex.OptMessagef("max size: %s", fileutil.FormatFileSize(mp.parent.MaxTotalSize)))
+		}
+		if err == io.EOF {
+			return ex.New(mp.part.Close())
+		}
+		if err != nil {
+			return ex.New(err)
+		}
+	}
+}
+
+// ResultForMultipartError returns a result for an error returned by a multipart reader.
+//
+// Size limit errors return 413 (Request Entity Too Large), content type errors return
+// 415 (Unsupported Media Type), and any other errors return 400 (Bad Request).
+func ResultForMultipartError(rp ResultProvider, err error) Result {
+	if IsErrMultipartTooLarge(err) {
+		return rp.Status(http.StatusRequestEntityTooLarge, multipartErrorMessage(err))
+	}
+	if IsErrMultipartContentType(err) {
+		return rp.Status(http.StatusUnsupportedMediaType, multipartErrorMessage(err))
+	}
+	return rp.BadRequest(err)
+}
+
+func multipartErrorMessage(err error) string {
+	if message := ex.ErrMessage(err); message != "" {
+		return fmt.Sprintf("%v; %s", ex.ErrClass(err), message)
+	}
+	return fmt.Sprint(ex.ErrClass(err))
+}
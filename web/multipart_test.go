@@ -0,0 +1,203 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package web
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func mockMultipartCtx(t *testing.T, build func(*multipart.Writer)) *Ctx {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	build(writer)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := MockCtx("POST", "/upload", OptCtxHeaderValue(webutil.HeaderContentType, writer.FormDataContentType()))
+	ctx.Request.Body = ioutil.NopCloser(body)
+	return ctx
+}
+
+func createMultipartFile(writer *multipart.Writer, formName, fileName, contentType, contents string) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="`+formName+`"; filename="`+fileName+`"`)
+	if contentType != "" {
+		header.Set(webutil.HeaderContentType, contentType)
+	}
+	part, _ := writer.CreatePart(header)
+	_, _ = io.WriteString(part, contents)
+}
+
+func TestCtxMultipartReader(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := mockMultipartCtx(t, func(writer *multipart.Writer) {
+		_ = writer.WriteField("name", "foo")
+		createMultipartFile(writer, "upload", "test.txt", "text/plain", "this is a test")
+		createMultipartFile(writer, "other", "test.bin", "", "binary")
+	})
+
+	reader, err := ctx.MultipartReader()
+	assert.Nil(err)
+
+	var parts []*MultipartPart
+	var contents []string
+	assert.Nil(reader.Each(func(part *MultipartPart) error {
+		parts = append(parts, part)
+		partContents, err := ioutil.ReadAll(part)
+		contents = append(contents, string(partContents))
+		return err
+	}))
+	assert.Len(parts, 3)
+	assert.Equal("name", parts[0].FormName)
+	assert.False(parts[0].IsFile())
+	assert.Equal("foo", contents[0])
+	assert.Equal("upload", parts[1].FormName)
+	assert.Equal("test.txt", parts[1].FileName)
+	assert.Equal("text/plain", parts[1].ContentType)
+	assert.True(parts[1].IsFile())
+	assert.Equal("this is a test", contents[1])
+	assert.Equal(webutil.ContentTypeApplicationOctetStream, parts[2].ContentType)
+	assert.Equal("binary", contents[2])
+	assert.Equal(int64(len("foo")+len("this is a test")+len("binary")), reader.TotalRead())
+}
+
+func TestCtxMultipartReaderNotMultipart(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := MockCtx("POST", "/upload", OptCtxHeaderValue(webutil.HeaderContentType, webutil.ContentTypeApplicationJSON))
+	_, err := ctx.MultipartReader()
+	assert.True(IsErrMultipartContentType(err))
+	assert.Equal(http.StatusUnsupportedMediaType, ResultForMultipartError(Text, err).(*RawResult).StatusCode)
+}
+
+func TestMultipartReaderMaxFileSize(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := mockMultipartCtx(t, func(writer *multipart.Writer) {
+		createMultipartFile(writer, "small", "small.txt", "text/plain", "small")
+		createMultipartFile(writer, "large", "large.txt", "text/plain", strings.Repeat("a", 1024))
+	})
+	reader, err := ctx.MultipartReader(OptMultipartMaxFileSize(512))
+	assert.Nil(err)
+
+	err = reader.Each(func(part *MultipartPart) error {
+		_, err := io.Copy(ioutil.Discard, part)
+		return err
+	})
+	assert.True(IsErrMultipartTooLarge(err))
+
+	result := ResultForMultipartError(Text, err).(*RawResult)
+	assert.Equal(http.StatusRequestEntityTooLarge, result.StatusCode)
+	assert.Contains(string(result.Response), string(ErrMultipartFileTooLarge))
+}
+
+func TestMultipartReaderMaxTotalSize(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := mockMultipartCtx(t, func(writer *multipart.Writer) {
+		createMultipartFile(writer, "one", "one.txt", "text/plain", strings.Repeat("a", 300))
+		createMultipartFile(writer, "two", "two.txt", "text/plain", strings.Repeat("b", 300))
+	})
+	reader, err := ctx.MultipartReader(OptMultipartMaxFileSize(512), OptMultipartMaxTotalSize(512))
+	assert.Nil(err)
+
+	var files int
+	err = reader.Each(func(part *MultipartPart) error {
+		files++
+		_, err := io.Copy(ioutil.Discard, part)
+		return err
+	})
+	assert.Equal(2, files)
+	assert.True(IsErrMultipartTooLarge(err))
+}
+
+func TestMultipartReaderMaxTotalSizeSkippedParts(t *testing.T) {
+	assert := assert.New(t)
+
+	build := func(writer *multipart.Writer) {
+		createMultipartFile(writer, "skipped", "skipped.txt", "text/plain", strings.Repeat("a", 4096))
+		createMultipartFile(writer, "small", "small.txt", "text/plain", "small")
+	}
+
+	// skipped with Each
+	reader, err := mockMultipartCtx(t, build).MultipartReader(OptMultipartMaxTotalSize(512))
+	assert.Nil(err)
+	err = reader.Each(func(part *MultipartPart) error { return nil })
+	assert.True(IsErrMultipartTooLarge(err))
+
+	// skipped with NextPart
+	reader, err = mockMultipartCtx(t, build).MultipartReader(OptMultipartMaxTotalSize(512))
+	assert.Nil(err)
+	part, err := reader.NextPart()
+	assert.Nil(err)
+	assert.Equal("skipped", part.FormName)
+	_, err = reader.NextPart()
+	assert.True(IsErrMultipartTooLarge(err))
+	assert.True(reader.TotalRead() > 512)
+}
+
+func TestMultipartReaderAllowedContentTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := mockMultipartCtx(t, func(writer *multipart.Writer) {
+		_ = writer.WriteField("name", "foo")
+		createMultipartFile(writer, "image", "test.png", "image/png", "png")
+		createMultipartFile(writer, "script", "test.sh", "text/x-shellscript", "rm -rf /")
+	})
+	reader, err := ctx.MultipartReader(OptMultipartAllowedContentTypes("image/*", "application/pdf"))
+	assert.Nil(err)
+
+	var formNames []string
+	err = reader.Each(func(part *MultipartPart) error {
+		formNames = append(formNames, part.FormName)
+		return nil
+	})
+	assert.Equal([]string{"name", "image"}, formNames)
+	assert.True(IsErrMultipartContentType(err))
+	assert.Equal(http.StatusUnsupportedMediaType, ResultForMultipartError(JSON, err).(*JSONResult).StatusCode)
+}
+
+func TestMultipartPartSpill(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := mockMultipartCtx(t, func(writer *multipart.Writer) {
+		createMultipartFile(writer, "upload", "test.txt", "text/plain", "this is a test")
+	})
+	reader, err := ctx.MultipartReader()
+	assert.Nil(err)
+
+	part, err := reader.NextPart()
+	assert.Nil(err)
+	temp, err := part.Spill()
+	assert.Nil(err)
+	defer temp.Close()
+
+	contents, err := ioutil.ReadFile(temp.Name())
+	assert.Nil(err)
+	assert.Equal("this is a test", string(contents))
+
+	_, err = reader.NextPart()
+	assert.Equal(io.EOF, err)
+}
+
+func TestResultForMultipartErrorBadRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(http.StatusBadRequest, ResultForMultipartError(Text, io.ErrUnexpectedEOF).(*RawResult).StatusCode)
+}
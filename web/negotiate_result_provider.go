@@ -0,0 +1,118 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/blend/go-sdk/webutil"
+)
+
+var (
+	// assert negotiate implements result provider.
+	_ ResultProvider = (*NegotiateResultProvider)(nil)
+)
+
+// NewNegotiateResultProvider returns a new negotiate result provider for a given request context.
+//
+// It will offer html results only if the context has a view cache set.
+func NewNegotiateResultProvider(ctx *Ctx) *NegotiateResultProvider {
+	return &NegotiateResultProvider{
+		Request: ctx.Request,
+		Views:   ctx.Views,
+	}
+}
+
+// NegotiateResultProvider is a result provider that picks json, xml, text or
+// an html view result based on the `Accept` header of the request.
+//
+// If the request does not specify an `Accept` header, or accepts anything, json results are returned.
+type NegotiateResultProvider struct {
+	// Request is the inbound request.
+	Request *http.Request
+	// Views is the view cache used for html results, it is optional.
+	Views *ViewCache
+}
+
+// NotFound returns a negotiated not found result.
+func (nrp NegotiateResultProvider) NotFound() Result {
+	return nrp.ProviderOrDefault().NotFound()
+}
+
+// NotAuthorized returns a negotiated not authorized result.
+func (nrp NegotiateResultProvider) NotAuthorized() Result {
+	return nrp.ProviderOrDefault().NotAuthorized()
+}
+
+// InternalError returns a negotiated internal error result.
+func (nrp NegotiateResultProvider) InternalError(err error) Result {
+	return nrp.ProviderOrDefault().InternalError(err)
+}
+
+// BadRequest returns a negotiated bad request result.
+func (nrp NegotiateResultProvider) BadRequest(err error) Result {
+	return nrp.ProviderOrDefault().BadRequest(err)
+}
+
+// Status returns a negotiated status result.
+//
+// Unlike `Negotiate`, if no content type is acceptable it will return a json result.
+func (nrp NegotiateResultProvider) Status(statusCode int, response interface{}) Result {
+	return nrp.ProviderOrDefault().Status(statusCode, response)
+}
+
+// Negotiate returns a negotiated status result.
+//
+// If no content type is acceptable it returns a 406 (Not Acceptable) plaintext result.
+func (nrp NegotiateResultProvider) Negotiate(statusCode int, response interface{}) Result {
+	provider, ok := nrp.Provider()
+	if !ok {
+		return Text.Status(http.StatusNotAcceptable, nil)
+	}
+	return provider.Status(statusCode, response)
+}
+
+// ProviderOrDefault returns the negotiated result provider, or the json result provider
+// if no content type is acceptable.
+func (nrp NegotiateResultProvider) ProviderOrDefault() ResultProvider {
+	if provider, ok := nrp.Provider(); ok {
+		return provider
+	}
+	return JSON
+}
+
+// Provider returns the negotiated result provider and if one was acceptable.
+func (nrp NegotiateResultProvider) Provider() (ResultProvider, bool) {
+	contentType, ok := webutil.NegotiateContentTypeFromRequest(nrp.Request, nrp.Offers()...)
+	if !ok {
+		return nil, false
+	}
+	switch contentType {
+	case webutil.ContentTypeApplicationXML, webutil.ContentTypeXML:
+		return XML, true
+	case webutil.ContentTypeHTML:
+		return nrp.Views, true
+	case webutil.ContentTypeText:
+		return Text, true
+	default:
+		return JSON, true
+	}
+}
+
+// Offers returns the content types the provider can produce, in order of preference.
+func (nrp NegotiateResultProvider) Offers() []string {
+	offers := []string{
+		webutil.ContentTypeApplicationJSON,
+		webutil.ContentTypeApplicationXML,
+		webutil.ContentTypeXML,
+	}
+	if nrp.Views != nil {
+		offers = append(offers, webutil.ContentTypeHTML)
+	}
+	return append(offers, webutil.ContentTypeText)
+}
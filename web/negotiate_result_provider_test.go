@@ -0,0 +1,82 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestNegotiateResultProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	vc := MustNewViewCache()
+	assert.Nil(vc.Initialize())
+
+	negotiate := func(accept string) *NegotiateResultProvider {
+		return NewNegotiateResultProvider(MockCtx("GET", "/", OptCtxViews(vc), OptCtxHeaderValue(webutil.HeaderAccept, accept)))
+	}
+
+	_, ok := negotiate("").NotFound().(*JSONResult)
+	assert.True(ok)
+	_, ok = negotiate("*/*").NotAuthorized().(*JSONResult)
+	assert.True(ok)
+	_, ok = negotiate("application/xml").BadRequest(fmt.Errorf("bad-request")).(*XMLResult)
+	assert.True(ok)
+	_, ok = negotiate("text/xml").Status(http.StatusAccepted, nil).(*XMLResult)
+	assert.True(ok)
+	_, ok = negotiate("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8").Status(http.StatusOK, "foo").(*ViewResult)
+	assert.True(ok)
+	_, ok = negotiate("text/plain").Status(http.StatusOK, "foo").(*RawResult)
+	assert.True(ok)
+	_, ok = negotiate("image/png").Status(http.StatusOK, "foo").(*JSONResult)
+	assert.True(ok)
+	_, ok = negotiate("image/png").InternalError(fmt.Errorf("internal")).(*LoggedErrorResult).Result.(*JSONResult)
+	assert.True(ok)
+
+	notAcceptable, ok := negotiate("image/png").Negotiate(http.StatusOK, "foo").(*RawResult)
+	assert.True(ok)
+	assert.Equal(http.StatusNotAcceptable, notAcceptable.StatusCode)
+
+	jsonResult, ok := negotiate("application/json").Negotiate(http.StatusCreated, "foo").(*JSONResult)
+	assert.True(ok)
+	assert.Equal(http.StatusCreated, jsonResult.StatusCode)
+	assert.Equal("foo", jsonResult.Response)
+}
+
+func TestNegotiateResultProviderWithoutViews(t *testing.T) {
+	assert := assert.New(t)
+
+	nrp := NewNegotiateResultProvider(MockCtx("GET", "/", OptCtxHeaderValue(webutil.HeaderAccept, "text/html, text/plain;q=0.5")))
+	assert.Len(nrp.Offers(), 4)
+	_, ok := nrp.Negotiate(http.StatusOK, "foo").(*RawResult)
+	assert.True(ok)
+
+	nrp = NewNegotiateResultProvider(MockCtx("GET", "/", OptCtxHeaderValue(webutil.HeaderAccept, "text/html")))
+	result, ok := nrp.Negotiate(http.StatusOK, "foo").(*RawResult)
+	assert.True(ok)
+	assert.Equal(http.StatusNotAcceptable, result.StatusCode)
+}
+
+func TestCtxNegotiate(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := new(bytes.Buffer)
+	ctx := NewCtx(webutil.NewMockResponse(buffer), webutil.NewMockRequest("GET", "/"), OptCtxHeaderValue(webutil.HeaderAccept, "application/xml"))
+	result := ctx.Negotiate(http.StatusOK, "foo")
+	assert.Nil(result.Render(ctx))
+	assert.Equal(webutil.HeaderAccept, ctx.Response.Header().Get(webutil.HeaderVary))
+	assert.Contains(buffer.String(), "foo")
+	_, ok := result.(*XMLResult)
+	assert.True(ok)
+}
@@ -0,0 +1,416 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// NewResponseCache returns a new response cache.
+//
+// By default it will only compute etags and answer conditional requests; to store
+// full responses set a cache with `OptResponseCacheStore`.
+func NewResponseCache(options ...ResponseCacheOption) *ResponseCache {
+	rc := ResponseCache{
+		keys: make(map[string]time.Time),
+	}
+	for _, option := range options {
+		option(&rc)
+	}
+	return &rc
+}
+
+// ResponseCacheOption is an option for response caches.
+type ResponseCacheOption func(*ResponseCache)
+
+// OptResponseCacheStore sets the cache used to store full responses.
+func OptResponseCacheStore(store cache.Cache) ResponseCacheOption {
+	return func(rc *ResponseCache) { rc.Store = store }
+}
+
+// OptResponseCacheTTL sets the time to live for stored responses.
+func OptResponseCacheTTL(ttl time.Duration) ResponseCacheOption {
+	return func(rc *ResponseCache) { rc.TTL = ttl }
+}
+
+// OptResponseCacheVaryHeaders sets the request headers that vary stored responses.
+func OptResponseCacheVaryHeaders(headers ...string) ResponseCacheOption {
+	return func(rc *ResponseCache) { rc.VaryHeaders = headers }
+}
+
+// ResponseCache computes etags for action results, answers conditional requests
+// with 304 (Not Modified) results, and optionally stores full responses in a cache.
+//
+// Only `GET` and `HEAD` requests are considered, and only 200 (OK) responses are stored.
+// Responses that set cookies or a `Cache-Control` of `no-store` or `private` are never stored.
+type ResponseCache struct {
+	// Store is an optional cache used to store full responses.
+	Store cache.Cache
+	// TTL is the time to live for stored responses; if unset responses never expire.
+	TTL time.Duration
+	// VaryHeaders are the request headers that are included in the cache key.
+	VaryHeaders []string
+
+	keysMu      sync.Mutex
+	keys        map[string]time.Time
+	keysPruneAt int
+}
+
+// responseCacheKeysPruneThreshold is the minimum number of tracked keys before
+// keys that are no longer stored are pruned.
+const responseCacheKeysPruneThreshold = 1024
+
+// Middleware is the response cache middleware.
+func (rc *ResponseCache) Middleware(action Action) Action {
+	return func(ctx *Ctx) Result {
+		if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+			return action(ctx)
+		}
+		for _, header := range rc.VaryHeaders {
+			webutil.HeaderAddUnique(ctx.Response.Header(), webutil.HeaderVary, header)
+		}
+
+		key := rc.Key(ctx.Request)
+		if rc.Store != nil && !webutil.HeaderAny(ctx.Request.Header, webutil.HeaderCacheControl, "no-cache") {
+			if value, ok := rc.Store.Get(key); ok {
+				if cached, ok := value.(*CachedResponse); ok && !cached.IsExpired(time.Now().UTC()) {
+					return cached
+				}
+			}
+		}
+
+		cached, err := bufferAction(ctx, action)
+		if cached == nil {
+			// the action wrote its response directly, which has been copied to the response;
+			// as with unbuffered actions, errors writing it are not reported.
+			return nil
+		}
+		if err != nil {
+			return ResultWithLoggedError(cached, err)
+		}
+		if rc.Store != nil && cached.IsStorable() {
+			rc.store(key, cached)
+		}
+		return cached
+	}
+}
+
+// Key returns the cache key for a request.
+//
+// It is of the form `METHOD /path?query`, followed by the value
+// of each of the vary headers on their own line.
+func (rc *ResponseCache) Key(r *http.Request) string {
+	key := new(strings.Builder)
+	key.WriteString(r.Method)
+	key.WriteString(" ")
+	key.WriteString(r.URL.Path)
+	if query := r.URL.Query(); len(query) > 0 {
+		key.WriteString("?")
+		key.WriteString(query.Encode())
+	}
+	for _, header := range rc.VaryHeaders {
+		key.WriteString("\n")
+		key.WriteString(http.CanonicalHeaderKey(header))
+		key.WriteString(": ")
+		key.WriteString(r.Header.Get(header))
+	}
+	return key.String()
+}
+
+// Invalidate removes any stored responses whose key starts with a given prefix, e.g. `GET /users/`.
+//
+// It returns the number of responses removed.
+func (rc *ResponseCache) Invalidate(prefix string) (removed int) {
+	if rc.Store == nil {
+		return
+	}
+	var keys []string
+	rc.keysMu.Lock()
+	for key := range rc.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	rc.keysMu.Unlock()
+
+	for _, key := range keys {
+		if _, ok := rc.Store.Remove(key); ok {
+			removed++
+		}
+	}
+	return
+}
+
+func (rc *ResponseCache) store(key string, cached *CachedResponse) {
+	options := []cache.ValueOption{
+		cache.OptValueOnRemove(rc.onRemove),
+	}
+	if rc.TTL > 0 {
+		cached.Expires = time.Now().UTC().Add(rc.TTL)
+		options = append(options, cache.OptValueExpires(cached.Expires))
+	}
+	rc.keysMu.Lock()
+	if rc.keys == nil {
+		rc.keys = make(map[string]time.Time)
+	}
+	rc.keys[key] = cached.Expires
+	shouldPrune := len(rc.keys) >= rc.keysPruneAt && len(rc.keys) >= responseCacheKeysPruneThreshold
+	rc.keysMu.Unlock()
+
+	rc.Store.Set(key, cached, options...)
+	if shouldPrune {
+		rc.pruneKeys()
+	}
+}
+
+// pruneKeys stops tracking keys whose responses have expired or are no longer stored,
+// as stores do not call remove handlers for every value they drop (e.g. expired values
+// that have not been swept).
+func (rc *ResponseCache) pruneKeys() {
+	now := time.Now().UTC()
+	var candidates []string
+	rc.keysMu.Lock()
+	for key := range rc.keys {
+		candidates = append(candidates, key)
+	}
+	rc.keysMu.Unlock()
+
+	// check the store outside the lock, as it may call remove handlers.
+	var stale []string
+	for _, key := range candidates {
+		if !rc.Store.Has(key) {
+			stale = append(stale, key)
+		}
+	}
+
+	rc.keysMu.Lock()
+	defer rc.keysMu.Unlock()
+	for _, key := range stale {
+		delete(rc.keys, key)
+	}
+	for key, expires := range rc.keys {
+		if !expires.IsZero() && now.After(expires) {
+			delete(rc.keys, key)
+		}
+	}
+	rc.keysPruneAt = 2 * len(rc.keys)
+}
+
+func (rc *ResponseCache) onRemove(key interface{}, _ cache.RemovalReason) {
+	if typed, ok := key.(string); ok {
+		rc.keysMu.Lock()
+		delete(rc.keys, typed)
+		rc.keysMu.Unlock()
+	}
+}
+
+// bufferAction calls an action and renders its result (including any pre and post render steps)
+// into a cached response, computing an etag from the body if the result did not set one.
+//
+// The action and result write to a fresh set of response headers, so only the headers
+// they set are part of the cached response. If the action returns a nil result, anything
+// it wrote is copied to the response instead, and a nil cached response is returned.
+func bufferAction(ctx *Ctx, action Action) (*CachedResponse, error) {
+	buffer := newBufferedResponseWriter()
+	response := ctx.Response
+	ctx.Response = buffer
+	defer func() { ctx.Response = response }()
+
+	result := action(ctx)
+	if result == nil {
+		return nil, buffer.CopyTo(response)
+	}
+
+	var err error
+	if typed, ok := result.(ResultPreRender); ok {
+		err = typed.PreRender(ctx)
+	}
+	if err == nil {
+		err = result.Render(ctx)
+	}
+	if typed, ok := result.(ResultPostRender); ok {
+		if errPostRender := typed.PostRender(ctx); errPostRender != nil && err == nil {
+			err = errPostRender
+		}
+	}
+
+	cached := &CachedResponse{
+		StatusCode: buffer.StatusCode(),
+		Header:     buffer.Header(),
+		Body:       buffer.Bytes(),
+		ETag:       buffer.Header().Get(webutil.HeaderETag),
+	}
+	if cached.ETag == "" {
+		cached.ETag = `"` + webutil.ETag(cached.Body) + `"`
+	}
+	if lastModified := buffer.Header().Get(webutil.HeaderLastModified); lastModified != "" {
+		cached.LastModified, _ = http.ParseTime(lastModified)
+	}
+	return cached, err
+}
+
+var (
+	_ Result = (*CachedResponse)(nil)
+)
+
+// CachedResponse is a buffered response that answers conditional requests.
+type CachedResponse struct {
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+	Expires      time.Time
+}
+
+// IsExpired returns if the response has expired as of a given time.
+func (cr CachedResponse) IsExpired(now time.Time) bool {
+	return !cr.Expires.IsZero() && now.After(cr.Expires)
+}
+
+// IsStorable returns if the response can be stored in a shared cache.
+func (cr CachedResponse) IsStorable() bool {
+	if cr.StatusCode != http.StatusOK {
+		return false
+	}
+	if cr.Header.Get(webutil.HeaderSetCookie) != "" {
+		return false
+	}
+	return !webutil.HeaderAny(cr.Header, webutil.HeaderCacheControl, "no-store") &&
+		!webutil.HeaderAny(cr.Header, webutil.HeaderCacheControl, "private")
+}
+
+// IsNotModified returns if a request's conditional headers match the response.
+//
+// `If-None-Match` takes precedence over `If-Modified-Since` per RFC 7232.
+func (cr CachedResponse) IsNotModified(r *http.Request) bool {
+	if cr.StatusCode < http.StatusOK || cr.StatusCode >= http.StatusMultipleChoices {
+		return false
+	}
+	if ifNoneMatch := r.Header.Get(webutil.HeaderIfNoneMatch); ifNoneMatch != "" {
+		if cr.ETag == "" {
+			return false
+		}
+		for _, etag := range strings.Split(ifNoneMatch, ",") {
+			etag = strings.TrimSpace(etag)
+			if etag == "*" || strings.TrimPrefix(etag, "W/") == strings.TrimPrefix(cr.ETag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince := r.Header.Get(webutil.HeaderIfModifiedSince); ifModifiedSince != "" && !cr.LastModified.IsZero() {
+		ts, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		return !cr.LastModified.Truncate(time.Second).After(ts)
+	}
+	return false
+}
+
+// Render implements Result.
+func (cr CachedResponse) Render(ctx *Ctx) error {
+	header := ctx.Response.Header()
+	mergeResponseHeader(header, cr.Header)
+	if cr.ETag != "" {
+		header.Set(webutil.HeaderETag, cr.ETag)
+	}
+	if !cr.LastModified.IsZero() {
+		header.Set(webutil.HeaderLastModified, cr.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if cr.IsNotModified(ctx.Request) {
+		header.Del(webutil.HeaderContentType)
+		header.Del(webutil.HeaderContentLength)
+		ctx.Response.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	if cr.StatusCode > 0 {
+		ctx.Response.WriteHeader(cr.StatusCode)
+	}
+	_, err := ctx.Response.Write(cr.Body)
+	return err
+}
+
+// mergeResponseHeader copies headers onto a response's headers; vary headers
+// set by the middleware are merged rather than replaced.
+func mergeResponseHeader(header, from http.Header) {
+	for key, values := range from {
+		if key == webutil.HeaderVary {
+			for _, value := range values {
+				for _, piece := range strings.Split(value, ",") {
+					webutil.HeaderAddUnique(header, key, strings.TrimSpace(piece))
+				}
+			}
+			continue
+		}
+		header[key] = append([]string(nil), values...)
+	}
+}
+
+var (
+	_ ResponseWriter = (*bufferedResponseWriter)(nil)
+)
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{
+		header: make(http.Header),
+	}
+}
+
+// bufferedResponseWriter is a response writer that holds the response in memory.
+type bufferedResponseWriter struct {
+	bytes.Buffer
+	header     http.Header
+	statusCode int
+}
+
+func (brw *bufferedResponseWriter) Header() http.Header { return brw.header }
+
+func (brw *bufferedResponseWriter) Write(contents []byte) (int, error) {
+	if brw.statusCode == 0 {
+		brw.statusCode = http.StatusOK
+	}
+	return brw.Buffer.Write(contents)
+}
+
+func (brw *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if brw.statusCode == 0 {
+		brw.statusCode = statusCode
+	}
+}
+
+func (brw *bufferedResponseWriter) StatusCode() int {
+	if brw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return brw.statusCode
+}
+
+// CopyTo writes the buffered headers, status code and body to a response, if anything was written.
+func (brw *bufferedResponseWriter) CopyTo(rw http.ResponseWriter) error {
+	mergeResponseHeader(rw.Header(), brw.header)
+	if brw.statusCode == 0 && brw.Buffer.Len() == 0 {
+		return nil
+	}
+	rw.WriteHeader(brw.StatusCode())
+	_, err := rw.Write(brw.Buffer.Bytes())
+	return err
+}
+
+func (brw *bufferedResponseWriter) ContentLength() int                 { return brw.Buffer.Len() }
+func (brw *bufferedResponseWriter) InnerResponse() http.ResponseWriter { return brw }
+func (brw *bufferedResponseWriter) Flush()                             {}
+func (brw *bufferedResponseWriter) Close() error                       { return nil }
@@ -0,0 +1,247 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/cache"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func renderResponseCache(rc *ResponseCache, action Action, method, path string, options ...CtxOption) (*Ctx, *bytes.Buffer) {
+	buffer := new(bytes.Buffer)
+	ctx := MockCtxWithBuffer(method, path, buffer, options...)
+	if result := rc.Middleware(action)(ctx); result != nil {
+		_ = result.Render(ctx)
+	}
+	return ctx, buffer
+}
+
+func TestResponseCacheETag(t *testing.T) {
+	assert := assert.New(t)
+
+	rc := NewResponseCache()
+	action := func(_ *Ctx) Result { return Text.Result("hello") }
+
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal(http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal("hello", buffer.String())
+	etag := ctx.Response.Header().Get(webutil.HeaderETag)
+	assert.Equal(`"`+webutil.ETag([]byte("hello"))+`"`, etag)
+	assert.Equal(webutil.ContentTypeText, ctx.Response.Header().Get(webutil.HeaderContentType))
+
+	ctx, buffer = renderResponseCache(rc, action, "GET", "/", OptCtxHeaderValue(webutil.HeaderIfNoneMatch, etag))
+	assert.Equal(http.StatusNotModified, ctx.Response.StatusCode())
+	assert.Empty(buffer.String())
+
+	ctx, buffer = renderResponseCache(rc, action, "GET", "/", OptCtxHeaderValue(webutil.HeaderIfNoneMatch, `"other", W/`+etag))
+	assert.Equal(http.StatusNotModified, ctx.Response.StatusCode())
+
+	ctx, buffer = renderResponseCache(rc, action, "GET", "/", OptCtxHeaderValue(webutil.HeaderIfNoneMatch, `"other"`))
+	assert.Equal(http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal("hello", buffer.String())
+}
+
+func TestResponseCacheErrorResults(t *testing.T) {
+	assert := assert.New(t)
+
+	rc := NewResponseCache()
+	action := func(_ *Ctx) Result { return Text.NotFound() }
+
+	ctx, _ := renderResponseCache(rc, action, "GET", "/")
+	etag := ctx.Response.Header().Get(webutil.HeaderETag)
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/", OptCtxHeaderValue(webutil.HeaderIfNoneMatch, etag))
+	assert.Equal(http.StatusNotFound, ctx.Response.StatusCode())
+	assert.Equal("Not Found", buffer.String())
+}
+
+func TestResponseCacheStore(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int
+	modified := time.Now().UTC().Add(-time.Hour).Format(http.TimeFormat)
+	action := func(ctx *Ctx) Result {
+		calls++
+		ctx.Response.Header().Set(webutil.HeaderLastModified, modified)
+		return JSON.Result(map[string]int{"calls": calls})
+	}
+	rc := NewResponseCache(OptResponseCacheStore(cache.New()), OptResponseCacheVaryHeaders(webutil.HeaderAccept))
+
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/users/1")
+	assert.Equal(http.StatusOK, ctx.Response.StatusCode())
+	assert.Contains(buffer.String(), `"calls":1`)
+	assert.Equal(webutil.HeaderAccept, ctx.Response.Header().Get(webutil.HeaderVary))
+	assert.Equal(modified, ctx.Response.Header().Get(webutil.HeaderLastModified))
+	lastModified := ctx.Response.Header().Get(webutil.HeaderLastModified)
+
+	_, buffer = renderResponseCache(rc, action, "GET", "/users/1")
+	assert.Contains(buffer.String(), `"calls":1`)
+	assert.Equal(1, calls)
+
+	ctx, buffer = renderResponseCache(rc, action, "GET", "/users/1", OptCtxHeaderValue(webutil.HeaderIfModifiedSince, lastModified))
+	assert.Equal(http.StatusNotModified, ctx.Response.StatusCode())
+	assert.Empty(buffer.String())
+	assert.Equal(1, calls)
+
+	_, buffer = renderResponseCache(rc, action, "GET", "/users/1", OptCtxHeaderValue(webutil.HeaderAccept, webutil.ContentTypeApplicationJSON))
+	assert.Contains(buffer.String(), `"calls":2`)
+
+	_, buffer = renderResponseCache(rc, action, "GET", "/users/1", OptCtxHeaderValue(webutil.HeaderCacheControl, "no-cache"))
+	assert.Contains(buffer.String(), `"calls":3`)
+
+	_, buffer = renderResponseCache(rc, action, "POST", "/users/1")
+	assert.Contains(buffer.String(), `"calls":4`)
+
+	_, buffer = renderResponseCache(rc, action, "GET", "/users/2")
+	assert.Contains(buffer.String(), `"calls":5`)
+
+	assert.Equal(2, rc.Invalidate("GET /users/1"))
+	assert.Equal(0, rc.Invalidate("GET /users/1"))
+
+	_, buffer = renderResponseCache(rc, action, "GET", "/users/1")
+	assert.Contains(buffer.String(), `"calls":6`)
+	_, buffer = renderResponseCache(rc, action, "GET", "/users/2")
+	assert.Contains(buffer.String(), `"calls":5`)
+}
+
+func TestResponseCacheStoreTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int
+	action := func(_ *Ctx) Result {
+		calls++
+		return Text.Result(calls)
+	}
+	rc := NewResponseCache(OptResponseCacheStore(cache.New()), OptResponseCacheTTL(time.Millisecond))
+
+	_, buffer := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal("1", buffer.String())
+	time.Sleep(2 * time.Millisecond)
+	_, buffer = renderResponseCache(rc, action, "GET", "/")
+	assert.Equal("2", buffer.String())
+}
+
+func TestResponseCacheStoreSkipsUnstorable(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int
+	action := func(ctx *Ctx) Result {
+		calls++
+		ctx.Response.Header().Set(webutil.HeaderCacheControl, "private, max-age=60")
+		return Text.Result(calls)
+	}
+	rc := NewResponseCache(OptResponseCacheStore(cache.New()))
+
+	_, buffer := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal("1", buffer.String())
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal("2", buffer.String())
+	assert.Equal("private, max-age=60", ctx.Response.Header().Get(webutil.HeaderCacheControl))
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	assert := assert.New(t)
+
+	rc := NewResponseCache(OptResponseCacheVaryHeaders("accept"))
+	req := webutil.NewMockRequest("GET", "/foo")
+	req.URL.RawQuery = "b=2&a=1"
+	req.Header.Set(webutil.HeaderAccept, "text/plain")
+	assert.Equal("GET /foo?a=1&b=2\nAccept: text/plain", rc.Key(req))
+}
+
+func TestResponseCacheMergesVary(t *testing.T) {
+	assert := assert.New(t)
+
+	action := func(ctx *Ctx) Result {
+		ctx.Response.Header().Add(webutil.HeaderVary, "Cookie")
+		return Text.Result("hello")
+	}
+	rc := NewResponseCache(OptResponseCacheStore(cache.New()), OptResponseCacheVaryHeaders(webutil.HeaderAccept))
+
+	ctx, _ := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal([]string{webutil.HeaderAccept, "Cookie"}, ctx.Response.Header().Values(webutil.HeaderVary))
+
+	// served from the store
+	ctx, _ = renderResponseCache(rc, action, "GET", "/")
+	assert.Equal([]string{webutil.HeaderAccept, "Cookie"}, ctx.Response.Header().Values(webutil.HeaderVary))
+}
+
+func TestResponseCacheIfModifiedSinceWithoutStore(t *testing.T) {
+	assert := assert.New(t)
+
+	rc := NewResponseCache()
+	modified := time.Now().UTC().Add(-time.Hour).Format(http.TimeFormat)
+	action := func(ctx *Ctx) Result {
+		ctx.Response.Header().Set(webutil.HeaderLastModified, modified)
+		return Text.Result("hello")
+	}
+
+	ctx, _ := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal(modified, ctx.Response.Header().Get(webutil.HeaderLastModified))
+
+	ifModifiedSince := time.Now().UTC().Format(http.TimeFormat)
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/", OptCtxHeaderValue(webutil.HeaderIfModifiedSince, ifModifiedSince))
+	assert.Equal(http.StatusNotModified, ctx.Response.StatusCode())
+	assert.Empty(buffer.String())
+}
+
+func TestResponseCacheNoLastModifiedValidator(t *testing.T) {
+	assert := assert.New(t)
+
+	rc := NewResponseCache()
+	action := func(_ *Ctx) Result { return Text.Result("hello") }
+
+	// responses without a last modified header are not answered from if modified since.
+	ifModifiedSince := time.Now().UTC().Add(time.Minute).Format(http.TimeFormat)
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/", OptCtxHeaderValue(webutil.HeaderIfModifiedSince, ifModifiedSince))
+	assert.Empty(ctx.Response.Header().Get(webutil.HeaderLastModified))
+	assert.Equal(http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal("hello", buffer.String())
+}
+
+func TestResponseCacheNilResult(t *testing.T) {
+	assert := assert.New(t)
+
+	rc := NewResponseCache(OptResponseCacheStore(cache.New()))
+	action := func(ctx *Ctx) Result {
+		ctx.Response.Header().Set(webutil.HeaderContentType, webutil.ContentTypeText)
+		ctx.Response.WriteHeader(http.StatusAccepted)
+		_, _ = ctx.Response.Write([]byte("direct"))
+		return nil
+	}
+
+	ctx, buffer := renderResponseCache(rc, action, "GET", "/")
+	assert.Equal(http.StatusAccepted, ctx.Response.StatusCode())
+	assert.Equal(webutil.ContentTypeText, ctx.Response.Header().Get(webutil.HeaderContentType))
+	assert.Equal("direct", buffer.String())
+}
+
+func TestResponseCachePrunesKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	store := cache.New()
+	rc := NewResponseCache(OptResponseCacheStore(store), OptResponseCacheTTL(time.Millisecond))
+	action := func(_ *Ctx) Result { return Text.Result("hello") }
+
+	for x := 0; x < responseCacheKeysPruneThreshold-1; x++ {
+		renderResponseCache(rc, action, "GET", fmt.Sprintf("/%d", x))
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// expired responses are not swept, so their keys are only removed by pruning.
+	renderResponseCache(rc, action, "GET", "/last")
+	rc.keysMu.Lock()
+	defer rc.keysMu.Unlock()
+	assert.Len(rc.keys, 1)
+}
@@ -0,0 +1,56 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package webutil
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControl is a set of parsed `Cache-Control` directives.
+//
+// Directive names are lower case; directives without a value map to an empty string.
+type CacheControl map[string]string
+
+// ParseCacheControl parses the `Cache-Control` directives of a set of headers.
+func ParseCacheControl(headers http.Header) CacheControl {
+	output := make(CacheControl)
+	for _, value := range headers.Values(HeaderCacheControl) {
+		for _, directive := range strings.Split(value, ",") {
+			key, value := splitParam(directive)
+			if key == "" {
+				continue
+			}
+			output[key] = strings.Trim(value, `"`)
+		}
+	}
+	return output
+}
+
+// Has returns if a directive is present.
+func (cc CacheControl) Has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// Duration returns the value of a directive given in seconds, e.g. `max-age`.
+//
+// The returned bool is false if the directive is missing or its value is not a valid number of seconds.
+func (cc CacheControl) Duration(directive string) (time.Duration, bool) {
+	value, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,41 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package webutil
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	assert := assert.New(t)
+
+	headers := http.Header{}
+	headers.Add(HeaderCacheControl, `public, Max-Age=60`)
+	headers.Add(HeaderCacheControl, `no-cache="Set-Cookie", max-stale=bogus`)
+
+	cc := ParseCacheControl(headers)
+	assert.True(cc.Has("public"))
+	assert.True(cc.Has("no-cache"))
+	assert.False(cc.Has("no-store"))
+	assert.Equal("Set-Cookie", cc["no-cache"])
+
+	maxAge, ok := cc.Duration("max-age")
+	assert.True(ok)
+	assert.Equal(time.Minute, maxAge)
+
+	_, ok = cc.Duration("max-stale")
+	assert.False(ok)
+	_, ok = cc.Duration("s-maxage")
+	assert.False(ok)
+
+	assert.Empty(ParseCacheControl(http.Header{}))
+}
@@ -45,6 +45,7 @@ const (
 var (
 	HeaderAccept                  = http.CanonicalHeaderKey("Accept")
 	HeaderAcceptEncoding          = http.CanonicalHeaderKey("Accept-Encoding")
+	HeaderAge                     = http.CanonicalHeaderKey("Age")
 	HeaderAllow                   = http.CanonicalHeaderKey("Allow")
 	HeaderAuthorization           = http.CanonicalHeaderKey("Authorization")
 	HeaderCacheControl            = http.CanonicalHeaderKey("Cache-Control")
@@ -55,12 +56,18 @@ var (
 	HeaderCookie                  = http.CanonicalHeaderKey("Cookie")
 	HeaderDate                    = http.CanonicalHeaderKey("Date")
 	HeaderETag                    = http.CanonicalHeaderKey("etag")
+	HeaderExpires                 = http.CanonicalHeaderKey("Expires")
 	HeaderForwarded               = http.CanonicalHeaderKey("Forwarded")
+	HeaderIfModifiedSince         = http.CanonicalHeaderKey("If-Modified-Since")
+	HeaderIfNoneMatch             = http.CanonicalHeaderKey("If-None-Match")
+	HeaderLastModified            = http.CanonicalHeaderKey("Last-Modified")
+	HeaderRetryAfter              = http.CanonicalHeaderKey("Retry-After")
 	HeaderServer                  = http.CanonicalHeaderKey("Server")
 	HeaderSetCookie               = http.CanonicalHeaderKey("Set-Cookie")
 	HeaderStrictTransportSecurity = http.CanonicalHeaderKey("Strict-Transport-Security")
 	HeaderUserAgent               = http.CanonicalHeaderKey("User-Agent")
 	HeaderVary                    = http.CanonicalHeaderKey("Vary")
+	HeaderXCache                  = http.CanonicalHeaderKey("X-Cache")
 	HeaderXContentTypeOptions     = http.CanonicalHeaderKey("X-Content-Type-Options")
 	HeaderXForwardedFor           = http.CanonicalHeaderKey("X-Forwarded-For")
 	HeaderXForwardedHost          = http.CanonicalHeaderKey("X-Forwarded-Host")
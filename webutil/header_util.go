@@ -40,6 +40,18 @@ func HeaderAny(headers http.Header, key, value string) bool {
 	return false
 }
 
+// HeaderAddUnique adds a value to a csv header if no existing piece of the header matches it (case insensitive).
+func HeaderAddUnique(headers http.Header, key, value string) {
+	for _, rawHeaderValue := range headers.Values(key) {
+		for _, headerValue := range strings.Split(rawHeaderValue, ",") {
+			if strings.EqualFold(strings.TrimSpace(headerValue), value) {
+				return
+			}
+		}
+	}
+	headers.Add(key, value)
+}
+
 // Headers creates headers from a given map.
 func Headers(from map[string]string) http.Header {
 	output := make(http.Header)
@@ -24,3 +24,19 @@ func TestHeaderAny(t *testing.T) {
 	assert.True(HeaderAny(http.Header{"Foo": []string{"bar,example-string"}}, "foo", "bar"))
 	assert.True(HeaderAny(http.Header{"fuzz": []string{"buzz"}, "Foo": []string{"bar,example-string"}}, "foo", "bar"))
 }
+
+func TestHeaderAddUnique(t *testing.T) {
+	assert := assert.New(t)
+
+	headers := http.Header{}
+	HeaderAddUnique(headers, "Vary", "Accept")
+	HeaderAddUnique(headers, "Vary", "Accept")
+	assert.Equal([]string{"Accept"}, headers.Values("Vary"))
+
+	headers = http.Header{"Vary": []string{"Accept-Encoding, accept"}}
+	HeaderAddUnique(headers, "Vary", "Accept")
+	assert.Equal([]string{"Accept-Encoding, accept"}, headers.Values("Vary"))
+
+	HeaderAddUnique(headers, "Vary", "Cookie")
+	assert.Equal([]string{"Accept-Encoding, accept", "Cookie"}, headers.Values("Vary"))
+}
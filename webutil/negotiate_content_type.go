@@ -0,0 +1,173 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package webutil
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a parsed element of an `Accept` header.
+type MediaRange struct {
+	// Type is the top level type, e.g. `application` or `*`.
+	Type string
+	// Subtype is the subtype, e.g. `json` or `*`.
+	Subtype string
+	// Q is the quality (or weight) of the media range, in [0,1].
+	Q float64
+}
+
+// String returns the media range as `type/subtype`.
+func (mr MediaRange) String() string {
+	return mr.Type + "/" + mr.Subtype
+}
+
+// Specificity returns how specific the media range is.
+//
+// A full `type/subtype` is the most specific (2), a `type/*` is
+// less specific (1) and `*/*` is the least specific (0).
+func (mr MediaRange) Specificity() int {
+	if mr.Type == "*" {
+		return 0
+	}
+	if mr.Subtype == "*" {
+		return 1
+	}
+	return 2
+}
+
+// Matches returns if the media range matches a given content type.
+//
+// The content type can include parameters (e.g. `; charset=utf-8`) which are ignored.
+func (mr MediaRange) Matches(contentType string) bool {
+	contentTypeType, contentTypeSubtype := splitMediaType(contentType)
+	if mr.Type == "*" {
+		return true
+	}
+	if !strings.EqualFold(mr.Type, contentTypeType) {
+		return false
+	}
+	if mr.Subtype == "*" {
+		return true
+	}
+	return strings.EqualFold(mr.Subtype, contentTypeSubtype)
+}
+
+// ParseAccept parses an `Accept` header value into media ranges.
+//
+// The results are sorted by descending quality, then by descending specificity;
+// ranges with equal quality and specificity retain the order they were given in.
+// Malformed elements are skipped, and a missing or malformed `q` parameter is treated as 1.
+func ParseAccept(header string) (output []MediaRange) {
+	for _, element := range strings.Split(header, ",") {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+		parts := strings.Split(element, ";")
+		mediaType, mediaSubtype := splitMediaType(parts[0])
+		if mediaType == "" || mediaSubtype == "" {
+			continue
+		}
+		mediaRange := MediaRange{Type: mediaType, Subtype: mediaSubtype, Q: 1}
+		for _, param := range parts[1:] {
+			key, value := splitParam(param)
+			if key != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+				mediaRange.Q = q
+			}
+		}
+		output = append(output, mediaRange)
+	}
+	sort.SliceStable(output, func(i, j int) bool {
+		if output[i].Q != output[j].Q {
+			return output[i].Q > output[j].Q
+		}
+		return output[i].Specificity() > output[j].Specificity()
+	})
+	return
+}
+
+// NegotiateContentType returns the best content type from a list of offered content types
+// for a given `Accept` header value.
+//
+// If the header is empty, the first offer is returned. Each offer is weighted by the most
+// specific media range that matches it; offers weighted `q=0` are never returned.
+// Ties are broken by the order of the offers, i.e. the server preference.
+// If no offer is acceptable, the returned bool will be false.
+func NegotiateContentType(header string, offers ...string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0], true
+	}
+	mediaRanges := ParseAccept(header)
+
+	var best string
+	var bestQ float64
+	for _, offer := range offers {
+		q, ok := offerQuality(mediaRanges, offer)
+		if !ok || q == 0 {
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// NegotiateContentTypeFromRequest returns the best content type from a list of offered content types
+// for a given request's `Accept` header.
+func NegotiateContentTypeFromRequest(r *http.Request, offers ...string) (string, bool) {
+	if r == nil {
+		return NegotiateContentType("", offers...)
+	}
+	return NegotiateContentType(r.Header.Get(HeaderAccept), offers...)
+}
+
+// offerQuality returns the quality of the most specific media range that matches an offer.
+func offerQuality(mediaRanges []MediaRange, offer string) (q float64, ok bool) {
+	specificity := -1
+	for _, mediaRange := range mediaRanges {
+		if !mediaRange.Matches(offer) {
+			continue
+		}
+		if rangeSpecificity := mediaRange.Specificity(); rangeSpecificity > specificity {
+			q, specificity, ok = mediaRange.Q, rangeSpecificity, true
+		}
+	}
+	return
+}
+
+// splitMediaType splits a media type, ignoring parameters, into its type and subtype.
+func splitMediaType(mediaType string) (string, string) {
+	if index := strings.IndexByte(mediaType, ';'); index >= 0 {
+		mediaType = mediaType[:index]
+	}
+	mediaType = strings.TrimSpace(mediaType)
+	index := strings.IndexByte(mediaType, '/')
+	if index < 0 {
+		return "", ""
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType[:index])), strings.ToLower(strings.TrimSpace(mediaType[index+1:]))
+}
+
+// splitParam splits a `key=value` media type parameter.
+func splitParam(param string) (key, value string) {
+	index := strings.IndexByte(param, '=')
+	if index < 0 {
+		return strings.ToLower(strings.TrimSpace(param)), ""
+	}
+	return strings.ToLower(strings.TrimSpace(param[:index])), strings.TrimSpace(param[index+1:])
+}
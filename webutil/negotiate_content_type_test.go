@@ -0,0 +1,96 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package webutil
+
+import (
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestParseAccept(t *testing.T) {
+	assert := assert.New(t)
+
+	ranges := ParseAccept("text/*;q=0.3, text/html;q=0.7, text/html;level=1, */*;q=0.5, bogus")
+	assert.Len(ranges, 4)
+	assert.Equal("text/html", ranges[0].String())
+	assert.Equal(1, ranges[0].Q)
+	assert.Equal("text/html", ranges[1].String())
+	assert.Equal(0.7, ranges[1].Q)
+	assert.Equal("*/*", ranges[2].String())
+	assert.Equal(0.5, ranges[2].Q)
+	assert.Equal("text/*", ranges[3].String())
+	assert.Equal(0.3, ranges[3].Q)
+
+	assert.Empty(ParseAccept(""))
+}
+
+func TestParseAcceptSpecificity(t *testing.T) {
+	assert := assert.New(t)
+
+	ranges := ParseAccept("*/*, text/*, text/plain")
+	assert.Len(ranges, 3)
+	assert.Equal("text/plain", ranges[0].String())
+	assert.Equal("text/*", ranges[1].String())
+	assert.Equal("*/*", ranges[2].String())
+}
+
+func TestParseAcceptInvalidQ(t *testing.T) {
+	assert := assert.New(t)
+
+	ranges := ParseAccept("application/json;q=foo, text/plain;q=2")
+	assert.Len(ranges, 2)
+	assert.Equal(1, ranges[0].Q)
+	assert.Equal(1, ranges[1].Q)
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	assert := assert.New(t)
+
+	offers := []string{ContentTypeApplicationJSON, ContentTypeHTML, ContentTypeText}
+
+	testCases := [...]struct {
+		Accept   string
+		Expected string
+		OK       bool
+	}{
+		{Accept: "", Expected: ContentTypeApplicationJSON, OK: true},
+		{Accept: "*/*", Expected: ContentTypeApplicationJSON, OK: true},
+		{Accept: "text/html", Expected: ContentTypeHTML, OK: true},
+		{Accept: "TEXT/HTML", Expected: ContentTypeHTML, OK: true},
+		{Accept: "text/*", Expected: ContentTypeHTML, OK: true},
+		{Accept: "text/html;q=0.5, text/plain", Expected: ContentTypeText, OK: true},
+		{Accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", Expected: ContentTypeHTML, OK: true},
+		{Accept: "application/json;q=0, */*", Expected: ContentTypeHTML, OK: true},
+		{Accept: "image/png", Expected: "", OK: false},
+		{Accept: "*/*;q=0", Expected: "", OK: false},
+	}
+
+	for _, tc := range testCases {
+		actual, ok := NegotiateContentType(tc.Accept, offers...)
+		assert.Equal(tc.OK, ok, tc.Accept)
+		assert.Equal(tc.Expected, actual, tc.Accept)
+	}
+
+	_, ok := NegotiateContentType("*/*")
+	assert.False(ok)
+}
+
+func TestNegotiateContentTypeFromRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	req := NewMockRequest("GET", "/")
+	req.Header.Set(HeaderAccept, "application/xml")
+	contentType, ok := NegotiateContentTypeFromRequest(req, ContentTypeApplicationJSON, ContentTypeApplicationXML)
+	assert.True(ok)
+	assert.Equal(ContentTypeApplicationXML, contentType)
+
+	contentType, ok = NegotiateContentTypeFromRequest(nil, ContentTypeApplicationJSON)
+	assert.True(ok)
+	assert.Equal(ContentTypeApplicationJSON, contentType)
+}
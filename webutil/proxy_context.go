@@ -0,0 +1,50 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package webutil
+
+import (
+	"context"
+)
+
+type proxyAttemptKey struct{}
+
+// WithProxyAttempt adds the attempt number of a proxied request to a context.
+//
+// It is set by proxies that retry requests against other upstreams so that tracers can tag them.
+func WithProxyAttempt(ctx context.Context, attempt uint) context.Context {
+	return context.WithValue(ctx, proxyAttemptKey{}, attempt)
+}
+
+// GetProxyAttempt gets the attempt number of a proxied request from a context.
+//
+// Attempts start at 1; it returns 0 for requests that are not retried.
+func GetProxyAttempt(ctx context.Context) uint {
+	if value := ctx.Value(proxyAttemptKey{}); value != nil {
+		if typed, ok := value.(uint); ok {
+			return typed
+		}
+	}
+	return 0
+}
+
+type proxyMirroredKey struct{}
+
+// WithProxyMirrored marks a context as belonging to a mirrored (shadow) copy of a proxied request.
+func WithProxyMirrored(ctx context.Context) context.Context {
+	return context.WithValue(ctx, proxyMirroredKey{}, true)
+}
+
+// IsProxyMirrored returns if a context belongs to a mirrored copy of a proxied request.
+func IsProxyMirrored(ctx context.Context) bool {
+	if value := ctx.Value(proxyMirroredKey{}); value != nil {
+		if typed, ok := value.(bool); ok {
+			return typed
+		}
+	}
+	return false
+}
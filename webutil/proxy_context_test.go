@@ -0,0 +1,25 @@
+/*
+
+Copyright (c) 2021 - Present. Blend Labs, Inc. All rights reserved
+Use of this source code is governed by a MIT license that can be found in the LICENSE file.
+
+*/
+
+package webutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestProxyContext(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Zero(GetProxyAttempt(context.Background()))
+	assert.Equal(2, GetProxyAttempt(WithProxyAttempt(context.Background(), 2)))
+
+	assert.False(IsProxyMirrored(context.Background()))
+	assert.True(IsProxyMirrored(WithProxyMirrored(context.Background())))
+}